@@ -0,0 +1,53 @@
+package sdk
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/quantosnetwork/dev-0.1.0/common"
+)
+
+const (
+	addressHashSize     = 20
+	addressChecksumSize = 4
+)
+
+// Address identifies the account by its owner public key, whatever the
+// keystore file is called: the first 20 bytes of the key's SHA3 followed
+// by a 4 byte SHA3 checksum of those, base58 encoded. Renaming the account
+// keeps its address; replacing the owner keypair changes it.
+func (a *AccountInfo) Address() (string, error) {
+	kp, ok := a.Keypairs[PermOwner]
+	if !ok || kp == nil {
+		return "", fmt.Errorf("%w %v: account has no owner keypair", ErrInvalidPermission, PermOwner)
+	}
+	pub, err := decodeBase58Field("public_key", kp.PubKey)
+	if err != nil {
+		return "", err
+	}
+	return AddressFromPublicKey(pub), nil
+}
+
+// AddressFromPublicKey is the address of an owner public key in its raw
+// byte form.
+func AddressFromPublicKey(pub []byte) string {
+	payload := common.Sha3(pub)[:addressHashSize]
+	return common.EncodeBase58(append(payload, addressChecksum(payload)...))
+}
+
+// ValidateAddress checks the length and checksum of addr, catching typos
+// before a lookup.
+func ValidateAddress(addr string) error {
+	b := common.DecodeBase58(addr)
+	if len(b) != addressHashSize+addressChecksumSize {
+		return fmt.Errorf("%w %q", ErrInvalidAddress, addr)
+	}
+	payload, sum := b[:addressHashSize], b[addressHashSize:]
+	if !bytes.Equal(addressChecksum(payload), sum) {
+		return fmt.Errorf("%w %q: checksum mismatch", ErrInvalidAddress, addr)
+	}
+	return nil
+}
+
+func addressChecksum(payload []byte) []byte {
+	return common.Sha3(payload)[:addressChecksumSize]
+}
@@ -0,0 +1,103 @@
+package sdk
+
+import (
+	"encoding/hex"
+	"errors"
+	"github.com/quantosnetwork/dev-0.1.0/common"
+	"testing"
+)
+
+func TestAddressVector(t *testing.T) {
+	// the RFC 8032 test 1 key of TestImportPrivateKeyVectors
+	pub, err := hex.DecodeString("d75a980182b10ab7d54bfed3c964073a0ee172f3daa62325af021a68f707511a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	kp, err := NewWatchOnlyKeyPair(common.EncodeBase58(pub), "ed25519")
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := NewAccountInfo()
+	if err := a.AddKeyPair(PermOwner, kp); err != nil {
+		t.Fatal(err)
+	}
+	const want = "V5G7kxfE1tWQTnj3E7Q1C3dEHPiNwwd1"
+	if got, err := a.Address(); got != want || err != nil {
+		t.Fatalf("Address() = %v, %v, want %v", got, err, want)
+	}
+}
+
+func TestAddress(t *testing.T) {
+	a := NewAccountInfo()
+	a.Name = "alice"
+	if err := a.AddKeyPair(PermOwner, newTestKeyPair(t, KeyTypeEd25519)); err != nil {
+		t.Fatal(err)
+	}
+	addr, err := a.Address()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ValidateAddress(addr); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name   string
+		change func(t *testing.T, b *AccountInfo)
+		same   bool
+	}{
+		{"renamed", func(t *testing.T, b *AccountInfo) { b.Name = "bob" }, true},
+		{"encrypted", func(t *testing.T, b *AccountInfo) {
+			if err := b.EncryptWithOptions([]byte("pw"), EncryptOptions{Scrypt: fastScrypt}); err != nil {
+				t.Fatal(err)
+			}
+		}, true},
+		{"other keypair added", func(t *testing.T, b *AccountInfo) {
+			if err := b.AddKeyPair(PermActive, newTestKeyPair(t, KeyTypeEd25519)); err != nil {
+				t.Fatal(err)
+			}
+		}, true},
+		{"owner replaced", func(t *testing.T, b *AccountInfo) { b.Keypairs[PermOwner] = newTestKeyPair(t, KeyTypeP256) }, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := a.Clone()
+			tt.change(t, b)
+			got, err := b.Address()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if (got == addr) != tt.same {
+				t.Fatalf("Address() = %v, original %v, want same: %v", got, addr, tt.same)
+			}
+		})
+	}
+
+	if _, err := NewAccountInfo().Address(); !errors.Is(err, ErrInvalidPermission) {
+		t.Fatalf("Address() without an owner = %v, want %v", err, ErrInvalidPermission)
+	}
+}
+
+func TestValidateAddress(t *testing.T) {
+	addr := AddressFromPublicKey(make([]byte, 32))
+	payload := common.DecodeBase58(addr)
+	flipped := append([]byte(nil), payload...)
+	flipped[0] ^= 1
+	tests := []struct {
+		name    string
+		addr    string
+		wantErr error
+	}{
+		{"valid", addr, nil},
+		{"typo", common.EncodeBase58(flipped), ErrInvalidAddress},
+		{"truncated", common.EncodeBase58(payload[:len(payload)-1]), ErrInvalidAddress},
+		{"not base58", "0OIl", ErrInvalidAddress},
+		{"empty", "", ErrInvalidAddress},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateAddress(tt.addr); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ValidateAddress(%q) = %v, want %v", tt.addr, err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package sdk
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strings"
+	"time"
+)
+
+// addressIndex maps addresses to the keystore files holding them, as of
+// the account directory's modification time.
+type addressIndex struct {
+	modTime time.Time
+	files   map[string][]string
+}
+
+// LoadByAddress loads the account whose Address is addr. The address to
+// file index is built on first use and rebuilt whenever the account
+// directory's modification time changes, which SaveAccount, RenameAccount
+// and DeleteAccount all cause. Fails with ErrAddressNotFound if no keystore
+// has addr and with ErrAddressConflict if several do, e.g. because one was
+// copied under another name.
+func (s *FileAccountStore) LoadByAddress(addr string) (*AccountInfo, error) {
+	if err := ValidateAddress(addr); err != nil {
+		return nil, err
+	}
+	for rebuilt := false; ; rebuilt = true {
+		files, err := s.filesForAddress(addr, rebuilt)
+		if err != nil {
+			return nil, err
+		}
+		switch len(files) {
+		case 0:
+			return nil, fmt.Errorf("%w: %v in %v", ErrAddressNotFound, addr, s.AccountDir)
+		case 1:
+		default:
+			return nil, fmt.Errorf("%w: %v is held by %v", ErrAddressConflict, addr, strings.Join(files, ", "))
+		}
+		a, err := s.loadFile(files[0])
+		if err == nil {
+			var got string
+			if got, err = a.Address(); err == nil && got == addr {
+				s.audit(AuditLoad, a.Name, nil)
+				return a, nil
+			}
+			a.Wipe()
+		}
+		// the file changed without touching the directory, look again
+		if !rebuilt {
+			continue
+		}
+		if err == nil {
+			err = fmt.Errorf("%w: %v no longer holds %v", ErrAddressNotFound, s.path(files[0]), addr)
+		}
+		return nil, err
+	}
+}
+
+// filesForAddress looks addr up, refreshing the index if the directory
+// changed or force is set.
+func (s *FileAccountStore) filesForAddress(addr string, force bool) ([]string, error) {
+	info, err := s.stat("")
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, notInitializedError{err}
+	}
+	if err != nil {
+		return nil, err
+	}
+	s.addrMu.Lock()
+	defer s.addrMu.Unlock()
+	if force || s.addrIndex == nil || !s.addrIndex.modTime.Equal(info.ModTime()) {
+		idx, err := s.buildAddressIndex(info.ModTime())
+		if err != nil {
+			return nil, err
+		}
+		s.addrIndex = idx
+	}
+	return s.addrIndex.files[addr], nil
+}
+
+// buildAddressIndex parses every keystore for its owner public key.
+// Keystores that fail to load or have no owner keypair are skipped with a
+// warning, like ListAccounts does.
+func (s *FileAccountStore) buildAddressIndex(modTime time.Time) (*addressIndex, error) {
+	entries, err := s.readDir("")
+	if err != nil {
+		return nil, err
+	}
+	idx := &addressIndex{modTime: modTime, files: make(map[string][]string)}
+	for _, e := range entries {
+		if e.IsDir() || s.accountName(e.Name()) == "" {
+			continue
+		}
+		a, err := s.loadFile(e.Name())
+		if err != nil {
+			currentLogger().Warnf("indexing account %v failed: %v", s.path(e.Name()), err)
+			continue
+		}
+		addr, err := a.Address()
+		a.Wipe()
+		if err != nil {
+			currentLogger().Warnf("indexing account %v failed: %v", s.path(e.Name()), err)
+			continue
+		}
+		idx.files[addr] = append(idx.files[addr], e.Name())
+	}
+	for _, files := range idx.files {
+		sort.Strings(files)
+	}
+	return idx, nil
+}
@@ -0,0 +1,116 @@
+package sdk
+
+import (
+	"errors"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestLoadByAddress(t *testing.T) {
+	s := NewFileAccountStore(t.TempDir())
+	save := func(t *testing.T, name string) string {
+		a := NewAccountInfo()
+		a.Name = name
+		if err := a.AddKeyPair(PermOwner, newTestKeyPair(t, KeyTypeEd25519)); err != nil {
+			t.Fatal(err)
+		}
+		if err := s.SaveAccount(a); err != nil {
+			t.Fatal(err)
+		}
+		addr, err := a.Address()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return addr
+	}
+	alice, bob := save(t, "alice"), save(t, "bob")
+	var carol string
+	tests := []struct {
+		name    string
+		setup   func(t *testing.T)
+		addr    func() string
+		want    string
+		wantErr error
+	}{
+		{"hit", nil, func() string { return alice }, "alice", nil},
+		{"another hit", nil, func() string { return bob }, "bob", nil},
+		{"miss", nil, func() string { return AddressFromPublicKey(make([]byte, 32)) }, "", ErrAddressNotFound},
+		{"invalid", nil, func() string { return "0OIl" }, "", ErrInvalidAddress},
+		{"account added after indexing", func(t *testing.T) { carol = save(t, "carol") }, func() string { return carol }, "carol", nil},
+		{"renamed", func(t *testing.T) {
+			if err := s.RenameAccount("bob", "robert"); err != nil {
+				t.Fatal(err)
+			}
+		}, func() string { return bob }, "robert", nil},
+		{"deleted", func(t *testing.T) {
+			if err := s.DeleteAccount("carol"); err != nil {
+				t.Fatal(err)
+			}
+		}, func() string { return carol }, "", ErrAddressNotFound},
+		{"copied", func(t *testing.T) {
+			data, err := os.ReadFile(s.path("alice.json"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(s.path("alice-copy.json"), data, 0600); err != nil {
+				t.Fatal(err)
+			}
+		}, func() string { return alice }, "", ErrAddressConflict},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.setup != nil {
+				tt.setup(t)
+			}
+			a, err := s.LoadByAddress(tt.addr())
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("LoadByAddress() = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && a.Name != tt.want {
+				t.Fatalf("LoadByAddress() loaded %v, want %v", a.Name, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadByAddressFileChangedInPlace(t *testing.T) {
+	s := NewFileAccountStore(t.TempDir())
+	a := NewAccountInfo()
+	a.Name = "alice"
+	if err := a.AddKeyPair(PermOwner, newTestKeyPair(t, KeyTypeEd25519)); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SaveAccount(a); err != nil {
+		t.Fatal(err)
+	}
+	oldAddr, _ := a.Address()
+	if _, err := s.LoadByAddress(oldAddr); err != nil {
+		t.Fatal(err)
+	}
+
+	// swap the owner key by rewriting the file, leaving the directory's
+	// modification time as the index saw it
+	info, err := os.Stat(s.AccountDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a.Keypairs[PermOwner] = newTestKeyPair(t, KeyTypeEd25519)
+	newAddr, _ := a.Address()
+	if err := s.writeAccount(a, s.path("alice.json"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(s.AccountDir, time.Now(), info.ModTime()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.LoadByAddress(oldAddr); !errors.Is(err, ErrAddressNotFound) {
+		t.Fatalf("LoadByAddress(old) = %v, want %v", err, ErrAddressNotFound)
+	}
+	got, err := s.LoadByAddress(newAddr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Name != "alice" {
+		t.Fatalf("loaded %v", got.Name)
+	}
+}
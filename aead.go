@@ -0,0 +1,60 @@
+package sdk
+
+import (
+	"crypto/cipher"
+	"fmt"
+)
+
+// CipherAESGCM seals keypairs with AES-GCM, which authenticates associated
+// data along with the key. AccountInfo.EncryptWithOptions uses the account
+// name, permission and key type, so a keypair copied into another account
+// or permission fails to decrypt.
+const CipherAESGCM = "aes-gcm"
+
+// keyPairAAD is the associated data binding a keypair to where it lives.
+// Each part is length-prefixed so that no two placements share it.
+func keyPairAAD(account, perm string, keyType KeyType) []byte {
+	return []byte(fmt.Sprintf("quantos keypair %d:%s%d:%s%d:%s", len(account), account, len(perm), perm, len(keyType), keyType))
+}
+
+// keyPairAAD returns what the keypair of perm was sealed over, nil unless
+// it uses CipherAESGCM.
+func (a *AccountInfo) keyPairAAD(perm string, kp *KeyPairInfo) []byte {
+	if kp.Cipher != CipherAESGCM {
+		return nil
+	}
+	return keyPairAAD(a.Name, perm, kp.KeyType)
+}
+
+func (a *AccountInfo) decryptKeyPair(perm string, kp *KeyPairInfo, password []byte) error {
+	return kp.DecryptWithAAD(password, a.keyPairAAD(perm, kp))
+}
+
+// gcmSealKey encrypts plaintext with the first 12 bytes of the IV part of
+// salt as nonce. The tag is returned apart from the ciphertext so it can be
+// stored as the MAC.
+func gcmSealKey(block cipher.Block, salt, plaintext, aad []byte) ([]byte, []byte, error) {
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, nil, err
+	}
+	sealed := gcm.Seal(nil, salt[32:32+gcm.NonceSize()], plaintext, aad)
+	n := len(sealed) - gcm.Overhead()
+	return sealed[:n], sealed[n:], nil
+}
+
+func gcmOpenKey(block cipher.Block, salt, ciphertext, tag, aad []byte) ([]byte, error) {
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	if len(tag) != gcm.Overhead() {
+		return nil, fmt.Errorf("%w: gcm tag is %v bytes, want %v", ErrCorruptKeystore, len(tag), gcm.Overhead())
+	}
+	sealed := append(append([]byte{}, ciphertext...), tag...)
+	plain, err := gcm.Open(nil, salt[32:32+gcm.NonceSize()], sealed, aad)
+	if err != nil {
+		return nil, fmt.Errorf("%w, or the keypair was moved to another account or permission", ErrWrongPassword)
+	}
+	return plain, nil
+}
@@ -0,0 +1,139 @@
+package sdk
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestAESGCMBindsPlacement(t *testing.T) {
+	tests := []struct {
+		name    string
+		move    func(a *AccountInfo)
+		wantErr error
+	}{
+		{"in place", func(a *AccountInfo) {}, nil},
+		{"swapped permissions", func(a *AccountInfo) {
+			a.Keypairs[PermOwner], a.Keypairs[PermActive] = a.Keypairs[PermActive], a.Keypairs[PermOwner]
+		}, ErrWrongPassword},
+		{"moved to a new permission", func(a *AccountInfo) {
+			a.Keypairs["backup"] = a.Keypairs[PermActive]
+			delete(a.Keypairs, PermActive)
+		}, ErrWrongPassword},
+		{"copied to another account", func(a *AccountInfo) { a.Name = "mallory" }, ErrWrongPassword},
+		{"key type changed", func(a *AccountInfo) { a.Keypairs[PermActive].KeyType = KeyTypeP256 }, ErrWrongPassword},
+		{"ciphertext changed", func(a *AccountInfo) {
+			kp := a.Keypairs[PermActive]
+			kp.EncryptedKey = kp.Mac
+		}, ErrWrongPassword},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := NewAccountInfo()
+			a.Name = "alice"
+			for _, perm := range []string{PermOwner, PermActive} {
+				if err := a.AddKeyPair(perm, newTestKeyPair(t, KeyTypeEd25519)); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := a.EncryptWithOptions([]byte("pw"), EncryptOptions{Scrypt: fastScrypt, Cipher: CipherAESGCM}); err != nil {
+				t.Fatal(err)
+			}
+			tt.move(a)
+			if err := a.Decrypt([]byte("pw")); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Decrypt() = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestKeyPairAADUnambiguous(t *testing.T) {
+	placements := []struct {
+		account, perm string
+		keyType       KeyType
+	}{
+		{"alice", "owner", KeyTypeEd25519},
+		{"alic", "eowner", KeyTypeEd25519},
+		{"alice", "owne", "red25519"},
+		{"alice", "active", KeyTypeEd25519},
+		{"bob", "owner", KeyTypeEd25519},
+		{"alice", "owner", KeyTypeP256},
+	}
+	for i, p := range placements {
+		for _, q := range placements[i+1:] {
+			if bytes.Equal(keyPairAAD(p.account, p.perm, p.keyType), keyPairAAD(q.account, q.perm, q.keyType)) {
+				t.Fatalf("%+v and %+v share associated data", p, q)
+			}
+		}
+	}
+}
+
+func TestAESGCMThroughAccount(t *testing.T) {
+	a := NewAccountInfo()
+	a.Name = "alice"
+	if err := a.AddKeyPair(PermOwner, newTestKeyPair(t, KeyTypeEd25519)); err != nil {
+		t.Fatal(err)
+	}
+	raw := a.Keypairs[PermOwner].RawKey.Reveal()
+	if err := a.EncryptWithOptions([]byte("pw"), EncryptOptions{Scrypt: fastScrypt, Cipher: CipherAESGCM}); err != nil {
+		t.Fatal(err)
+	}
+	kp := a.Keypairs[PermOwner]
+	pw := func() ([]byte, error) { return []byte("pw"), nil }
+
+	// the keypair on its own cannot know what it was sealed over
+	if _, err := kp.VerifyPassword([]byte("pw")); !errors.Is(err, ErrAADRequired) {
+		t.Fatalf("VerifyPassword() = %v, want ErrAADRequired", err)
+	}
+	if _, err := NewKeyPairSigner(kp, pw).Sign([]byte("msg")); !errors.Is(err, ErrAADRequired) {
+		t.Fatalf("NewKeyPairSigner().Sign() = %v, want ErrAADRequired", err)
+	}
+	if _, err := kp.ExportV3([]byte("pw")); !errors.Is(err, ErrAADRequired) {
+		t.Fatalf("ExportV3() = %v, want ErrAADRequired", err)
+	}
+
+	for password, want := range map[string]bool{"pw": true, "wrong": false} {
+		if ok, err := a.VerifyKeyPairPassword(PermOwner, []byte(password)); ok != want || err != nil {
+			t.Fatalf("VerifyKeyPairPassword(%q) = %v, %v, want %v", password, ok, err, want)
+		}
+	}
+	s, err := a.KeyPairSigner(PermOwner, pw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig, err := s.Sign([]byte("msg"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := VerifySignature(kp.PubKey, []byte("msg"), sig); !ok || err != nil {
+		t.Fatalf("VerifySignature() = %v, %v", ok, err)
+	}
+	v3, err := a.ExportV3(PermOwner, []byte("pw"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	imported, err := ImportV3(v3, []byte("pw"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if imported.RawKey.Reveal() != raw {
+		t.Fatal("exported another key")
+	}
+	if !kp.IsEncrypted() || !kp.RawKey.IsEmpty() {
+		t.Fatal("account keypair was decrypted")
+	}
+	if _, err := a.VerifyKeyPairPassword("missing", []byte("pw")); !errors.Is(err, ErrInvalidPermission) {
+		t.Fatalf("VerifyKeyPairPassword() of a missing permission = %v, want ErrInvalidPermission", err)
+	}
+	if _, err := a.KeyPairSigner("missing", pw); !errors.Is(err, ErrInvalidPermission) {
+		t.Fatalf("KeyPairSigner() of a missing permission = %v, want ErrInvalidPermission", err)
+	}
+	if _, err := a.ExportV3("missing", []byte("pw")); !errors.Is(err, ErrInvalidPermission) {
+		t.Fatalf("ExportV3() of a missing permission = %v, want ErrInvalidPermission", err)
+	}
+
+	k := newTestKeyPair(t, KeyTypeEd25519)
+	if err := k.EncryptWithOptions([]byte("pw"), EncryptOptions{Scrypt: fastScrypt, Cipher: CipherAESGCM}); !errors.Is(err, ErrAADRequired) {
+		t.Fatalf("sealing aes-gcm without associated data = %v, want ErrAADRequired", err)
+	}
+}
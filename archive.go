@@ -0,0 +1,176 @@
+package sdk
+
+import (
+	"archive/tar"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+)
+
+// ExportAll writes every keystore file of the store to w as a tar archive.
+// Files are copied byte for byte, so encrypted keypairs stay encrypted and
+// no password is needed.
+func (s *FileAccountStore) ExportAll(w io.Writer) error {
+	return s.exportAll(w, false)
+}
+
+// ExportAllWithBackups is ExportAll including the backup directory.
+func (s *FileAccountStore) ExportAllWithBackups(w io.Writer) error {
+	return s.exportAll(w, true)
+}
+
+func (s *FileAccountStore) exportAll(w io.Writer, backups bool) error {
+	tw := tar.NewWriter(w)
+	dirs := []string{"."}
+	if backups {
+		dirs = append(dirs, "backup")
+	}
+	for _, dir := range dirs {
+		files, err := s.readDir(dir)
+		if dir == "backup" && errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		for _, f := range files {
+			if f.IsDir() || strings.HasPrefix(f.Name(), ".") || s.keystoreExt(f.Name()) == "" {
+				continue
+			}
+			if err := s.exportFile(tw, path.Join(dir, f.Name()), f); err != nil {
+				return err
+			}
+		}
+	}
+	return tw.Close()
+}
+
+func (s *FileAccountStore) exportFile(tw *tar.Writer, file string, f fs.DirEntry) error {
+	info, err := f.Info()
+	if err != nil {
+		return err
+	}
+	data, err := s.readFile(file)
+	if err != nil {
+		return err
+	}
+	hdr := &tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     file,
+		Mode:     0400,
+		Size:     int64(len(data)),
+		ModTime:  info.ModTime(),
+	}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	_, err = tw.Write(data)
+	return err
+}
+
+// ImportAll restores an archive written by ExportAll and returns how many
+// keystores it wrote. Every keystore must load cleanly and hold the account
+// its file is named after. Existing accounts are skipped unless overwrite
+// is set, in which case they are backed up first as SaveAccount would.
+// Backups in the archive are only added where no backup of that time
+// exists yet.
+func (s *FileAccountStore) ImportAll(r io.Reader, overwrite bool) (int, error) {
+	if err := s.writable(); err != nil {
+		return 0, err
+	}
+	fileMode, dirMode, err := s.modes()
+	if err != nil {
+		return 0, err
+	}
+	if err := os.MkdirAll(s.AccountDir, dirMode); err != nil {
+		return 0, err
+	}
+	tr := tar.NewReader(r)
+	imported := 0
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return imported, nil
+		}
+		if err != nil {
+			return imported, err
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		if err := checkKeystoreSize(int(hdr.Size), hdr.Name); err != nil {
+			return imported, err
+		}
+		data, err := readKeystore(tr, hdr.Name)
+		if err != nil {
+			return imported, err
+		}
+		dir, file := path.Split(path.Clean(hdr.Name))
+		switch {
+		case dir == "" && s.accountName(file) != "":
+			written, err := s.importAccountFile(file, data, overwrite, fileMode, dirMode)
+			if err != nil {
+				return imported, err
+			}
+			if written {
+				imported++
+			}
+		case dir == "backup/" && s.keystoreExt(file) != "" && !strings.HasPrefix(file, "."):
+			if err := s.importBackupFile(file, data, fileMode, dirMode); err != nil {
+				return imported, err
+			}
+		default:
+			return imported, fmt.Errorf("unexpected file %v in keystore archive", hdr.Name)
+		}
+	}
+}
+
+func (s *FileAccountStore) importAccountFile(file string, data []byte, overwrite bool, fileMode, dirMode os.FileMode) (bool, error) {
+	name := s.accountName(file)
+	a, err := s.decodeFile(data, file)
+	if err != nil {
+		return false, err
+	}
+	if a.Name != name {
+		return false, fmt.Errorf("archived keystore %v holds account %v", file, a.Name)
+	}
+	unlock, err := s.lockAccount(name)
+	if err != nil {
+		return false, err
+	}
+	defer unlock()
+	fileName := s.AccountDir + "/" + file
+	existing := s.AccountDir + "/" + s.keystoreFile(name)
+	if _, err := os.Stat(existing); !os.IsNotExist(err) {
+		if !overwrite {
+			currentLogger().Infof("account %v exists, not importing it", name)
+			return false, nil
+		}
+		if err := s.backupFile(name, existing, dirMode); err != nil {
+			return false, err
+		}
+	}
+	currentLogger().Infof("importing keyfile of account %v to %v", name, fileName)
+	err = writeFileAtomic(fileName, fileMode, bytes.NewReader(data))
+	s.audit(AuditSave, name, err)
+	return err == nil, err
+}
+
+func (s *FileAccountStore) importBackupFile(file string, data []byte, fileMode, dirMode os.FileMode) error {
+	if _, err := s.decodeFile(data, "backup/"+file); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(s.AccountDir+"/backup", dirMode); err != nil {
+		return err
+	}
+	fileName := s.AccountDir + "/backup/" + file
+	if _, err := os.Stat(fileName); !os.IsNotExist(err) {
+		return err
+	}
+	return writeFileAtomic(fileName, fileMode, bytes.NewReader(data))
+}
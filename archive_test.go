@@ -0,0 +1,182 @@
+package sdk
+
+import (
+	"archive/tar"
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// newTestStore returns a store in a temporary directory holding accounts
+// with the given names, each with an encrypted owner keypair.
+func newTestStore(t testing.TB, names ...string) *FileAccountStore {
+	t.Helper()
+	s := NewFileAccountStore(t.TempDir())
+	for _, name := range names {
+		a := NewAccountInfo()
+		a.Name = name
+		kp := newTestKeyPair(t, KeyTypeEd25519)
+		if err := kp.EncryptWithParams([]byte("pw"), fastScrypt); err != nil {
+			t.Fatal(err)
+		}
+		if err := a.AddKeyPair(PermOwner, kp); err != nil {
+			t.Fatal(err)
+		}
+		if err := s.SaveAccount(a); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return s
+}
+
+func TestExportImportAll(t *testing.T) {
+	names := []string{"alice", "bob", "carol"}
+	src := newTestStore(t, names...)
+	var buf bytes.Buffer
+	if err := src.ExportAll(&buf); err != nil {
+		t.Fatal(err)
+	}
+	archive := buf.Bytes()
+
+	dst := NewFileAccountStore(t.TempDir())
+	n, err := dst.ImportAll(bytes.NewReader(archive), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != len(names) {
+		t.Fatalf("imported %v accounts, want %v", n, len(names))
+	}
+	for _, name := range names {
+		want, err := os.ReadFile(src.path(src.keystoreFile(name)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := os.ReadFile(dst.path(dst.keystoreFile(name)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Fatalf("keystore of %v changed in the archive", name)
+		}
+		a, err := dst.LoadAccount(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !a.IsEncrypted() {
+			t.Fatalf("account %v was decrypted", name)
+		}
+	}
+
+	// existing accounts are skipped unless overwriting
+	tests := []struct {
+		overwrite bool
+		want      int
+	}{
+		{false, 0},
+		{true, len(names)},
+	}
+	for _, tt := range tests {
+		n, err := dst.ImportAll(bytes.NewReader(archive), tt.overwrite)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n != tt.want {
+			t.Fatalf("overwrite=%v imported %v accounts, want %v", tt.overwrite, n, tt.want)
+		}
+	}
+}
+
+func TestImportAllRejects(t *testing.T) {
+	keystore, err := os.ReadFile(newTestStore(t, "alice").path("alice.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name    string
+		file    string
+		data    []byte
+		wantErr error
+	}{
+		{"oversized", "alice.json", bytes.Repeat([]byte(" "), MaxKeystoreSize+1), ErrKeystoreTooLarge},
+		{"misnamed", "bob.json", keystore, nil},
+		{"unexpected file", "notes.txt", []byte("hi"), nil},
+		{"nested", "a/alice.json", keystore, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			tw := tar.NewWriter(&buf)
+			if err := tw.WriteHeader(&tar.Header{Typeflag: tar.TypeReg, Name: tt.file, Mode: 0400, Size: int64(len(tt.data))}); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := tw.Write(tt.data); err != nil {
+				t.Fatal(err)
+			}
+			if err := tw.Close(); err != nil {
+				t.Fatal(err)
+			}
+			s := NewFileAccountStore(t.TempDir())
+			n, err := s.ImportAll(&buf, false)
+			if err == nil || n != 0 {
+				t.Fatalf("ImportAll() = %v, %v, want an error", n, err)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ImportAll() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestExportAllWithBackups(t *testing.T) {
+	src := saveVersions(t, 3)
+	srcBackups, err := src.ListBackups("alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name   string
+		export func(w io.Writer) error
+		want   int
+	}{
+		{"without backups", src.ExportAll, 0},
+		{"with backups", src.ExportAllWithBackups, len(srcBackups)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := tt.export(&buf); err != nil {
+				t.Fatal(err)
+			}
+			dst := NewFileAccountStore(t.TempDir())
+			// importing twice must not duplicate backups
+			for i := 0; i < 2; i++ {
+				if _, err := dst.ImportAll(bytes.NewReader(buf.Bytes()), false); err != nil {
+					t.Fatal(err)
+				}
+			}
+			got, err := dst.ListBackups("alice")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != tt.want {
+				t.Fatalf("%v backups, want %v", len(got), tt.want)
+			}
+			for i, b := range srcBackups[:tt.want] {
+				want, err := os.ReadFile(b.Path)
+				if err != nil {
+					t.Fatal(err)
+				}
+				data, err := os.ReadFile(got[i].Path)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if filepath.Base(got[i].Path) != filepath.Base(b.Path) || !bytes.Equal(data, want) {
+					t.Fatalf("backup %v is not %v", got[i].Path, b.Path)
+				}
+			}
+		})
+	}
+}
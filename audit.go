@@ -0,0 +1,155 @@
+package sdk
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"golang.org/x/crypto/sha3"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	AuditLoad    = "load"
+	AuditSave    = "save"
+	AuditDelete  = "delete"
+	AuditRename  = "rename"
+	AuditDecrypt = "decrypt"
+)
+
+// AuditEvent is one keystore operation as reported to an AuditSink. Error
+// is empty when the operation succeeded.
+type AuditEvent struct {
+	Time    time.Time `json:"time"`
+	Op      string    `json:"op"`
+	Account string    `json:"account"`
+	Error   string    `json:"error,omitempty"`
+}
+
+// AuditSink receives the operations of a FileAccountStore. Failing to
+// record an event is logged but does not fail the operation.
+type AuditSink interface {
+	Record(e AuditEvent) error
+}
+
+func (s *FileAccountStore) audit(op, account string, err error) {
+	if s.Audit == nil {
+		return
+	}
+	e := AuditEvent{Time: time.Now().UTC(), Op: op, Account: account}
+	if err != nil {
+		e.Error = err.Error()
+	}
+	if err := s.Audit.Record(e); err != nil {
+		currentLogger().Warnf("recording %v of %v in audit log failed: %v", op, account, err)
+	}
+}
+
+// DecryptAccount loads the account name and decrypts it with password,
+// recording the attempt in the audit log.
+func (s *FileAccountStore) DecryptAccount(name string, password []byte) (*AccountInfo, error) {
+	a, err := s.loadAccount(name)
+	if err != nil {
+		s.audit(AuditDecrypt, name, err)
+		return nil, err
+	}
+	err = a.Decrypt(password)
+	s.audit(AuditDecrypt, name, err)
+	if err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// auditRecord is a line of a FileAuditLog: the event and the SHA3-256 of
+// the line before it, empty for the first line.
+type auditRecord struct {
+	AuditEvent
+	Prev string `json:"prev"`
+}
+
+// FileAuditLog is an AuditSink appending one JSON line per event to a file.
+// Every line carries the hash of the previous one, so VerifyAuditLog
+// detects lines that were edited, removed or reordered; dropping lines at
+// the very end is not detectable.
+type FileAuditLog struct {
+	path string
+
+	mu   sync.Mutex
+	last string // hash of the last line written
+}
+
+// OpenFileAuditLog opens or creates the log at path. An existing log is
+// verified before new events are chained onto it.
+func OpenFileAuditLog(path string) (*FileAuditLog, error) {
+	last, err := verifyAuditLog(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	return &FileAuditLog{path: path, last: last}, nil
+}
+
+func (l *FileAuditLog) Record(e AuditEvent) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	line, err := json.Marshal(auditRecord{AuditEvent: e, Prev: l.last})
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(l.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(append(line, '\n'))
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	l.last = auditHash(line)
+	return nil
+}
+
+// VerifyAuditLog checks the hash chain of the audit log at path.
+func VerifyAuditLog(path string) error {
+	_, err := verifyAuditLog(path)
+	return err
+}
+
+// verifyAuditLog returns the hash of the last line of a valid log.
+func verifyAuditLog(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	prev := ""
+	sc := bufio.NewScanner(f)
+	for n := 1; sc.Scan(); n++ {
+		line := sc.Bytes()
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		var r auditRecord
+		if err := json.Unmarshal(line, &r); err != nil {
+			return "", fmt.Errorf("audit log %v line %v: %v", path, n, err)
+		}
+		if r.Prev != prev {
+			return "", fmt.Errorf("audit log %v line %v: hash chain broken", path, n)
+		}
+		prev = auditHash(line)
+	}
+	if err := sc.Err(); err != nil {
+		return "", err
+	}
+	return prev, nil
+}
+
+func auditHash(line []byte) string {
+	h := sha3.Sum256(line)
+	return hex.EncodeToString(h[:])
+}
@@ -0,0 +1,107 @@
+package sdk
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestFileAuditLog(t *testing.T) {
+	logFile := t.TempDir() + "/audit.log"
+	l, err := OpenFileAuditLog(logFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewFileAccountStore(t.TempDir())
+	s.Audit = l
+	a := NewAccountInfo()
+	a.Name = "alice"
+	if err := a.AddKeyPair(PermOwner, newTestKeyPair(t, KeyTypeEd25519)); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.EncryptWithOptions([]byte("pw"), EncryptOptions{Scrypt: fastScrypt}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SaveAccount(a); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.LoadAccount("alice"); err != nil {
+		t.Fatal(err)
+	}
+	s.DecryptAccount("alice", []byte("wrong"))
+	if _, err := s.DecryptAccount("alice", []byte("pw")); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.DeleteAccount("alice"); err != nil {
+		t.Fatal(err)
+	}
+
+	// reopening continues the chain
+	if l, err = OpenFileAuditLog(logFile); err != nil {
+		t.Fatal(err)
+	}
+	s.Audit = l
+	s.LoadAccount("alice")
+	if err := VerifyAuditLog(logFile); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(logFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.SplitAfter(strings.TrimSuffix(string(data), "\n"), "\n")
+	want := []struct {
+		op     string
+		failed bool
+	}{
+		{AuditSave, false}, {AuditLoad, false}, {AuditDecrypt, true}, {AuditDecrypt, false}, {AuditDelete, false}, {AuditLoad, true},
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("audit log has %v lines, want %v:\n%s", len(lines), len(want), data)
+	}
+	for i, line := range lines {
+		var r auditRecord
+		if err := json.Unmarshal([]byte(line), &r); err != nil {
+			t.Fatal(err)
+		}
+		if r.Op != want[i].op || r.Account != "alice" || (r.Error != "") != want[i].failed || r.Time.IsZero() {
+			t.Fatalf("line %v is %+v, want %v failed=%v", i+1, r.AuditEvent, want[i].op, want[i].failed)
+		}
+	}
+
+	tampered := map[string]string{
+		"middle line removed": strings.Join(append(append([]string{}, lines[:2]...), lines[3:]...), ""),
+		"lines swapped":       lines[1] + lines[0] + strings.Join(lines[2:], ""),
+		"line edited":         strings.Replace(string(data), `"op":"delete"`, `"op":"load"`, 1),
+		"not json":            string(data) + "garbage\n",
+	}
+	for name, content := range tampered {
+		if err := os.WriteFile(logFile, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if err := VerifyAuditLog(logFile); err == nil {
+			t.Fatalf("%v: VerifyAuditLog succeeded", name)
+		}
+		if _, err := OpenFileAuditLog(logFile); err == nil {
+			t.Fatalf("%v: OpenFileAuditLog accepted a broken log", name)
+		}
+	}
+}
+
+func TestAuditOffByDefault(t *testing.T) {
+	dir := t.TempDir()
+	s := NewFileAccountStore(dir + "/accounts")
+	writeTestAccounts(t, s, 1)
+	if err := s.DeleteAccount("acc000"); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("store wrote %v (%v), want only its account directory", entries, err)
+	}
+	if s.Audit != nil {
+		t.Fatal("Audit is set by default")
+	}
+}
@@ -0,0 +1,164 @@
+package sdk
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// BackupInfo describes a keystore file SaveAccount moved into the backup
+// directory. Time is when it was replaced.
+type BackupInfo struct {
+	Account string
+	Time    time.Time
+	Path    string
+}
+
+// ListBackups returns the backups of account name, oldest first.
+func (s *FileAccountStore) ListBackups(name string) ([]BackupInfo, error) {
+	files, err := s.readDir("backup")
+	if errors.Is(err, fs.ErrNotExist) {
+		return []BackupInfo{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	prefix := name + "."
+	backups := make([]BackupInfo, 0)
+	for _, f := range files {
+		fn := f.Name()
+		ext := s.keystoreExt(fn)
+		if f.IsDir() || !strings.HasPrefix(fn, prefix) || ext == "" {
+			continue
+		}
+		// an account whose name extends this one ("a.b" for "a") fails to
+		// parse as a timestamp here
+		t, ok := s.parseBackupTime(strings.TrimSuffix(strings.TrimPrefix(fn, prefix), ext))
+		if !ok {
+			continue
+		}
+		backups = append(backups, BackupInfo{Account: name, Time: t, Path: s.path("backup/" + fn)})
+	}
+	sort.Slice(backups, func(i, j int) bool { return backups[i].Time.Before(backups[j].Time) })
+	return backups, nil
+}
+
+// parseBackupTime reads the timestamp of a backup file name written with
+// the store's format, the default one or those of older versions.
+func (s *FileAccountStore) parseBackupTime(v string) (time.Time, bool) {
+	for _, layout := range []string{s.backupTimeFormat(), DefaultBackupTimeFormat, legacyBackupTimeFormat, time.RFC3339} {
+		if t, err := time.Parse(layout, v); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// RestoreBackup makes the backup of account name taken at timestamp its
+// current keystore. The backup must load cleanly; the keystore it replaces
+// is itself backed up first. The backup file is left in place.
+func (s *FileAccountStore) RestoreBackup(name string, timestamp time.Time) error {
+	if err := s.writable(); err != nil {
+		return err
+	}
+	fileMode, dirMode, err := s.modes()
+	if err != nil {
+		return err
+	}
+	backups, err := s.ListBackups(name)
+	if err != nil {
+		return err
+	}
+	var backup *BackupInfo
+	for i := range backups {
+		if backups[i].Time.Equal(timestamp) {
+			backup = &backups[i]
+		}
+	}
+	if backup == nil {
+		return fmt.Errorf("account %v has no backup from %v", name, timestamp.Format(time.RFC3339))
+	}
+	data, err := os.ReadFile(backup.Path)
+	if err != nil {
+		return err
+	}
+	a, err := s.decodeFile(data, backup.Path)
+	if err != nil {
+		return fmt.Errorf("backup is not usable, %w", err)
+	}
+	if a.Name != name {
+		return fmt.Errorf("backup %v holds account %v, not %v", backup.Path, a.Name, name)
+	}
+
+	unlock, err := s.lockAccount(name)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	// the restored file keeps the backup's compression
+	fileName := s.AccountDir + "/" + name + s.keystoreExt(backup.Path)
+	current := s.AccountDir + "/" + s.keystoreFile(name)
+	if _, err := os.Stat(current); !os.IsNotExist(err) {
+		if err := s.backupFile(name, current, dirMode); err != nil {
+			return err
+		}
+	}
+	currentLogger().Infof("restoring %v from %v", fileName, backup.Path)
+	return writeFileAtomic(fileName, fileMode, bytes.NewReader(data))
+}
+
+// PruneBackups deletes all but the newest keep backups of account name and
+// returns how many were removed. The current keystore is never touched.
+func (s *FileAccountStore) PruneBackups(name string, keep int) (int, error) {
+	if keep < 0 {
+		return 0, fmt.Errorf("invalid number of backups to keep %v", keep)
+	}
+	return s.pruneBackups(name, func(backups []BackupInfo) []BackupInfo {
+		if len(backups) <= keep {
+			return nil
+		}
+		return backups[:len(backups)-keep]
+	})
+}
+
+// PruneBackupsOlderThan deletes the backups of account name taken more
+// than d ago and returns how many were removed.
+func (s *FileAccountStore) PruneBackupsOlderThan(name string, d time.Duration) (int, error) {
+	cutoff := time.Now().Add(-d)
+	return s.pruneBackups(name, func(backups []BackupInfo) []BackupInfo {
+		n := sort.Search(len(backups), func(i int) bool { return !backups[i].Time.Before(cutoff) })
+		return backups[:n]
+	})
+}
+
+// pruneBackups removes the backups selected from the oldest-first list.
+func (s *FileAccountStore) pruneBackups(name string, selectOld func([]BackupInfo) []BackupInfo) (int, error) {
+	if err := s.writable(); err != nil {
+		return 0, err
+	}
+	unlock, err := s.lockAccount(name)
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+	backups, err := s.ListBackups(name)
+	if err != nil {
+		return 0, err
+	}
+	removed := 0
+	for _, b := range selectOld(backups) {
+		if err := os.Remove(b.Path); err != nil {
+			return removed, err
+		}
+		removed++
+	}
+	if removed > 0 {
+		currentLogger().Infof("pruned %v backups of account %v", removed, name)
+	}
+	return removed, nil
+}
@@ -0,0 +1,257 @@
+package sdk
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// saveVersions saves account name n times, with notes v0 to v(n-1), so
+// that v0 to v(n-2) end up in the backup directory.
+func saveVersions(t *testing.T, n int) *FileAccountStore {
+	t.Helper()
+	s := NewFileAccountStore(t.TempDir())
+	a := NewAccountInfo()
+	a.Name = "alice"
+	if err := a.AddKeyPair(PermOwner, newTestKeyPair(t, KeyTypeEd25519)); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < n; i++ {
+		a.Note = fmt.Sprintf("v%v", i)
+		if err := s.SaveAccount(a); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return s
+}
+
+func TestRestoreBackup(t *testing.T) {
+	s := saveVersions(t, 4)
+	backups, err := s.ListBackups("alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backups) != 3 {
+		t.Fatalf("%v backups, want 3", len(backups))
+	}
+	for i, b := range backups {
+		data, err := os.ReadFile(b.Path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		a, err := s.decodeFile(data, b.Path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := fmt.Sprintf("v%v", i); a.Note != want || b.Account != "alice" {
+			t.Fatalf("backup %v holds %v of %v, want %v", i, a.Note, b.Account, want)
+		}
+	}
+	if err := s.RestoreBackup("alice", backups[0].Time.Add(-time.Hour)); err == nil {
+		t.Fatal("restored a backup that does not exist")
+	}
+	if err := s.RestoreBackup("alice", backups[1].Time); err != nil {
+		t.Fatal(err)
+	}
+	a, err := s.LoadAccount("alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Note != "v1" {
+		t.Fatalf("restored %v, want v1", a.Note)
+	}
+	after, err := s.ListBackups("alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(after) != 4 {
+		t.Fatalf("%v backups after restoring, want the replaced keystore backed up too", len(after))
+	}
+
+	// a backup that does not load is never promoted
+	if err := os.Chmod(backups[2].Path, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(backups[2].Path, []byte("{"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.RestoreBackup("alice", backups[2].Time); err == nil {
+		t.Fatal("restored a corrupt backup")
+	}
+	if a, err := s.LoadAccount("alice"); err != nil || a.Note != "v1" {
+		t.Fatalf("failed restore changed the account: %v", err)
+	}
+}
+
+func TestListBackupsNone(t *testing.T) {
+	s := NewFileAccountStore(t.TempDir())
+	writeTestAccounts(t, s, 1)
+	backups, err := s.ListBackups("acc000")
+	if err != nil || len(backups) != 0 {
+		t.Fatalf("ListBackups() = %v, %v, want none", backups, err)
+	}
+}
+
+func TestPruneBackups(t *testing.T) {
+	tests := []struct {
+		keep        int
+		wantRemoved int
+		wantErr     bool
+	}{
+		{keep: 3, wantRemoved: 7},
+		{keep: 0, wantRemoved: 10},
+		{keep: 10, wantRemoved: 0},
+		{keep: 20, wantRemoved: 0},
+		{keep: -1, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprint(tt.keep), func(t *testing.T) {
+			s := saveVersions(t, 11)
+			before, err := s.ListBackups("alice")
+			if err != nil {
+				t.Fatal(err)
+			}
+			removed, err := s.PruneBackups("alice", tt.keep)
+			if (err != nil) != tt.wantErr || removed != tt.wantRemoved {
+				t.Fatalf("PruneBackups() = %v, %v, want %v", removed, err, tt.wantRemoved)
+			}
+			after, err := s.ListBackups("alice")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := before[len(before)-len(after):]; fmt.Sprint(after) != fmt.Sprint(want) || len(after) != len(before)-removed {
+				t.Fatalf("kept %v, want the newest %v", after, len(before)-removed)
+			}
+			if a, err := s.LoadAccount("alice"); err != nil || a.Note != "v10" {
+				t.Fatalf("pruning touched the current keystore: %v", err)
+			}
+		})
+	}
+}
+
+func TestPruneBackupsOlderThan(t *testing.T) {
+	s := saveVersions(t, 1)
+	for _, age := range []time.Duration{72 * time.Hour, 48 * time.Hour, 25 * time.Hour, time.Hour, time.Minute} {
+		fileName := s.backupName("alice", ".json", time.Now().Add(-age))
+		if err := os.MkdirAll(s.path("backup"), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(fileName, []byte("{}"), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	removed, err := s.PruneBackupsOlderThan("alice", 24*time.Hour)
+	if err != nil || removed != 3 {
+		t.Fatalf("PruneBackupsOlderThan() = %v, %v, want 3", removed, err)
+	}
+	backups, err := s.ListBackups("alice")
+	if err != nil || len(backups) != 2 {
+		t.Fatalf("ListBackups() = %v, %v, want the 2 recent backups", backups, err)
+	}
+	if _, err := s.LoadAccount("alice"); err != nil {
+		t.Fatalf("pruning touched the current keystore: %v", err)
+	}
+}
+
+func TestBackupTimeFormat(t *testing.T) {
+	s := NewFileAccountStore(t.TempDir())
+	a := NewAccountInfo()
+	a.Name = "alice"
+	if err := a.AddKeyPair(PermOwner, newTestKeyPair(t, KeyTypeEd25519)); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := s.SaveAccount(a); err != nil {
+			t.Fatal(err)
+		}
+	}
+	backups, err := s.ListBackups("alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backups) != 1 {
+		t.Fatalf("%v backups, want 1", len(backups))
+	}
+	base := filepath.Base(backups[0].Path)
+	if strings.Contains(base, ":") {
+		t.Fatalf("backup file name %v contains a colon", base)
+	}
+	stamp := strings.TrimSuffix(strings.TrimPrefix(base, "alice."), ".json")
+	if _, err := time.Parse(DefaultBackupTimeFormat, stamp); err != nil {
+		t.Fatalf("backup file name %v: %v", base, err)
+	}
+
+	// backups named by older versions or other formats are still listed
+	s.BackupTimeFormat = "2006-01-02_150405"
+	keystore, err := os.ReadFile(backups[0].Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{
+		"alice.2019-01-02T03:04:05Z.json",
+		"alice.2018-01-02_030405.json",
+		"alice.20160102T030405Z.json",
+		"alice.b.20170102T030405Z.json",
+	} {
+		if err := os.WriteFile(s.AccountDir+"/backup/"+name, keystore, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	a.Note = "custom format"
+	if err := s.SaveAccount(a); err != nil {
+		t.Fatal(err)
+	}
+	backups, err = s.ListBackups("alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []int
+	for _, b := range backups {
+		got = append(got, b.Time.Year())
+	}
+	now := time.Now().UTC().Year()
+	if want := []int{2016, 2018, 2019, now, now}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("backup years = %v, want %v", got, want)
+	}
+	custom := 0
+	for _, b := range backups {
+		if strings.HasPrefix(filepath.Base(b.Path), "alice."+time.Now().UTC().Format("2006-01-02_")) {
+			custom++
+		}
+	}
+	if custom != 1 {
+		t.Fatalf("%v backups use the configured format, want 1", custom)
+	}
+}
+
+func TestBackupQuickSaves(t *testing.T) {
+	s := saveVersions(t, 20)
+	backups, err := s.ListBackups("alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backups) != 19 {
+		t.Fatalf("%v backups of 19 quick saves", len(backups))
+	}
+
+	// a format too coarse to tell saves apart fails rather than replacing
+	// the earlier backup
+	s.BackupTimeFormat = "2006"
+	a, err := s.LoadAccount("alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SaveAccount(a); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SaveAccount(a); err == nil {
+		t.Fatal("second save in the same year replaced its backup")
+	}
+	if backups, err := s.ListBackups("alice"); err != nil || len(backups) != 20 {
+		t.Fatalf("ListBackups() = %v backups, %v, want 20", len(backups), err)
+	}
+}
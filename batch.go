@@ -0,0 +1,73 @@
+package sdk
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ImportBatch creates one account per entry of keys, which maps account
+// names to base58 private keys, encrypts it with password and saves it. A
+// key is ed25519 unless prefixed with its key type, as in "p256:<key>". A
+// bad key or existing account does not stop the batch: the accounts that
+// were saved are returned along with an AccountErrors for the rest.
+func (s *FileAccountStore) ImportBatch(keys map[string]string, password []byte) ([]*AccountInfo, error) {
+	if err := s.writable(); err != nil {
+		return nil, err
+	}
+	accs := make([]*AccountInfo, 0, len(keys))
+	errs := AccountErrors{}
+	for _, name := range sortedKeys(keys) {
+		acc, err := s.importOne(name, keys[name], password)
+		if err != nil {
+			errs[name] = err
+			continue
+		}
+		accs = append(accs, acc)
+	}
+	if len(errs) > 0 {
+		return accs, errs
+	}
+	return accs, nil
+}
+
+func (s *FileAccountStore) importOne(name, key string, password []byte) (*AccountInfo, error) {
+	exists, err := s.HasAccount(name)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, fmt.Errorf("account %v already exists", name)
+	}
+	// base58 has no colon, so a key cannot be mistaken for a prefix
+	keyType, rawKey := string(KeyTypeEd25519), key
+	if i := strings.IndexByte(key, ':'); i >= 0 {
+		keyType, rawKey = key[:i], key[i+1:]
+	}
+	kp, err := ImportPrivateKey(rawKey, keyType)
+	if err != nil {
+		return nil, err
+	}
+	defer kp.Wipe()
+	if err := kp.Encrypt(password); err != nil {
+		return nil, err
+	}
+	acc := NewAccountInfo()
+	acc.Name = name
+	if err := acc.AddKeyPair(PermOwner, kp); err != nil {
+		return nil, err
+	}
+	if err := s.SaveAccount(acc); err != nil {
+		return nil, err
+	}
+	return acc, nil
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
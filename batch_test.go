@@ -0,0 +1,85 @@
+package sdk
+
+import (
+	"errors"
+	"github.com/quantosnetwork/dev-0.1.0/common"
+	"os"
+	"testing"
+)
+
+func TestImportBatch(t *testing.T) {
+	s := NewFileAccountStore(t.TempDir())
+	existing := NewAccountInfo()
+	existing.Name = "existing"
+	if err := existing.AddKeyPair(PermOwner, newTestKeyPair(t, KeyTypeEd25519)); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SaveAccount(existing); err != nil {
+		t.Fatal(err)
+	}
+	ed := newTestKeyPair(t, KeyTypeEd25519)
+	p256 := newTestKeyPair(t, KeyTypeP256)
+	tests := []struct {
+		name    string
+		key     string
+		keyType KeyType
+		wantErr error
+	}{
+		{"bare", ed.RawKey.Reveal(), KeyTypeEd25519, nil},
+		{"prefixed", "p256:" + p256.RawKey.Reveal(), KeyTypeP256, nil},
+		{"empty", "", "", ErrEmptyKey},
+		{"short key", common.EncodeBase58([]byte{1, 2, 3}), "", ErrInvalidKeyPair},
+		{"unknown type", "rsa:" + ed.RawKey.Reveal(), "", ErrUnsupportedKey},
+		{"native type", "quantos:" + ed.RawKey.Reveal(), "", ErrUnsupportedKey},
+		{"existing", ed.RawKey.Reveal(), "", nil},
+	}
+	keys := map[string]string{}
+	for _, tt := range tests {
+		keys[tt.name] = tt.key
+	}
+
+	accs, err := s.ImportBatch(keys, []byte("pw"))
+	var errs AccountErrors
+	if !errors.As(err, &errs) {
+		t.Fatalf("ImportBatch() error = %v, want AccountErrors", err)
+	}
+	imported := map[string]*AccountInfo{}
+	for _, a := range accs {
+		imported[a.Name] = a
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.keyType == "" {
+				if errs[tt.name] == nil {
+					t.Fatal("invalid key was imported")
+				}
+				if tt.wantErr != nil && !errors.Is(errs[tt.name], tt.wantErr) {
+					t.Fatalf("error = %v, want %v", errs[tt.name], tt.wantErr)
+				}
+				if tt.name != "existing" {
+					if _, err := os.Stat(s.path(s.keystoreFile(tt.name))); !errors.Is(err, os.ErrNotExist) {
+						t.Fatalf("failed import left a keystore behind: %v", err)
+					}
+				}
+				return
+			}
+			if errs[tt.name] != nil || imported[tt.name] == nil {
+				t.Fatalf("not imported: %v", errs[tt.name])
+			}
+			a, err := s.LoadAccount(tt.name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			kp := a.Keypairs[PermOwner]
+			if kp.KeyType != tt.keyType || !kp.IsEncrypted() {
+				t.Fatalf("saved keypair is %v, encrypted %v", kp.KeyType, kp.IsEncrypted())
+			}
+			if err := kp.Decrypt([]byte("pw")); err != nil {
+				t.Fatal(err)
+			}
+			if err := kp.VerifyPublicKey(); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
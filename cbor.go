@@ -0,0 +1,116 @@
+package sdk
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/fxamacker/cbor/v2"
+	"strings"
+)
+
+// Keystore codecs for FileAccountStore.Codec. The codec is also the file
+// extension of the keystores.
+const (
+	CodecJSON = "json"
+	CodecCBOR = "cbor"
+)
+
+// EncodeCBOR is the CBOR counterpart of WriteTo, for clients where the JSON
+// form is too bulky. Field names and base58 values are the same as in JSON.
+func EncodeCBOR(a *AccountInfo) ([]byte, error) {
+	a.stamp()
+	return cbor.Marshal(a)
+}
+
+// DecodeCBOR reads a keystore written by EncodeCBOR, with the same checks
+// as LoadAccount.
+func DecodeCBOR(data []byte) (*AccountInfo, error) {
+	return decodeCBOR(data, "input")
+}
+
+func decodeCBOR(data []byte, source string) (*AccountInfo, error) {
+	if err := checkKeystoreSize(len(data), source); err != nil {
+		return nil, err
+	}
+	a := NewAccountInfo()
+	if err := cbor.Unmarshal(data, a); err != nil {
+		return nil, fmt.Errorf("invalid cbor keystore, %v", err)
+	}
+	return checkAccount(a, source)
+}
+
+// MarshalCBOR writes the keypair with its real raw key, like MarshalJSON.
+func (k KeyPairInfo) MarshalCBOR() ([]byte, error) {
+	type plain KeyPairInfo
+	return cbor.Marshal(struct {
+		plain
+		RawKey string `cbor:"raw_key,omitempty"`
+	}{plain(k), k.RawKey.Reveal()})
+}
+
+func (k *KeyPairInfo) UnmarshalCBOR(data []byte) error {
+	type plain KeyPairInfo
+	var v struct {
+		plain
+		RawKey string `cbor:"raw_key,omitempty"`
+	}
+	if err := cbor.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	*k = KeyPairInfo(v.plain)
+	k.RawKey = NewSecretKey(v.RawKey)
+	return nil
+}
+
+// encodeAccount serializes a in the store's codec.
+func (s *FileAccountStore) encodeAccount(a *AccountInfo) ([]byte, error) {
+	switch s.codec() {
+	case CodecJSON:
+		var buf bytes.Buffer
+		if _, err := a.WriteTo(&buf); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	case CodecCBOR:
+		return EncodeCBOR(a)
+	}
+	return nil, fmt.Errorf("unsupported keystore codec %v", s.Codec)
+}
+
+// decodeFile parses and checks the keystore read from file, by extension,
+// decompressing it first if needed.
+func (s *FileAccountStore) decodeFile(data []byte, file string) (*AccountInfo, error) {
+	data, err := gunzipKeystore(data, file)
+	if err != nil {
+		return nil, err
+	}
+	if strings.HasSuffix(strings.TrimSuffix(file, gzipExt), "."+CodecCBOR) {
+		return decodeCBOR(data, file)
+	}
+	return decodeAccount(bytes.NewReader(data), file, s.StrictDecode)
+}
+
+// unmarshal only parses data in the store's codec, skipping the checks
+// decodeFile makes.
+func (s *FileAccountStore) unmarshal(data []byte, a *AccountInfo) error {
+	if s.codec() == CodecCBOR {
+		return cbor.Unmarshal(data, a)
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if s.StrictDecode {
+		dec.DisallowUnknownFields()
+	}
+	return dec.Decode(a)
+}
+
+func (s *FileAccountStore) codec() string {
+	if s.Codec == "" {
+		return CodecJSON
+	}
+	return s.Codec
+}
+
+// ext is the extension of the keystore files the store writes.
+func (s *FileAccountStore) ext() string {
+	return s.exts()[0]
+}
@@ -0,0 +1,100 @@
+package sdk
+
+import (
+	"bytes"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestCBORMatchesJSON(t *testing.T) {
+	a := NewAccountInfo()
+	a.Name = "alice"
+	a.Note = "paper copy in the safe"
+	owner := newTestKeyPair(t, KeyTypeEd25519)
+	if err := owner.EncryptWithOptions([]byte("pw"), EncryptOptions{KeySize: 32, Scrypt: fastScrypt}); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.AddKeyPair(PermOwner, owner, "cold"); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.AddKeyPair(PermActive, newTestKeyPair(t, KeyTypeP256)); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if _, err := a.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	fromJSON, err := LoadAccount(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := EncodeCBOR(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fromCBOR, err := DecodeCBOR(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(fromCBOR, fromJSON) {
+		t.Fatalf("CBOR gave %+v, JSON gave %+v", fromCBOR, fromJSON)
+	}
+	if fromCBOR.Keypairs[PermActive].RawKey.Reveal() != a.Keypairs[PermActive].RawKey.Reveal() {
+		t.Fatal("raw key did not survive CBOR")
+	}
+	if err := fromCBOR.Keypairs[PermOwner].Decrypt([]byte("pw")); err != nil {
+		t.Fatalf("encrypted keypair does not decrypt after CBOR: %v", err)
+	}
+	if _, err := DecodeCBOR(data[:len(data)/2]); err == nil {
+		t.Fatal("DecodeCBOR accepted truncated input")
+	}
+}
+
+func TestStoreCodec(t *testing.T) {
+	tests := []struct {
+		codec   string
+		file    string
+		wantErr bool
+	}{
+		{"", "alice.json", false},
+		{CodecJSON, "alice.json", false},
+		{CodecCBOR, "alice.cbor", false},
+		{"yaml", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.codec, func(t *testing.T) {
+			s := NewFileAccountStore(t.TempDir())
+			s.Codec = tt.codec
+			a := NewAccountInfo()
+			a.Name = "alice"
+			if err := a.AddKeyPair(PermOwner, newTestKeyPair(t, KeyTypeEd25519)); err != nil {
+				t.Fatal(err)
+			}
+			err := s.SaveAccount(a)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("SaveAccount succeeded")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := os.Stat(s.path(tt.file)); err != nil {
+				t.Fatalf("keystore not written as %v: %v", tt.file, err)
+			}
+			got, err := s.LoadAccount("alice")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Keypairs[PermOwner].PubKey != a.Keypairs[PermOwner].PubKey {
+				t.Fatal("keypair did not survive the store")
+			}
+			if accs, err := s.ListAccounts(); err != nil || len(accs) != 1 {
+				t.Fatalf("ListAccounts() = %v, %v", accs, err)
+			}
+		})
+	}
+}
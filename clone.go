@@ -0,0 +1,51 @@
+package sdk
+
+// Clone returns a deep copy of the keypair. A decrypted key is copied too,
+// so wipe the clone when done with it.
+func (k *KeyPairInfo) Clone() *KeyPairInfo {
+	c := *k
+	c.RawKey = SecretKey{b: append([]byte(nil), k.RawKey.b...)}
+	if k.ScryptParams != nil {
+		params := *k.ScryptParams
+		c.ScryptParams = &params
+	}
+	if k.Argon2Params != nil {
+		params := *k.Argon2Params
+		c.Argon2Params = &params
+	}
+	return &c
+}
+
+// Clone returns a deep copy of the account, its keypairs, metadata and
+// threshold permissions. Registered signers are shared with the original.
+func (a *AccountInfo) Clone() *AccountInfo {
+	c := *a
+	if a.Keypairs != nil {
+		c.Keypairs = make(map[string]*KeyPairInfo, len(a.Keypairs))
+		for perm, kp := range a.Keypairs {
+			if kp != nil {
+				kp = kp.Clone()
+			}
+			c.Keypairs[perm] = kp
+		}
+	}
+	if a.Metadata != nil {
+		c.Metadata = a.Tags()
+	}
+	if a.Thresholds != nil {
+		c.Thresholds = make(map[string]*MultiKeyPermission, len(a.Thresholds))
+		for perm, m := range a.Thresholds {
+			if m != nil {
+				m = &MultiKeyPermission{PubKeys: append([]string(nil), m.PubKeys...), Threshold: m.Threshold}
+			}
+			c.Thresholds[perm] = m
+		}
+	}
+	if a.signers != nil {
+		c.signers = make(map[string]Signer, len(a.signers))
+		for perm, s := range a.signers {
+			c.signers[perm] = s
+		}
+	}
+	return &c
+}
@@ -0,0 +1,91 @@
+package sdk
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestKeyPairClone(t *testing.T) {
+	kp := newTestKeyPair(t, KeyTypeEd25519)
+	kp.ScryptParams = &ScryptParams{N: minScryptN, R: 8, P: 1}
+	kp.Argon2Params = &Argon2Params{Time: 1, Memory: 1024, Threads: 1}
+	kp.Label = "hot"
+	raw := kp.RawKey.Reveal()
+	tests := []struct {
+		name   string
+		mutate func(c *KeyPairInfo)
+	}{
+		{"raw key bytes", func(c *KeyPairInfo) { c.RawKey.b[0] ^= 1 }},
+		{"raw key wiped", func(c *KeyPairInfo) { c.Wipe() }},
+		{"scrypt params", func(c *KeyPairInfo) { c.ScryptParams.N *= 2 }},
+		{"argon2 params", func(c *KeyPairInfo) { c.Argon2Params.Memory *= 2 }},
+		{"label", func(c *KeyPairInfo) { c.Label = "cold" }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := kp.Clone()
+			if !reflect.DeepEqual(c, kp) {
+				t.Fatalf("Clone() = %+v, want %+v", c, kp)
+			}
+			tt.mutate(c)
+			if kp.RawKey.Reveal() != raw || kp.ScryptParams.N != minScryptN || kp.Argon2Params.Memory != 1024 || kp.Label != "hot" {
+				t.Fatalf("mutating the clone changed the original: %+v", kp)
+			}
+		})
+	}
+	// new pointer fields need a deep copy in Clone as well
+	c := kp.Clone()
+	v, cv := reflect.ValueOf(kp).Elem(), reflect.ValueOf(c).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		switch f := v.Field(i); f.Kind() {
+		case reflect.Ptr, reflect.Slice, reflect.Map:
+			if !f.IsNil() && f.Pointer() == cv.Field(i).Pointer() {
+				t.Fatalf("%v is shared with the clone", v.Type().Field(i).Name)
+			}
+		}
+	}
+}
+
+func TestAccountClone(t *testing.T) {
+	a := NewAccountInfo()
+	a.Name = "alice"
+	for _, perm := range []string{PermOwner, PermActive} {
+		if err := a.AddKeyPair(perm, newTestKeyPair(t, KeyTypeEd25519)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	a.SetTag("tier", "cold")
+	if err := a.AddThresholdPermission("treasury", []string{a.Keypairs[PermOwner].PubKey, a.Keypairs[PermActive].PubKey}, 2); err != nil {
+		t.Fatal(err)
+	}
+	want := a.Clone()
+	tests := []struct {
+		name   string
+		mutate func(c *AccountInfo)
+	}{
+		{"name", func(c *AccountInfo) { c.Name = "bob" }},
+		{"remove keypair", func(c *AccountInfo) { delete(c.Keypairs, PermActive) }},
+		{"add keypair", func(c *AccountInfo) { c.Keypairs["extra"] = newTestKeyPair(t, KeyTypeEd25519) }},
+		{"keypair field", func(c *AccountInfo) { c.Keypairs[PermOwner].PubKey = "changed" }},
+		{"encrypt", func(c *AccountInfo) {
+			if err := c.EncryptWithOptions([]byte("pw"), EncryptOptions{Scrypt: fastScrypt}); err != nil {
+				t.Fatal(err)
+			}
+		}},
+		{"tag", func(c *AccountInfo) { c.SetTag("tier", "hot") }},
+		{"threshold", func(c *AccountInfo) { c.Thresholds["treasury"].Threshold = 1 }},
+		{"threshold keys", func(c *AccountInfo) { c.Thresholds["treasury"].PubKeys[0] = "changed" }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := a.Clone()
+			if !reflect.DeepEqual(c, a) {
+				t.Fatal("clone differs from the original")
+			}
+			tt.mutate(c)
+			if !reflect.DeepEqual(a, want) {
+				t.Fatalf("mutating the clone changed the original: %+v", a)
+			}
+		})
+	}
+}
@@ -0,0 +1,189 @@
+package sdk
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/quantosnetwork/dev-0.1.0/common"
+)
+
+// compactVersion is the leading byte of an ExportCompact payload.
+const compactVersion = 1
+
+// Compact codes of key types and KDFs. They are part of the format, so
+// never renumber them.
+var (
+	compactKeyTypes = map[KeyType]byte{KeyTypeQuantos: 1, KeyTypeEd25519: 2, KeyTypeP256: 3}
+	compactKDFs     = map[string]byte{KDFScrypt: 1, KDFArgon2id: 2, KDFNone: 3}
+)
+
+// ExportCompact encodes an encrypted keypair as a short base58 string, small
+// enough for a QR code. Only the encrypted key leaves the keypair, so the
+// payload is as safe as the keystore itself; a decrypted keypair is refused
+// with ErrNotEncrypted.
+//
+// The binary layout is: version, key type, KDF and key size bytes, the KDF
+// parameters as three uvarints (zero for KDFNone), the 16 byte ID, then salt, ciphertext, MAC and
+// public key, each prefixed by its uvarint length.
+func (k *KeyPairInfo) ExportCompact() (string, error) {
+	if k.EncryptedKey == "" {
+		return "", ErrNotEncrypted
+	}
+	if k.Cipher != "" {
+		return "", fmt.Errorf("%w: compact keypairs use aes-ctr, not %v", ErrUnsupportedKey, k.Cipher)
+	}
+	kt, ok := compactKeyTypes[k.KeyType]
+	if !ok {
+		return "", fmt.Errorf("%w %v", ErrUnsupportedKey, k.KeyType)
+	}
+	kdf := k.KDF
+	if kdf == "" {
+		kdf = KDFScrypt
+	}
+	keySize := k.KeySize
+	if keySize == 0 {
+		keySize = 16
+	}
+	id, err := uuid.Parse(k.ID)
+	if err != nil {
+		return "", fmt.Errorf("%w: id is not a uuid", ErrInvalidKeyPair)
+	}
+	buf := []byte{compactVersion, kt, compactKDFs[kdf], byte(keySize)}
+	switch kdf {
+	case KDFScrypt:
+		params := DefaultScryptParams
+		if k.ScryptParams != nil {
+			params = *k.ScryptParams
+		}
+		buf = appendUvarints(buf, uint64(params.N), uint64(params.R), uint64(params.P))
+	case KDFArgon2id:
+		params := DefaultArgon2Params
+		if k.Argon2Params != nil {
+			params = *k.Argon2Params
+		}
+		buf = appendUvarints(buf, uint64(params.Time), uint64(params.Memory), uint64(params.Threads))
+	case KDFNone:
+		buf = appendUvarints(buf, 0, 0, 0)
+	default:
+		return "", fmt.Errorf("unsupported kdf %v", k.KDF)
+	}
+	buf = append(buf, id[:]...)
+	for _, field := range []string{k.Salt, k.EncryptedKey, k.Mac, k.PubKey} {
+		b := common.DecodeBase58(field)
+		buf = appendUvarints(buf, uint64(len(b)))
+		buf = append(buf, b...)
+	}
+	return common.EncodeBase58(buf), nil
+}
+
+// ImportCompact decodes a string written by ExportCompact. The keypair
+// stays encrypted.
+func ImportCompact(s string) (*KeyPairInfo, error) {
+	r := compactReader{b: common.DecodeBase58(s)}
+	hdr := r.bytes(4)
+	if hdr == nil {
+		return nil, errCompactTruncated
+	}
+	if hdr[0] != compactVersion {
+		return nil, fmt.Errorf("unsupported compact keypair version %v", hdr[0])
+	}
+	k := &KeyPairInfo{KeySize: int(hdr[3])}
+	for kt, code := range compactKeyTypes {
+		if code == hdr[1] {
+			k.KeyType = kt
+		}
+	}
+	for kdf, code := range compactKDFs {
+		if code == hdr[2] {
+			k.KDF = kdf
+		}
+	}
+	if k.KeyType == "" {
+		return nil, fmt.Errorf("%w: code %v", ErrUnsupportedKey, hdr[1])
+	}
+	a, b, c := r.uvarint(), r.uvarint(), r.uvarint()
+	switch k.KDF {
+	case KDFScrypt:
+		if a > maxScryptN || b > 1<<30 || c > 1<<30 {
+			return nil, fmt.Errorf("%w: scrypt parameters out of range", ErrInvalidKeyPair)
+		}
+		params := ScryptParams{N: int(a), R: int(b), P: int(c)}
+		if err := params.validate(); err != nil {
+			return nil, err
+		}
+		k.ScryptParams = &params
+	case KDFArgon2id:
+		if a > 1<<32-1 || b > 1<<32-1 || c > 1<<8-1 {
+			return nil, fmt.Errorf("%w: argon2 parameters out of range", ErrInvalidKeyPair)
+		}
+		params := Argon2Params{Time: uint32(a), Memory: uint32(b), Threads: uint8(c)}
+		if err := params.validate(); err != nil {
+			return nil, err
+		}
+		k.Argon2Params = &params
+	case KDFNone:
+	default:
+		return nil, fmt.Errorf("unsupported kdf code %v", hdr[2])
+	}
+	id, err := uuid.FromBytes(r.bytes(16))
+	if err != nil {
+		return nil, errCompactTruncated
+	}
+	k.ID = id.String()
+	fields := []*string{&k.Salt, &k.EncryptedKey, &k.Mac, &k.PubKey}
+	for _, field := range fields {
+		b := r.bytes(int(r.uvarint()))
+		if b == nil {
+			return nil, errCompactTruncated
+		}
+		*field = common.EncodeBase58(b)
+	}
+	if r.err != nil || len(r.b) != 0 {
+		return nil, fmt.Errorf("%w: malformed compact keypair", ErrInvalidKeyPair)
+	}
+	if err := k.Validate(); err != nil {
+		return nil, err
+	}
+	return k, nil
+}
+
+var errCompactTruncated = fmt.Errorf("%w: compact keypair is truncated", ErrInvalidKeyPair)
+
+func appendUvarints(buf []byte, vs ...uint64) []byte {
+	var tmp [binary.MaxVarintLen64]byte
+	for _, v := range vs {
+		n := binary.PutUvarint(tmp[:], v)
+		buf = append(buf, tmp[:n]...)
+	}
+	return buf
+}
+
+// compactReader consumes an ExportCompact payload, remembering the first
+// malformed read.
+type compactReader struct {
+	b   []byte
+	err error
+}
+
+func (r *compactReader) uvarint() uint64 {
+	v, n := binary.Uvarint(r.b)
+	if n <= 0 {
+		if r.err == nil {
+			r.err = errors.New("bad uvarint")
+		}
+		return 0
+	}
+	r.b = r.b[n:]
+	return v
+}
+
+// bytes returns the next n bytes, or nil if there are fewer left.
+func (r *compactReader) bytes(n int) []byte {
+	if n < 0 || n > len(r.b) {
+		return nil
+	}
+	b := r.b[:n]
+	r.b = r.b[n:]
+	return b
+}
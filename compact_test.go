@@ -0,0 +1,102 @@
+package sdk
+
+import (
+	"errors"
+	"github.com/quantosnetwork/dev-0.1.0/common"
+	"testing"
+)
+
+// maxCompactPayload bounds the decoded ExportCompact payload, leaving room
+// for the base58 text in a byte-mode QR code
+const maxCompactPayload = 200
+
+func TestCompactRoundTrip(t *testing.T) {
+	tests := []struct {
+		name    string
+		keyType KeyType
+		encrypt func(k *KeyPairInfo) error
+	}{
+		{"scrypt", KeyTypeEd25519, func(k *KeyPairInfo) error { return k.EncryptWithParams([]byte("pw"), fastScrypt) }},
+		{"scrypt aes-256", KeyTypeP256, func(k *KeyPairInfo) error {
+			return k.EncryptWithOptions([]byte("pw"), EncryptOptions{KeySize: 32, Scrypt: fastScrypt})
+		}},
+		{"argon2id", KeyTypeEd25519, func(k *KeyPairInfo) error {
+			return k.EncryptWithOptions([]byte("pw"), EncryptOptions{KDF: KDFArgon2id, Argon2: Argon2Params{Time: 1, Memory: 1024, Threads: 1}})
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k := newTestKeyPair(t, tt.keyType)
+			raw := k.RawKey.Reveal()
+			if err := tt.encrypt(k); err != nil {
+				t.Fatal(err)
+			}
+			s, err := k.ExportCompact()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if n := len(common.DecodeBase58(s)); n > maxCompactPayload {
+				t.Fatalf("payload is %v bytes, want at most %v", n, maxCompactPayload)
+			}
+			got, err := ImportCompact(s)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.ID != k.ID || got.KeyType != k.KeyType || got.PubKey != k.PubKey || got.Salt != k.Salt ||
+				got.EncryptedKey != k.EncryptedKey || got.Mac != k.Mac || got.KDF != k.KDF || got.KeySize != k.KeySize {
+				t.Fatalf("ImportCompact() = %+v, want %+v", got, k)
+			}
+			if !got.IsEncrypted() {
+				t.Fatal("imported keypair is not encrypted")
+			}
+			if err := got.Decrypt([]byte("pw")); err != nil {
+				t.Fatal(err)
+			}
+			if got.RawKey.Reveal() != raw {
+				t.Fatal("imported keypair decrypts to another key")
+			}
+		})
+	}
+}
+
+func TestCompactRejects(t *testing.T) {
+	plain := newTestKeyPair(t, KeyTypeEd25519)
+	if _, err := plain.ExportCompact(); !errors.Is(err, ErrNotEncrypted) {
+		t.Fatalf("ExportCompact() of a plaintext keypair = %v, want %v", err, ErrNotEncrypted)
+	}
+	gcm := newTestKeyPair(t, KeyTypeEd25519)
+	if err := gcm.EncryptWithOptions([]byte("pw"), EncryptOptions{Scrypt: fastScrypt, Cipher: CipherAESGCM, AAD: []byte("aad")}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := gcm.ExportCompact(); !errors.Is(err, ErrUnsupportedKey) {
+		t.Fatalf("ExportCompact() of an aes-gcm keypair = %v, want %v", err, ErrUnsupportedKey)
+	}
+
+	k := newTestKeyPair(t, KeyTypeEd25519)
+	if err := k.EncryptWithParams([]byte("pw"), fastScrypt); err != nil {
+		t.Fatal(err)
+	}
+	s, err := k.ExportCompact()
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload := common.DecodeBase58(s)
+	edit := func(f func(b []byte) []byte) string {
+		return common.EncodeBase58(f(append([]byte{}, payload...)))
+	}
+	tests := map[string]string{
+		"empty":            "",
+		"not base58":       "0OIl",
+		"truncated":        edit(func(b []byte) []byte { return b[:len(b)-1] }),
+		"header only":      edit(func(b []byte) []byte { return b[:4] }),
+		"trailing bytes":   edit(func(b []byte) []byte { return append(b, 0) }),
+		"unknown version":  edit(func(b []byte) []byte { b[0] = 9; return b }),
+		"unknown key type": edit(func(b []byte) []byte { b[1] = 99; return b }),
+		"unknown kdf":      edit(func(b []byte) []byte { b[2] = 99; return b }),
+	}
+	for name, s := range tests {
+		if _, err := ImportCompact(s); err == nil {
+			t.Fatalf("%v: ImportCompact succeeded", name)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package sdk
+
+import (
+	"bytes"
+	"compress/gzip"
+	"strings"
+)
+
+// gzipExt follows the codec extension of keystores written with
+// FileAccountStore.Compress.
+const gzipExt = ".gz"
+
+// exts are the extensions keystore files may have, the one new saves use
+// first.
+func (s *FileAccountStore) exts() []string {
+	plain := "." + s.codec()
+	if s.Compress {
+		return []string{plain + gzipExt, plain}
+	}
+	return []string{plain, plain + gzipExt}
+}
+
+// keystoreExt returns which of exts file ends in, or "" for files that
+// are not keystores.
+func (s *FileAccountStore) keystoreExt(file string) string {
+	for _, ext := range s.exts() {
+		if strings.HasSuffix(file, ext) {
+			return ext
+		}
+	}
+	return ""
+}
+
+// keystoreFile is the file holding account name, compressed or not,
+// preferring the form new saves use if both exist. It is name+s.ext() for
+// an account that has no file yet.
+func (s *FileAccountStore) keystoreFile(name string) string {
+	for _, ext := range s.exts() {
+		if _, err := s.stat(name + ext); err == nil {
+			return name + ext
+		}
+	}
+	return name + s.ext()
+}
+
+func gzipKeystore(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// gunzipKeystore decompresses data if it starts with the gzip magic, so a
+// keystore is read right whatever its name says. The decompressed size is
+// held to MaxKeystoreSize.
+func gunzipKeystore(data []byte, source string) ([]byte, error) {
+	if len(data) < 2 || data[0] != 0x1f || data[1] != 0x8b {
+		return data, nil
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	return readKeystore(zr, source)
+}
@@ -0,0 +1,140 @@
+package sdk
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestCompressedStore(t *testing.T) {
+	dir := t.TempDir()
+	plain, compressed := NewFileAccountStore(dir), NewFileAccountStore(dir)
+	compressed.Compress = true
+	pubs := map[string]string{}
+	for name, s := range map[string]*FileAccountStore{"alice": plain, "bob": compressed} {
+		a := NewAccountInfo()
+		a.Name = name
+		if err := a.AddKeyPair(PermOwner, newTestKeyPair(t, KeyTypeEd25519)); err != nil {
+			t.Fatal(err)
+		}
+		if err := a.EncryptWithOptions([]byte("pw"), EncryptOptions{Scrypt: fastScrypt}); err != nil {
+			t.Fatal(err)
+		}
+		pubs[name] = a.Keypairs[PermOwner].PubKey
+		if err := s.SaveAccount(a); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, file := range []string{"alice.json", "bob.json.gz"} {
+		if _, err := os.Stat(dir + "/" + file); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// the checksum covers the uncompressed keystore
+	data, err := os.ReadFile(dir + "/bob.json.gz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bob, err := LoadAccount(bytes.NewReader(raw))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc map[string]interface{}
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc["checksum"] != bob.computeChecksum() {
+		t.Fatalf("checksum %v, want %v", doc["checksum"], bob.computeChecksum())
+	}
+
+	for name, s := range map[string]*FileAccountStore{"plain": plain, "compressed": compressed} {
+		t.Run(name, func(t *testing.T) {
+			accs, err := s.ListAccounts()
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := map[string]string{}
+			for _, a := range accs {
+				got[a.Name] = a.Keypairs[PermOwner].PubKey
+			}
+			if !reflect.DeepEqual(got, pubs) {
+				t.Fatalf("listed %v, want %v", got, pubs)
+			}
+			for account := range pubs {
+				a, err := s.LoadAccount(account)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err := a.Decrypt([]byte("pw")); err != nil {
+					t.Fatal(err)
+				}
+			}
+		})
+	}
+
+	// saving through the compressing store converts the keystore
+	alice, err := compressed.LoadAccount("alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := compressed.SaveAccount(alice); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(dir + "/alice.json"); !os.IsNotExist(err) {
+		t.Fatalf("uncompressed keystore left in place: %v", err)
+	}
+	if backups, err := compressed.ListBackups("alice"); err != nil || len(backups) != 1 {
+		t.Fatalf("ListBackups() = %v, %v, want the uncompressed keystore", backups, err)
+	}
+	if _, err := plain.LoadAccount("alice"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGunzipKeystore(t *testing.T) {
+	keystore := []byte(`{"name": "alice"}`)
+	small, err := gzipKeystore(keystore)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bomb, err := gzipKeystore(bytes.Repeat([]byte(" "), MaxKeystoreSize+1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name    string
+		data    []byte
+		want    []byte
+		wantErr error
+	}{
+		{"plain", keystore, keystore, nil},
+		{"gzip", small, keystore, nil},
+		{"empty", nil, nil, nil},
+		{"gzip bomb", bomb, nil, ErrKeystoreTooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := gunzipKeystore(tt.data, "test")
+			if !errors.Is(err, tt.wantErr) || !bytes.Equal(got, tt.want) {
+				t.Fatalf("gunzipKeystore() = %q, %v, want %q, %v", got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+	if _, err := gunzipKeystore(small[:len(small)/2], "test"); err == nil {
+		t.Fatal("truncated gzip accepted")
+	}
+}
@@ -0,0 +1,116 @@
+package sdk
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// PermissionChange is a permission held by both accounts whose public key
+// or protection differs. Encryption is a description such as "plaintext"
+// or "aes-128-ctr/scrypt", never anything derived from the key itself.
+type PermissionChange struct {
+	Perm          string `json:"perm"`
+	OldPubKey     string `json:"old_pubkey"`
+	NewPubKey     string `json:"new_pubkey"`
+	OldEncryption string `json:"old_encryption"`
+	NewEncryption string `json:"new_encryption"`
+}
+
+func (c PermissionChange) PubKeyChanged() bool {
+	return c.OldPubKey != c.NewPubKey
+}
+
+func (c PermissionChange) EncryptionChanged() bool {
+	return c.OldEncryption != c.NewEncryption
+}
+
+// AccountDiff is what DiffAccounts found, each list sorted by permission.
+type AccountDiff struct {
+	Added   []string           `json:"added,omitempty"`
+	Removed []string           `json:"removed,omitempty"`
+	Changed []PermissionChange `json:"changed,omitempty"`
+}
+
+func (d AccountDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// String renders the diff one permission per line, "+" for added, "-" for
+// removed and "~" for changed ones.
+func (d AccountDiff) String() string {
+	var lines []string
+	for _, perm := range d.Added {
+		lines = append(lines, "+ "+perm)
+	}
+	for _, perm := range d.Removed {
+		lines = append(lines, "- "+perm)
+	}
+	for _, c := range d.Changed {
+		var what []string
+		if c.PubKeyChanged() {
+			what = append(what, fmt.Sprintf("public key %v -> %v", c.OldPubKey, c.NewPubKey))
+		}
+		if c.EncryptionChanged() {
+			what = append(what, fmt.Sprintf("encryption %v -> %v", c.OldEncryption, c.NewEncryption))
+		}
+		lines = append(lines, "~ "+c.Perm+": "+strings.Join(what, ", "))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// DiffAccounts compares the keypairs of two snapshots of an account, a
+// being the older. Only public keys and encryption metadata are looked at,
+// so a and b may be encrypted or not. A nil account has no keypairs.
+func DiffAccounts(a, b *AccountInfo) AccountDiff {
+	var old, cur map[string]*KeyPairInfo
+	if a != nil {
+		old = a.Keypairs
+	}
+	if b != nil {
+		cur = b.Keypairs
+	}
+	var d AccountDiff
+	for perm := range cur {
+		if _, ok := old[perm]; !ok {
+			d.Added = append(d.Added, perm)
+		}
+	}
+	for perm, o := range old {
+		n, ok := cur[perm]
+		if !ok {
+			d.Removed = append(d.Removed, perm)
+			continue
+		}
+		c := PermissionChange{Perm: perm, OldEncryption: describeProtection(o), NewEncryption: describeProtection(n)}
+		if o != nil {
+			c.OldPubKey = o.PubKey
+		}
+		if n != nil {
+			c.NewPubKey = n.PubKey
+		}
+		if c.PubKeyChanged() || c.EncryptionChanged() {
+			d.Changed = append(d.Changed, c)
+		}
+	}
+	sort.Strings(d.Added)
+	sort.Strings(d.Removed)
+	sort.Slice(d.Changed, func(i, j int) bool { return d.Changed[i].Perm < d.Changed[j].Perm })
+	return d
+}
+
+func describeProtection(kp *KeyPairInfo) string {
+	switch {
+	case kp == nil:
+		return "missing"
+	case kp.IsWatchOnly():
+		return "watch-only"
+	case !kp.IsEncrypted():
+		return "plaintext"
+	}
+	info, err := kp.EncryptionInfo()
+	if err != nil {
+		return "encrypted (" + err.Error() + ")"
+	}
+	return info.Cipher + "/" + info.KDF
+}
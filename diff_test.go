@@ -0,0 +1,84 @@
+package sdk
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDiffAccounts(t *testing.T) {
+	base := NewAccountInfo()
+	base.Name = "alice"
+	for _, perm := range []string{PermOwner, PermActive} {
+		if err := base.AddKeyPair(perm, newTestKeyPair(t, KeyTypeEd25519)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	owner, active := base.Keypairs[PermOwner].PubKey, base.Keypairs[PermActive].PubKey
+	replacement := newTestKeyPair(t, KeyTypeEd25519)
+	tests := []struct {
+		name   string
+		change func(t *testing.T, b *AccountInfo)
+		want   AccountDiff
+		lines  string
+	}{
+		{"unchanged", func(*testing.T, *AccountInfo) {}, AccountDiff{}, ""},
+		{"added", func(t *testing.T, b *AccountInfo) {
+			if err := b.AddKeyPair("backup", newTestKeyPair(t, KeyTypeEd25519)); err != nil {
+				t.Fatal(err)
+			}
+		}, AccountDiff{Added: []string{"backup"}}, "+ backup"},
+		{"removed", func(t *testing.T, b *AccountInfo) { delete(b.Keypairs, PermActive) },
+			AccountDiff{Removed: []string{PermActive}}, "- active"},
+		{"public key changed", func(t *testing.T, b *AccountInfo) { b.Keypairs[PermOwner] = replacement.Clone() },
+			AccountDiff{Changed: []PermissionChange{{PermOwner, owner, replacement.PubKey, "plaintext", "plaintext"}}},
+			"~ owner: public key " + owner + " -> " + replacement.PubKey},
+		{"encrypted", func(t *testing.T, b *AccountInfo) {
+			if err := b.EncryptWithOptions([]byte("pw"), EncryptOptions{KeySize: 32, Scrypt: fastScrypt, Cipher: CipherAESGCM}); err != nil {
+				t.Fatal(err)
+			}
+		}, AccountDiff{Changed: []PermissionChange{
+			{PermActive, active, active, "plaintext", "aes-256-gcm/scrypt"},
+			{PermOwner, owner, owner, "plaintext", "aes-256-gcm/scrypt"},
+		}}, "~ active: encryption plaintext -> aes-256-gcm/scrypt\n~ owner: encryption plaintext -> aes-256-gcm/scrypt"},
+		{"everything", func(t *testing.T, b *AccountInfo) {
+			delete(b.Keypairs, PermActive)
+			b.Keypairs["backup"] = newTestKeyPair(t, KeyTypeEd25519)
+			b.Keypairs[PermOwner] = replacement.Clone()
+		}, AccountDiff{
+			Added:   []string{"backup"},
+			Removed: []string{PermActive},
+			Changed: []PermissionChange{{PermOwner, owner, replacement.PubKey, "plaintext", "plaintext"}},
+		}, "+ backup\n- active\n~ owner: public key " + owner + " -> " + replacement.PubKey},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := base.Clone()
+			tt.change(t, b)
+			d := DiffAccounts(base, b)
+			if !reflect.DeepEqual(d, tt.want) {
+				t.Fatalf("DiffAccounts() = %+v, want %+v", d, tt.want)
+			}
+			if d.Empty() != (tt.lines == "") || d.String() != tt.lines {
+				t.Fatalf("String() = %q, want %q", d.String(), tt.lines)
+			}
+			data, err := json.Marshal(d)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, kp := range []*KeyPairInfo{base.Keypairs[PermOwner], base.Keypairs[PermActive], replacement} {
+				if raw := kp.RawKey.Reveal(); strings.Contains(string(data), raw) || strings.Contains(d.String(), raw) {
+					t.Fatal("diff shows a raw key")
+				}
+			}
+		})
+	}
+
+	if d := DiffAccounts(nil, base); !reflect.DeepEqual(d.Added, []string{PermActive, PermOwner}) || len(d.Removed) != 0 {
+		t.Fatalf("DiffAccounts(nil, a) = %+v", d)
+	}
+	if d := DiffAccounts(base, nil); !reflect.DeepEqual(d.Removed, []string{PermActive, PermOwner}) || len(d.Added) != 0 {
+		t.Fatalf("DiffAccounts(a, nil) = %+v", d)
+	}
+}
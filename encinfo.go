@@ -0,0 +1,75 @@
+package sdk
+
+import (
+	"fmt"
+)
+
+// EncInfo describes how a keypair is protected. For a keypair that is not
+// encrypted only Encrypted is set.
+type EncInfo struct {
+	Encrypted bool          `json:"encrypted"`
+	KDF       string        `json:"kdf,omitempty"`
+	Scrypt    *ScryptParams `json:"scrypt,omitempty"`
+	Argon2    *Argon2Params `json:"argon2,omitempty"`
+	Cipher    string        `json:"cipher,omitempty"`
+	KeySize   int           `json:"key_size,omitempty"`
+	Wrapper   string        `json:"wrapper,omitempty"`
+}
+
+// EncryptionInfo reports the KDF and cipher from the stored fields alone;
+// no password is needed and no key material is decoded. Defaults are
+// filled in the same way Decrypt fills them for older keystores.
+func (k *KeyPairInfo) EncryptionInfo() (EncInfo, error) {
+	if k.EncryptedKey == "" {
+		return EncInfo{}, nil
+	}
+	info := EncInfo{Encrypted: true, KDF: k.KDF, KeySize: k.KeySize, Wrapper: k.Wrapper}
+	if info.KeySize == 0 {
+		info.KeySize = 16
+	}
+	if info.KeySize != 16 && info.KeySize != 32 {
+		return EncInfo{}, fmt.Errorf("%w %v", ErrInvalidKeySize, info.KeySize)
+	}
+	info.Cipher = fmt.Sprintf("aes-%d-ctr", info.KeySize*8)
+	if k.Cipher == CipherAESGCM {
+		info.Cipher = fmt.Sprintf("aes-%d-gcm", info.KeySize*8)
+	}
+	switch info.KDF {
+	case "", KDFScrypt:
+		params := DefaultScryptParams
+		if k.ScryptParams != nil {
+			params = *k.ScryptParams
+		}
+		info.KDF, info.Scrypt = KDFScrypt, &params
+	case KDFArgon2id:
+		params := DefaultArgon2Params
+		if k.Argon2Params != nil {
+			params = *k.Argon2Params
+		}
+		info.Argon2 = &params
+	case KDFNone, KDFKeyWrapper:
+	default:
+		return EncInfo{}, fmt.Errorf("unsupported kdf %v", k.KDF)
+	}
+	return info, nil
+}
+
+// EncryptionSummary is EncryptionInfo for every keypair, keyed by
+// permission. Keypairs whose metadata is broken are reported in a
+// KeyPairErrors and left out of the map.
+func (a *AccountInfo) EncryptionSummary() (map[string]EncInfo, error) {
+	summary := make(map[string]EncInfo, len(a.Keypairs))
+	errs := KeyPairErrors{}
+	for perm, kp := range a.Keypairs {
+		info, err := kp.EncryptionInfo()
+		if err != nil {
+			errs[perm] = err
+			continue
+		}
+		summary[perm] = info
+	}
+	if len(errs) > 0 {
+		return summary, errs
+	}
+	return summary, nil
+}
@@ -0,0 +1,90 @@
+package sdk
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestEncryptionInfo(t *testing.T) {
+	argon2 := Argon2Params{Time: 1, Memory: 1024, Threads: 1}
+	tests := []struct {
+		name string
+		opts *EncryptOptions
+		want EncInfo
+	}{
+		{"plaintext", nil, EncInfo{}},
+		{"scrypt", &EncryptOptions{Scrypt: fastScrypt},
+			EncInfo{Encrypted: true, KDF: KDFScrypt, Scrypt: &fastScrypt, Cipher: "aes-128-ctr", KeySize: 16}},
+		{"scrypt aes-256-gcm", &EncryptOptions{KeySize: 32, Scrypt: fastScrypt, Cipher: CipherAESGCM, AAD: []byte("aad")},
+			EncInfo{Encrypted: true, KDF: KDFScrypt, Scrypt: &fastScrypt, Cipher: "aes-256-gcm", KeySize: 32}},
+		{"argon2id", &EncryptOptions{KeySize: 32, KDF: KDFArgon2id, Argon2: argon2},
+			EncInfo{Encrypted: true, KDF: KDFArgon2id, Argon2: &argon2, Cipher: "aes-256-ctr", KeySize: 32}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k := newTestKeyPair(t, KeyTypeEd25519)
+			if tt.opts != nil {
+				if err := k.EncryptWithOptions([]byte("pw"), *tt.opts); err != nil {
+					t.Fatal(err)
+				}
+			}
+			got, err := k.EncryptionInfo()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("EncryptionInfo() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEncryptionInfoLegacy(t *testing.T) {
+	// keystores from before KDF and KeySize were recorded
+	k := &KeyPairInfo{EncryptedKey: "abc", Salt: "abc", Mac: "abc"}
+	got, err := k.EncryptionInfo()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := EncInfo{Encrypted: true, KDF: KDFScrypt, Scrypt: &DefaultScryptParams, Cipher: "aes-128-ctr", KeySize: 16}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("EncryptionInfo() = %+v, want %+v", got, want)
+	}
+
+	for name, k := range map[string]*KeyPairInfo{
+		"bad key size": {EncryptedKey: "abc", KeySize: 24},
+		"unknown kdf":  {EncryptedKey: "abc", KDF: "pbkdf2"},
+	} {
+		if _, err := k.EncryptionInfo(); err == nil {
+			t.Fatalf("%v: EncryptionInfo succeeded", name)
+		}
+	}
+}
+
+func TestEncryptionSummary(t *testing.T) {
+	a := NewAccountInfo()
+	for _, perm := range []string{PermOwner, PermActive, "broken"} {
+		if err := a.AddKeyPair(perm, newTestKeyPair(t, KeyTypeEd25519)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := a.EncryptPermission(PermOwner, []byte("pw")); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.EncryptPermission("broken", []byte("pw")); err != nil {
+		t.Fatal(err)
+	}
+	a.Keypairs["broken"].KDF = "pbkdf2"
+	summary, err := a.EncryptionSummary()
+	var kpErrs KeyPairErrors
+	if !errors.As(err, &kpErrs) || len(kpErrs) != 1 || kpErrs["broken"] == nil {
+		t.Fatalf("EncryptionSummary() error = %v, want only broken to fail", err)
+	}
+	if len(summary) != 2 || !summary[PermOwner].Encrypted || summary[PermActive].Encrypted {
+		t.Fatalf("EncryptionSummary() = %+v", summary)
+	}
+	if summary[PermOwner].Scrypt == nil || *summary[PermOwner].Scrypt != DefaultScryptParams {
+		t.Fatalf("owner scrypt parameters %+v, want the defaults", summary[PermOwner].Scrypt)
+	}
+}
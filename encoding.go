@@ -0,0 +1,85 @@
+package sdk
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"github.com/quantosnetwork/dev-0.1.0/common"
+)
+
+// Encoding is a text form for signatures exchanged with other tools.
+type Encoding string
+
+const (
+	EncodingBase58 Encoding = "base58"
+	EncodingHex    Encoding = "hex"
+	EncodingBase64 Encoding = "base64"
+)
+
+func (e Encoding) encode(b []byte) (string, error) {
+	switch e {
+	case EncodingBase58:
+		return common.EncodeBase58(b), nil
+	case EncodingHex:
+		return hex.EncodeToString(b), nil
+	case EncodingBase64:
+		return base64.StdEncoding.EncodeToString(b), nil
+	}
+	return "", fmt.Errorf("unsupported encoding %q", string(e))
+}
+
+func (e Encoding) decode(s string) ([]byte, error) {
+	var b []byte
+	var err error
+	switch e {
+	case EncodingBase58:
+		if b = common.DecodeBase58(s); len(b) == 0 {
+			err = fmt.Errorf("not valid base58")
+		}
+	case EncodingHex:
+		b, err = hex.DecodeString(s)
+	case EncodingBase64:
+		b, err = base64.StdEncoding.DecodeString(s)
+	default:
+		return nil, fmt.Errorf("unsupported encoding %q", string(e))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid %v signature: %v", e, err)
+	}
+	return b, nil
+}
+
+// SignEncoded is Sign with the signature returned in enc.
+func (a *AccountInfo) SignEncoded(perm string, message []byte, enc Encoding) (string, error) {
+	// reject the encoding before a signature is made and counted
+	if _, err := enc.encode(nil); err != nil {
+		return "", err
+	}
+	sig, err := a.Sign(perm, message)
+	if err != nil {
+		return "", err
+	}
+	return enc.encode(sig)
+}
+
+// VerifyEncoded checks a signature in enc over message against the public
+// key held under perm.
+func (a *AccountInfo) VerifyEncoded(perm string, message []byte, sig string, enc Encoding) (bool, error) {
+	kp, ok := a.Keypairs[perm]
+	if !ok {
+		return false, fmt.Errorf("%w %v", ErrInvalidPermission, perm)
+	}
+	raw, err := enc.decode(sig)
+	if err != nil {
+		return false, err
+	}
+	scheme, err := schemeFor(kp.KeyType)
+	if err != nil {
+		return false, err
+	}
+	pub, err := decodeBase58Field("public_key", kp.PubKey)
+	if err != nil {
+		return false, err
+	}
+	return scheme.verify(pub, message, raw)
+}
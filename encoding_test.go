@@ -0,0 +1,97 @@
+package sdk
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"github.com/quantosnetwork/dev-0.1.0/common"
+	"testing"
+)
+
+func TestSignEncoded(t *testing.T) {
+	a := NewAccountInfo()
+	a.Name = "alice"
+	if err := a.AddKeyPair(PermOwner, newTestKeyPair(t, KeyTypeEd25519)); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.AddKeyPair(PermActive, newTestKeyPair(t, KeyTypeP256)); err != nil {
+		t.Fatal(err)
+	}
+	msg := []byte("transfer 10 to bob")
+	raw, err := a.Sign(PermOwner, msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		enc    Encoding
+		encode func([]byte) string
+	}{
+		{EncodingBase58, common.EncodeBase58},
+		{EncodingHex, hex.EncodeToString},
+		{EncodingBase64, base64.StdEncoding.EncodeToString},
+	}
+	for _, tt := range tests {
+		for _, perm := range []string{PermOwner, PermActive} {
+			t.Run(string(tt.enc)+"/"+perm, func(t *testing.T) {
+				sig, err := a.SignEncoded(perm, msg, tt.enc)
+				if err != nil {
+					t.Fatal(err)
+				}
+				// ed25519 signatures are deterministic
+				if perm == PermOwner && sig != tt.encode(raw) {
+					t.Fatalf("SignEncoded() = %v, want %v", sig, tt.encode(raw))
+				}
+				if ok, err := a.VerifyEncoded(perm, msg, sig, tt.enc); !ok || err != nil {
+					t.Fatalf("VerifyEncoded() = %v, %v", ok, err)
+				}
+				if ok, err := a.VerifyEncoded(perm, []byte("transfer 99 to bob"), sig, tt.enc); ok || err != nil {
+					t.Fatalf("VerifyEncoded() of another message = %v, %v", ok, err)
+				}
+			})
+		}
+	}
+}
+
+func TestSignEncodedRejects(t *testing.T) {
+	a := NewAccountInfo()
+	if err := a.AddKeyPair(PermOwner, newTestKeyPair(t, KeyTypeEd25519)); err != nil {
+		t.Fatal(err)
+	}
+	msg := []byte("msg")
+	sig, err := a.SignEncoded(PermOwner, msg, EncodingHex)
+	if err != nil {
+		t.Fatal(err)
+	}
+	uses := a.Keypairs[PermOwner].Uses
+	if _, err := a.SignEncoded(PermOwner, msg, "base32"); err == nil {
+		t.Fatal("signed with an unknown encoding")
+	}
+	if a.Keypairs[PermOwner].Uses != uses {
+		t.Fatal("failed SignEncoded counted a use")
+	}
+	tests := []struct {
+		name    string
+		perm    string
+		sig     string
+		enc     Encoding
+		wantErr error
+	}{
+		{"unknown encoding", PermOwner, sig, "base32", nil},
+		{"not hex", PermOwner, "zz" + sig, EncodingHex, nil},
+		{"not base58", PermOwner, "0OIl", EncodingBase58, nil},
+		{"not base64", PermOwner, "!" + sig, EncodingBase64, nil},
+		{"unknown permission", "missing", sig, EncodingHex, ErrInvalidPermission},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ok, err := a.VerifyEncoded(tt.perm, msg, tt.sig, tt.enc)
+			if ok || err == nil || tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Fatalf("VerifyEncoded() = %v, %v, want an error %v", ok, err, tt.wantErr)
+			}
+		})
+	}
+	// a signature read with the wrong encoding does not verify
+	if ok, _ := a.VerifyEncoded(PermOwner, msg, sig, EncodingBase64); ok {
+		t.Fatal("hex signature verified as base64")
+	}
+}
@@ -0,0 +1,100 @@
+package sdk
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+var (
+	ErrWrongPassword       = errors.New("wrong password")
+	ErrAlreadyEncrypted    = errors.New("already encrypted")
+	ErrNotEncrypted        = errors.New("not encrypted")
+	ErrEmptyKey            = errors.New("empty key")
+	ErrInvalidKeySize      = errors.New("invalid key size")
+	ErrInvalidPermission   = errors.New("invalid permission")
+	ErrInvalidKeyPair      = errors.New("invalid keypair")
+	ErrStillEncrypted      = errors.New("keypair is encrypted, decrypt it first")
+	ErrPublicKeyMismatch   = errors.New("public key does not match private key")
+	ErrUnsupportedKey      = errors.New("unsupported key type")
+	ErrPermissionExists    = errors.New("permission already exists")
+	ErrLastKeyPair         = errors.New("refusing to remove the last keypair")
+	ErrDuplicateID         = errors.New("duplicate keypair id")
+	ErrMixedEncryption     = errors.New("only some keypairs are encrypted")
+	ErrReadOnly            = errors.New("account store is read-only")
+	ErrPasswordMismatch    = errors.New("passwords do not match")
+	ErrWeakPassword        = errors.New("weak password")
+	ErrThresholdNotMet     = errors.New("not enough valid signatures")
+	ErrCorruptKeystore     = errors.New("corrupt keystore")
+	ErrUnsupportedVersion  = errors.New("unsupported keystore version")
+	ErrWatchOnly           = errors.New("watch-only keypair has no private key")
+	ErrThrottled           = errors.New("too many wrong passwords, try again later")
+	ErrKeystoreTooLarge    = errors.New("keystore too large")
+	ErrStoreNotInitialized = errors.New("account directory does not exist, run Init first")
+	ErrLocked              = errors.New("account store is locked by another process")
+	ErrKeyExpired          = errors.New("keypair has expired")
+	ErrKeyNotYetValid      = errors.New("keypair is not valid yet")
+	ErrUsageLimitReached   = errors.New("keypair has reached its usage limit")
+	ErrInvalidAddress      = errors.New("invalid address")
+	ErrAddressNotFound     = errors.New("no account has this address")
+	ErrAddressConflict     = errors.New("several accounts have this address")
+	ErrNotEnoughShares     = errors.New("not enough shares")
+	ErrAADRequired         = errors.New("aes-gcm keypair needs the associated data it was sealed over")
+)
+
+// KeyPairErrors collects per-permission failures, e.g. from validating all
+// keypairs of an account. errors.Is matches if any entry matches.
+type KeyPairErrors map[string]error
+
+func (e KeyPairErrors) Error() string {
+	perms := make([]string, 0, len(e))
+	for perm := range e {
+		perms = append(perms, perm)
+	}
+	sort.Strings(perms)
+	msgs := make([]string, len(perms))
+	for i, perm := range perms {
+		msgs[i] = fmt.Sprintf("%v: %v", perm, e[perm])
+	}
+	return strings.Join(msgs, "; ")
+}
+
+func (e KeyPairErrors) Is(target error) bool {
+	for _, err := range e {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// AccountErrors collects per-account failures of a batch operation, keyed
+// by account name.
+type AccountErrors map[string]error
+
+func (e AccountErrors) Error() string {
+	return KeyPairErrors(e).Error()
+}
+
+func (e AccountErrors) Is(target error) bool {
+	return KeyPairErrors(e).Is(target)
+}
+
+// notInitializedError is ErrStoreNotInitialized that still unwraps to the
+// error reading the directory, so fs.ErrNotExist matches as well.
+type notInitializedError struct {
+	err error
+}
+
+func (e notInitializedError) Error() string {
+	return ErrStoreNotInitialized.Error() + ": " + e.err.Error()
+}
+
+func (e notInitializedError) Is(target error) bool {
+	return target == ErrStoreNotInitialized
+}
+
+func (e notInitializedError) Unwrap() error {
+	return e.err
+}
@@ -0,0 +1,71 @@
+package sdk
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestSentinelErrors(t *testing.T) {
+	encrypted := func(t *testing.T) *KeyPairInfo {
+		kp := newTestKeyPair(t, KeyTypeEd25519)
+		if err := kp.EncryptWithParams([]byte("pw"), fastScrypt); err != nil {
+			t.Fatal(err)
+		}
+		return kp
+	}
+	tests := []struct {
+		name    string
+		run     func(t *testing.T) error
+		wantErr error
+	}{
+		{"wrong password", func(t *testing.T) error {
+			return encrypted(t).Decrypt([]byte("wrong"))
+		}, ErrWrongPassword},
+		{"already encrypted", func(t *testing.T) error {
+			return encrypted(t).Encrypt([]byte("pw"))
+		}, ErrAlreadyEncrypted},
+		{"not encrypted", func(t *testing.T) error {
+			return newTestKeyPair(t, KeyTypeEd25519).Decrypt([]byte("pw"))
+		}, ErrNotEncrypted},
+		{"empty key", func(t *testing.T) error {
+			_, err := ImportPrivateKey("", string(KeyTypeEd25519))
+			return err
+		}, ErrEmptyKey},
+		{"invalid permission", func(t *testing.T) error {
+			return NewAccountInfo().EncryptPermission("nope", []byte("pw"))
+		}, ErrInvalidPermission},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.run(t); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestKeyPairErrors(t *testing.T) {
+	errs := KeyPairErrors{
+		PermOwner:  ErrWrongPassword,
+		PermActive: ErrInvalidKeyPair,
+	}
+	if got, want := errs.Error(), "active: invalid keypair; owner: wrong password"; got != want {
+		t.Fatalf("Error() = %q, want %q", got, want)
+	}
+	tests := []struct {
+		target error
+		want   bool
+	}{
+		{ErrWrongPassword, true},
+		{ErrInvalidKeyPair, true},
+		{ErrNotEncrypted, false},
+	}
+	for _, tt := range tests {
+		if got := errors.Is(errs, tt.target); got != tt.want {
+			t.Errorf("errors.Is(%v) = %v, want %v", tt.target, got, tt.want)
+		}
+		if got := errors.Is(AccountErrors(errs), tt.target); got != tt.want {
+			t.Errorf("AccountErrors: errors.Is(%v) = %v, want %v", tt.target, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,20 @@
+package sdk
+
+import (
+	"runtime"
+)
+
+// NewUnlockedAccountInfo is NewAccountInfo with WipeOnCollect applied.
+func NewUnlockedAccountInfo() *AccountInfo {
+	return WipeOnCollect(NewAccountInfo())
+}
+
+// WipeOnCollect makes the garbage collector wipe the account's raw keys
+// when it collects a, and returns a. This is a backstop for callers that
+// forget to: finalizers run late or not at all, e.g. when the program
+// exits, so call Wipe as soon as the keys are no longer needed. Keypairs
+// still referenced from elsewhere are wiped too once a is unreachable.
+func WipeOnCollect(a *AccountInfo) *AccountInfo {
+	runtime.SetFinalizer(a, (*AccountInfo).Wipe)
+	return a
+}
@@ -0,0 +1,55 @@
+package sdk
+
+import (
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestWipeOnCollect(t *testing.T) {
+	tests := []struct {
+		name string
+		new  func() *AccountInfo
+	}{
+		{"NewUnlockedAccountInfo", NewUnlockedAccountInfo},
+		{"WipeOnCollect", func() *AccountInfo { return WipeOnCollect(NewAccountInfo()) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// the sentinel signer is only reachable through the account, so
+			// its finalizer runs after the account's, on the same goroutine
+			done := make(chan struct{})
+			kp := func() *KeyPairInfo {
+				a := tt.new()
+				a.Name = "alice"
+				kp := newTestKeyPair(t, KeyTypeEd25519)
+				if err := a.AddKeyPair(PermOwner, kp); err != nil {
+					t.Fatal(err)
+				}
+				sentinel := &mockSigner{kp: newTestKeyPair(t, KeyTypeEd25519)}
+				runtime.SetFinalizer(sentinel, func(*mockSigner) { close(done) })
+				if err := a.RegisterSigner(PermActive, KeyTypeEd25519, sentinel); err != nil {
+					t.Fatal(err)
+				}
+				return kp
+			}()
+			if kp.RawKey.IsEmpty() {
+				t.Fatal("raw key wiped while the account was reachable")
+			}
+			deadline := time.After(10 * time.Second)
+			for collected := false; !collected; {
+				runtime.GC()
+				select {
+				case <-done:
+					collected = true
+				case <-time.After(10 * time.Millisecond):
+				case <-deadline:
+					t.Fatal("account was not collected")
+				}
+			}
+			if !kp.RawKey.IsEmpty() {
+				t.Fatal("raw key survived collection of the account")
+			}
+		})
+	}
+}
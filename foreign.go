@@ -0,0 +1,102 @@
+package sdk
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ImporterFunc converts a keystore written by another tool into an
+// account.
+type ImporterFunc func(data []byte) (*AccountInfo, error)
+
+// FormatIWallet is the account file of IOST's iwallet: keypairs keyed by
+// permission with a plaintext raw_key, key_type and public_key, and no IDs.
+const FormatIWallet = "iwallet"
+
+var importers = struct {
+	sync.RWMutex
+	m map[string]ImporterFunc
+}{m: map[string]ImporterFunc{FormatIWallet: importIWallet}}
+
+// RegisterImporter makes fn available to ImportForeign as format,
+// replacing any importer already registered under that name.
+func RegisterImporter(format string, fn ImporterFunc) {
+	if fn == nil {
+		panic("sdk: RegisterImporter with nil importer")
+	}
+	importers.Lock()
+	defer importers.Unlock()
+	importers.m[format] = fn
+}
+
+// ImportForeign converts data in the named format and validates the
+// resulting account as LoadAccount would.
+func ImportForeign(data []byte, format string) (*AccountInfo, error) {
+	importers.RLock()
+	fn := importers.m[format]
+	importers.RUnlock()
+	if fn == nil {
+		return nil, fmt.Errorf("unknown keystore format %q, known formats are %v", format, strings.Join(ImportFormats(), ", "))
+	}
+	a, err := fn(data)
+	if err != nil {
+		return nil, fmt.Errorf("importing %v keystore: %w", format, err)
+	}
+	return checkAccount(a, format+" import")
+}
+
+// ImportFormats lists the registered formats in sorted order.
+func ImportFormats() []string {
+	importers.RLock()
+	defer importers.RUnlock()
+	formats := make([]string, 0, len(importers.m))
+	for format := range importers.m {
+		formats = append(formats, format)
+	}
+	sort.Strings(formats)
+	return formats
+}
+
+type iwalletAccount struct {
+	Name     string `json:"name"`
+	Keypairs map[string]struct {
+		RawKey  string `json:"raw_key"`
+		KeyType string `json:"key_type"`
+		PubKey  string `json:"public_key"`
+	} `json:"keypairs"`
+}
+
+// importIWallet gives every keypair a fresh ID and checks its public key
+// against the private key.
+func importIWallet(data []byte) (*AccountInfo, error) {
+	var w iwalletAccount
+	if err := json.Unmarshal(data, &w); err != nil {
+		return nil, err
+	}
+	if len(w.Keypairs) == 0 {
+		return nil, fmt.Errorf("%w: account %v has no keypairs", ErrInvalidKeyPair, w.Name)
+	}
+	a := NewAccountInfo()
+	a.Name = w.Name
+	errs := KeyPairErrors{}
+	for perm, k := range w.Keypairs {
+		kp, err := ImportPrivateKey(k.RawKey, k.KeyType)
+		if err == nil && k.PubKey != "" && k.PubKey != kp.PubKey {
+			err = ErrPublicKeyMismatch
+		}
+		if err == nil {
+			err = a.AddKeyPair(perm, kp)
+		}
+		if err != nil {
+			errs[perm] = err
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	a.stamp()
+	return a, nil
+}
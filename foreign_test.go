@@ -0,0 +1,124 @@
+package sdk
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/quantosnetwork/dev-0.1.0/common"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestImportIWalletGolden(t *testing.T) {
+	data, err := os.ReadFile("testdata/iwallet.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, err := ImportForeign(data, FormatIWallet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Name != "alice" {
+		t.Fatalf("name = %q", a.Name)
+	}
+	// the RFC 8032 and P-256 d = 1 vectors of TestImportPrivateKeyVectors
+	want := map[string]struct {
+		keyType KeyType
+		pub     string
+	}{
+		PermOwner:  {KeyTypeEd25519, "d75a980182b10ab7d54bfed3c964073a0ee172f3daa62325af021a68f707511a"},
+		PermActive: {KeyTypeP256, "036b17d1f2e12c4247f8bce6e563a440f277037d812deb33a0f4a13945d898c296"},
+	}
+	if len(a.Keypairs) != len(want) {
+		t.Fatalf("imported permissions %v", a.Permissions())
+	}
+	for perm, w := range want {
+		kp := a.Keypairs[perm]
+		if kp == nil {
+			t.Fatalf("no %v keypair", perm)
+		}
+		if kp.KeyType != w.keyType || hex.EncodeToString(common.DecodeBase58(kp.PubKey)) != w.pub {
+			t.Fatalf("%v: %v key %v, want %v key %v", perm, kp.KeyType, kp.PubKey, w.keyType, w.pub)
+		}
+		if kp.ID == "" || kp.IsEncrypted() {
+			t.Fatalf("%v: id %q, encrypted %v", perm, kp.ID, kp.IsEncrypted())
+		}
+	}
+}
+
+func TestImportForeignRejects(t *testing.T) {
+	kp := newTestKeyPair(t, KeyTypeEd25519)
+	other := newTestKeyPair(t, KeyTypeEd25519)
+	iwallet := func(pub string) []byte {
+		return []byte(fmt.Sprintf(`{"name": "alice", "keypairs": {"owner": {"raw_key": %q, "key_type": "ed25519", "public_key": %q}}}`, kp.RawKey.Reveal(), pub))
+	}
+	tests := []struct {
+		name    string
+		data    []byte
+		format  string
+		wantErr error
+		wantMsg string
+	}{
+		{"unknown format", iwallet(""), "keepass", nil, `unknown keystore format "keepass", known formats are iwallet`},
+		{"not json", []byte("{"), FormatIWallet, nil, "importing iwallet keystore"},
+		{"no keypairs", []byte(`{"name": "alice", "keypairs": {}}`), FormatIWallet, ErrInvalidKeyPair, "no keypairs"},
+		{"public key mismatch", iwallet(other.PubKey), FormatIWallet, ErrPublicKeyMismatch, "owner"},
+		{"unsupported key type", []byte(`{"name": "alice", "keypairs": {"owner": {"raw_key": "x", "key_type": "rsa"}}}`), FormatIWallet, ErrUnsupportedKey, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, err := ImportForeign(tt.data, tt.format)
+			if err == nil || tt.wantErr != nil && !errors.Is(err, tt.wantErr) || !strings.Contains(err.Error(), tt.wantMsg) {
+				t.Fatalf("ImportForeign() = %v, %v, want %v containing %q", a, err, tt.wantErr, tt.wantMsg)
+			}
+		})
+	}
+	// without public_key the key is taken as is
+	a, err := ImportForeign(iwallet(""), FormatIWallet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Keypairs[PermOwner].PubKey != kp.PubKey {
+		t.Fatal("derived a different public key")
+	}
+}
+
+func TestRegisterImporter(t *testing.T) {
+	t.Cleanup(func() {
+		importers.Lock()
+		delete(importers.m, "test")
+		importers.Unlock()
+	})
+	kp := newTestKeyPair(t, KeyTypeEd25519)
+	RegisterImporter("test", func(data []byte) (*AccountInfo, error) {
+		a := NewAccountInfo()
+		a.Name = string(data)
+		c := kp.Clone()
+		if a.Name == "broken" {
+			c.KeyType = "rsa"
+		}
+		a.Keypairs[PermOwner] = c
+		return a, nil
+	})
+	if got := strings.Join(ImportFormats(), ","); got != "iwallet,test" {
+		t.Fatalf("ImportFormats() = %v", got)
+	}
+	a, err := ImportForeign([]byte("bob"), "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Name != "bob" || a.Keypairs[PermOwner].PubKey != kp.PubKey {
+		t.Fatalf("imported %+v", a)
+	}
+	// the result is validated like a loaded keystore
+	if _, err := ImportForeign([]byte("broken"), "test"); !errors.Is(err, ErrInvalidKeyPair) {
+		t.Fatalf("importing an invalid keypair = %v, want %v", err, ErrInvalidKeyPair)
+	}
+	defer func() {
+		if recover() == nil {
+			t.Fatal("RegisterImporter(nil) did not panic")
+		}
+	}()
+	RegisterImporter("nil", nil)
+}
@@ -0,0 +1,72 @@
+package sdk
+
+import (
+	"fmt"
+	"github.com/tyler-smith/go-bip32"
+	"strconv"
+	"strings"
+)
+
+// HDWallet derives keypairs from a single master seed following BIP32, so
+// only the seed needs backing up. KeyType picks the scheme each derived
+// private key is used with and is recorded on every derived keypair;
+// NewHDWallet sets it to KeyTypeQuantos.
+type HDWallet struct {
+	KeyType KeyType
+	master  *bip32.Key
+}
+
+func NewHDWallet(seed []byte) (*HDWallet, error) {
+	master, err := bip32.NewMasterKey(seed)
+	if err != nil {
+		return nil, err
+	}
+	return &HDWallet{KeyType: KeyTypeQuantos, master: master}, nil
+}
+
+// DeriveKeyPair derives the keypair at path, e.g. m/44'/0'/0'/0/0. A
+// trailing ' (or h) marks a hardened segment. The path is recorded on the
+// returned keypair so it can be derived again later.
+func (w *HDWallet) DeriveKeyPair(path string) (*KeyPairInfo, error) {
+	indexes, err := parseDerivationPath(path)
+	if err != nil {
+		return nil, err
+	}
+	key := w.master
+	for _, i := range indexes {
+		key, err = key.NewChildKey(i)
+		if err != nil {
+			return nil, fmt.Errorf("deriving %v: %v", path, err)
+		}
+	}
+	kp, err := keyPairFromSeed(key.Key, w.KeyType)
+	if err != nil {
+		return nil, err
+	}
+	kp.Path = path
+	return kp, nil
+}
+
+func parseDerivationPath(path string) ([]uint32, error) {
+	segments := strings.Split(path, "/")
+	if segments[0] != "m" {
+		return nil, fmt.Errorf("invalid derivation path %q: must start with m", path)
+	}
+	indexes := make([]uint32, 0, len(segments)-1)
+	for _, seg := range segments[1:] {
+		hardened := false
+		if strings.HasSuffix(seg, "'") || strings.HasSuffix(seg, "h") || strings.HasSuffix(seg, "H") {
+			hardened = true
+			seg = seg[:len(seg)-1]
+		}
+		i, err := strconv.ParseUint(seg, 10, 31)
+		if err != nil {
+			return nil, fmt.Errorf("invalid derivation path %q: bad segment %q", path, seg)
+		}
+		if hardened {
+			i += uint64(bip32.FirstHardenedChild)
+		}
+		indexes = append(indexes, uint32(i))
+	}
+	return indexes, nil
+}
@@ -0,0 +1,120 @@
+package sdk
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+func TestNewHDWalletKeyType(t *testing.T) {
+	w, err := NewHDWallet(make([]byte, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w.KeyType != KeyTypeQuantos {
+		t.Fatalf("KeyType = %q, want %q", w.KeyType, KeyTypeQuantos)
+	}
+}
+
+// TestHDWalletBIP32Vector checks derived private keys against test vector 1
+// of BIP32. The ed25519 scheme uses the derived key as its seed unchanged.
+func TestHDWalletBIP32Vector(t *testing.T) {
+	seed, _ := hex.DecodeString("000102030405060708090a0b0c0d0e0f")
+	tests := []struct {
+		path string
+		priv string
+	}{
+		{"m", "e8f32e723decf4051aefac8e2c93c9c5b214313817cdb01a1494b917c8436b35"},
+		{"m/0'", "edb2e14f9ee77d26dd93b4ecede8d16ed408ce149b6cd80b0715a2d911a0afea"},
+		{"m/0h/1", "3c6cb8d0f6a264c91ea8b5030fadaa8e538b020f0a387421a12de9319dc93368"},
+		{"m/0'/1/2'", "cbce0d719ecf7431d88e6a89fa1483e02e35092af60c042b1df2ff59fa424dca"},
+	}
+	w, err := NewHDWallet(seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.KeyType = KeyTypeEd25519
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			kp, err := w.DeriveKeyPair(tt.path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := hex.EncodeToString(kp.RawKey.decode()); got != tt.priv {
+				t.Fatalf("private key = %v, want %v", got, tt.priv)
+			}
+		})
+	}
+}
+
+func TestHDWalletDeterministic(t *testing.T) {
+	seed := []byte("an hd wallet seed of thirty-two!")
+	for _, kt := range []KeyType{KeyTypeEd25519, KeyTypeP256} {
+		t.Run(string(kt), func(t *testing.T) {
+			w, err := NewHDWallet(seed)
+			if err != nil {
+				t.Fatal(err)
+			}
+			w.KeyType = kt
+			a, err := w.DeriveKeyPair("m/44'/0'/0'/0/0")
+			if err != nil {
+				t.Fatal(err)
+			}
+			b, err := w.DeriveKeyPair("m/44'/0'/0'/0/0")
+			if err != nil {
+				t.Fatal(err)
+			}
+			c, err := w.DeriveKeyPair("m/44'/0'/0'/0/1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if a.PubKey != b.PubKey || a.RawKey.Reveal() != b.RawKey.Reveal() {
+				t.Fatal("same path derived different keys")
+			}
+			if a.PubKey == c.PubKey {
+				t.Fatal("different paths derived the same key")
+			}
+			if a.KeyType != kt || a.Path != "m/44'/0'/0'/0/0" {
+				t.Fatalf("keypair records key type %v and path %v", a.KeyType, a.Path)
+			}
+			if err := a.VerifyPublicKey(); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestParseDerivationPath(t *testing.T) {
+	const h = 0x80000000
+	tests := []struct {
+		path string
+		want []uint32
+		ok   bool
+	}{
+		{"m", []uint32{}, true},
+		{"m/0", []uint32{0}, true},
+		{"m/44'/0h/1H/2", []uint32{44 + h, h, 1 + h, 2}, true},
+		{"m/2147483647'", []uint32{2147483647 + h}, true},
+		{"", nil, false},
+		{"44'/0'", nil, false},
+		{"m/", nil, false},
+		{"m/x", nil, false},
+		{"m/-1", nil, false},
+		{"m/2147483648", nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			got, err := parseDerivationPath(tt.path)
+			if (err == nil) != tt.ok {
+				t.Fatalf("parseDerivationPath() error = %v, want ok=%v", err, tt.ok)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("parseDerivationPath() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("parseDerivationPath() = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
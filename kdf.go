@@ -0,0 +1,160 @@
+package sdk
+
+import (
+	"fmt"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/hkdf"
+	"golang.org/x/crypto/scrypt"
+	"golang.org/x/crypto/sha3"
+	"io"
+	"time"
+)
+
+const (
+	KDFScrypt   = "scrypt"
+	KDFArgon2id = "argon2id"
+	// KDFNone marks keypairs encrypted with EncryptWithKey.
+	KDFNone = "none"
+)
+
+type ScryptParams struct {
+	N int `json:"n"`
+	R int `json:"r"`
+	P int `json:"p"`
+}
+
+var DefaultScryptParams = ScryptParams{N: 32768, R: 8, P: 1}
+
+const (
+	minScryptN = 1 << 10
+	maxScryptN = 1 << 20
+	maxScryptR = 32
+	maxScryptP = 16
+	// maxScryptMemory caps the 128*N*r bytes scrypt allocates
+	maxScryptMemory = 1 << 30
+)
+
+func (p ScryptParams) validate() error {
+	if p.N < minScryptN || p.N > maxScryptN || p.N&(p.N-1) != 0 {
+		return fmt.Errorf("scrypt N must be a power of two between %v and %v, got %v", minScryptN, maxScryptN, p.N)
+	}
+	if p.R < 1 || p.R > maxScryptR || p.P < 1 || p.P > maxScryptP {
+		return fmt.Errorf("invalid scrypt parameters r=%v p=%v", p.R, p.P)
+	}
+	if 128*p.N*p.R > maxScryptMemory {
+		return fmt.Errorf("scrypt parameters n=%v r=%v need more than %v bytes", p.N, p.R, maxScryptMemory)
+	}
+	return nil
+}
+
+// BenchmarkKDF times one scrypt derivation with params on this machine,
+// which is about what unlocking a keystore that uses them costs.
+func BenchmarkKDF(params ScryptParams) (time.Duration, error) {
+	if err := params.validate(); err != nil {
+		return 0, err
+	}
+	salt := make([]byte, 32)
+	start := time.Now()
+	key, err := scrypt.Key([]byte("benchmark"), salt, params.N, params.R, params.P, 32)
+	elapsed := time.Since(start)
+	if err != nil {
+		return 0, err
+	}
+	wipeBytes(key)
+	return elapsed, nil
+}
+
+// RecommendScryptParams picks the N, with r=8 and p=1, whose derivation
+// time on this machine is closest to target. Timings are noisy, so treat
+// the result as a rough calibration.
+func RecommendScryptParams(target time.Duration) (ScryptParams, error) {
+	if target <= 0 {
+		return ScryptParams{}, fmt.Errorf("invalid target duration %v", target)
+	}
+	best := ScryptParams{N: minScryptN, R: 8, P: 1}
+	bestDiff := time.Duration(-1)
+	for n := minScryptN; n <= maxScryptN; n *= 2 {
+		params := ScryptParams{N: n, R: 8, P: 1}
+		d, err := BenchmarkKDF(params)
+		if err != nil {
+			return ScryptParams{}, err
+		}
+		diff := d - target
+		if diff < 0 {
+			diff = -diff
+		}
+		if bestDiff < 0 || diff < bestDiff {
+			best, bestDiff = params, diff
+		}
+		// every doubling of N doubles the time, so nothing further is closer
+		if d >= target {
+			break
+		}
+	}
+	return best, nil
+}
+
+// Argon2Params configures argon2id. Memory is in KiB.
+type Argon2Params struct {
+	Time    uint32 `json:"time"`
+	Memory  uint32 `json:"memory"`
+	Threads uint8  `json:"threads"`
+}
+
+var DefaultArgon2Params = Argon2Params{Time: 1, Memory: 64 * 1024, Threads: 4}
+
+const (
+	maxArgon2Memory = 1 << 20
+	maxArgon2Time   = 32
+)
+
+func (p Argon2Params) validate() error {
+	if p.Time < 1 || p.Time > maxArgon2Time || p.Threads < 1 {
+		return fmt.Errorf("invalid argon2 parameters time=%v threads=%v", p.Time, p.Threads)
+	}
+	if p.Memory < 8*uint32(p.Threads) || p.Memory > maxArgon2Memory {
+		return fmt.Errorf("argon2 memory must be between %v and %v KiB, got %v", 8*uint32(p.Threads), maxArgon2Memory, p.Memory)
+	}
+	return nil
+}
+
+// deriveKey runs the keypair's KDF over the password. Keystores written
+// before the KDF field existed are scrypt.
+func (k *KeyPairInfo) deriveKey(password, salt []byte, size int) ([]byte, error) {
+	switch k.KDF {
+	case "", KDFScrypt:
+		params := DefaultScryptParams
+		if k.ScryptParams != nil {
+			params = *k.ScryptParams
+		}
+		if err := params.validate(); err != nil {
+			return nil, err
+		}
+		return scrypt.Key(password, salt, params.N, params.R, params.P, size)
+	case KDFArgon2id:
+		params := DefaultArgon2Params
+		if k.Argon2Params != nil {
+			params = *k.Argon2Params
+		}
+		if err := params.validate(); err != nil {
+			return nil, err
+		}
+		return argon2.IDKey(password, salt, params.Time, params.Memory, params.Threads, uint32(size)), nil
+	case KDFNone:
+		return nil, fmt.Errorf("keypair is encrypted with a key, use DecryptWithKey")
+	case KDFKeyWrapper:
+		return nil, fmt.Errorf("keypair is encrypted with key wrapper %v, use DecryptWithKeyWrapper", k.Wrapper)
+	default:
+		return nil, fmt.Errorf("unsupported kdf %v", k.KDF)
+	}
+}
+
+// expandKey stretches a caller supplied key into size bytes of AES and MAC
+// key with HKDF-SHA3-256.
+func expandKey(key, salt []byte, size int) ([]byte, error) {
+	out := make([]byte, size)
+	if _, err := io.ReadFull(hkdf.New(sha3.New256, key, salt, []byte("quantos keystore")), out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
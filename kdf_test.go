@@ -0,0 +1,220 @@
+package sdk
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestScryptParamsValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		params ScryptParams
+		ok     bool
+	}{
+		{"default", DefaultScryptParams, true},
+		{"minimum", ScryptParams{N: minScryptN, R: 1, P: 1}, true},
+		{"geth standard", ScryptParams{N: 262144, R: 8, P: 1}, true},
+		{"maximum memory", ScryptParams{N: maxScryptN, R: 8, P: 1}, true},
+		{"n too small", ScryptParams{N: 512, R: 8, P: 1}, false},
+		{"n too large", ScryptParams{N: maxScryptN * 2, R: 8, P: 1}, false},
+		{"n not a power of two", ScryptParams{N: 3000, R: 8, P: 1}, false},
+		{"r zero", ScryptParams{N: minScryptN, R: 0, P: 1}, false},
+		{"p zero", ScryptParams{N: minScryptN, R: 8, P: 0}, false},
+		{"r too large", ScryptParams{N: minScryptN, R: maxScryptR + 1, P: 1}, false},
+		{"p too large", ScryptParams{N: minScryptN, R: 8, P: maxScryptP + 1}, false},
+		{"r huge", ScryptParams{N: minScryptN, R: 1 << 29, P: 1}, false},
+		{"too much memory", ScryptParams{N: maxScryptN, R: 16, P: 1}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.params.validate()
+			if tt.ok && err != nil {
+				t.Fatalf("validate() = %v, want nil", err)
+			}
+			if !tt.ok && err == nil {
+				t.Fatal("validate() = nil, want error")
+			}
+		})
+	}
+}
+
+func TestOversizedScryptRejectedBeforeDerivation(t *testing.T) {
+	tests := []struct {
+		name   string
+		params ScryptParams
+	}{
+		{"r", ScryptParams{N: maxScryptN, R: 1 << 20, P: 1}},
+		{"p", ScryptParams{N: maxScryptN, R: 8, P: 1 << 20}},
+		{"memory", ScryptParams{N: maxScryptN, R: maxScryptR, P: maxScryptP}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kp := newTestKeyPair(t, KeyTypeEd25519)
+			start := time.Now()
+			if err := kp.EncryptWithParams([]byte("pw"), tt.params); err == nil {
+				t.Fatal("EncryptWithParams accepted oversized parameters")
+			}
+			if kp.IsEncrypted() {
+				t.Fatal("keypair was encrypted despite the error")
+			}
+
+			// a keystore carrying the parameters must fail the same way
+			params := tt.params
+			kp.KDF, kp.ScryptParams = KDFScrypt, &params
+			if _, err := kp.deriveKey([]byte("pw"), make([]byte, 32), 32); err == nil {
+				t.Fatal("deriveKey accepted oversized parameters")
+			}
+			if d := time.Since(start); d > time.Second {
+				t.Fatalf("rejection took %v, parameters were not checked up front", d)
+			}
+		})
+	}
+}
+
+func TestArgon2ParamsValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		params Argon2Params
+		ok     bool
+	}{
+		{"default", DefaultArgon2Params, true},
+		{"time zero", Argon2Params{Time: 0, Memory: 64, Threads: 1}, false},
+		{"time too large", Argon2Params{Time: maxArgon2Time + 1, Memory: 64, Threads: 1}, false},
+		{"threads zero", Argon2Params{Time: 1, Memory: 64, Threads: 0}, false},
+		{"memory below threads", Argon2Params{Time: 1, Memory: 8, Threads: 4}, false},
+		{"memory too large", Argon2Params{Time: 1, Memory: maxArgon2Memory + 1, Threads: 1}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.params.validate(); (err == nil) != tt.ok {
+				t.Fatalf("validate() = %v, want ok=%v", err, tt.ok)
+			}
+		})
+	}
+}
+
+func TestEncryptWithParams(t *testing.T) {
+	tests := []struct {
+		name   string
+		params ScryptParams
+		want   ScryptParams
+		legacy bool
+	}{
+		{"zero means default", ScryptParams{}, DefaultScryptParams, false},
+		{"custom", ScryptParams{N: 2048, R: 4, P: 2}, ScryptParams{N: 2048, R: 4, P: 2}, false},
+		// keystores written before the scrypt fields existed used the defaults
+		{"legacy file", DefaultScryptParams, DefaultScryptParams, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kp := newTestKeyPair(t, KeyTypeEd25519)
+			want := kp.RawKey.Reveal()
+			if err := kp.EncryptWithParams([]byte("pw"), tt.params); err != nil {
+				t.Fatal(err)
+			}
+			if kp.KDF != KDFScrypt || kp.ScryptParams == nil || *kp.ScryptParams != tt.want {
+				t.Fatalf("stored kdf %v with %+v, want scrypt with %+v", kp.KDF, kp.ScryptParams, tt.want)
+			}
+			if tt.legacy {
+				kp.KDF, kp.ScryptParams = "", nil
+			}
+			if err := kp.Decrypt([]byte("pw")); err != nil {
+				t.Fatal(err)
+			}
+			if kp.RawKey.Reveal() != want {
+				t.Fatal("Decrypt did not recover the raw key")
+			}
+		})
+	}
+}
+
+func TestKDFRoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		opts EncryptOptions
+		kdf  string
+	}{
+		{"scrypt", EncryptOptions{Scrypt: fastScrypt}, KDFScrypt},
+		{"argon2id", EncryptOptions{KDF: KDFArgon2id, Argon2: Argon2Params{Time: 1, Memory: 1024, Threads: 1}}, KDFArgon2id},
+		{"argon2id defaults", EncryptOptions{KDF: KDFArgon2id}, KDFArgon2id},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kp := newTestKeyPair(t, KeyTypeEd25519)
+			want := kp.RawKey.Reveal()
+			if err := kp.EncryptWithOptions([]byte("pw"), tt.opts); err != nil {
+				t.Fatal(err)
+			}
+			if kp.KDF != tt.kdf {
+				t.Fatalf("KDF = %q, want %q", kp.KDF, tt.kdf)
+			}
+			if err := kp.Decrypt([]byte("wrong")); !errors.Is(err, ErrWrongPassword) {
+				t.Fatalf("Decrypt(wrong) = %v, want ErrWrongPassword", err)
+			}
+			if err := kp.Decrypt([]byte("pw")); err != nil {
+				t.Fatal(err)
+			}
+			if kp.RawKey.Reveal() != want {
+				t.Fatal("Decrypt did not recover the raw key")
+			}
+		})
+	}
+}
+
+func TestUnsupportedKDF(t *testing.T) {
+	kp := newTestKeyPair(t, KeyTypeEd25519)
+	if err := kp.EncryptWithOptions([]byte("pw"), EncryptOptions{KDF: "bcrypt"}); err == nil {
+		t.Fatal("EncryptWithOptions accepted an unknown kdf")
+	}
+	if err := kp.EncryptWithParams([]byte("pw"), fastScrypt); err != nil {
+		t.Fatal(err)
+	}
+	kp.KDF = "bcrypt"
+	if err := kp.Decrypt([]byte("pw")); err == nil {
+		t.Fatal("Decrypt accepted an unknown kdf")
+	}
+}
+
+func TestRecommendScryptParams(t *testing.T) {
+	if testing.Short() {
+		t.Skip("times scrypt derivations")
+	}
+	const target = 50 * time.Millisecond
+	params, err := RecommendScryptParams(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := params.validate(); err != nil {
+		t.Fatalf("recommended %+v: %v", params, err)
+	}
+	// the fastest of a few runs filters out scheduling noise
+	best := time.Duration(-1)
+	for i := 0; i < 3; i++ {
+		d, err := BenchmarkKDF(params)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if best < 0 || d < best {
+			best = d
+		}
+	}
+	// N only moves in powers of two, and CI machines are noisy
+	switch {
+	case params.N == minScryptN && best > target:
+	case params.N == maxScryptN && best < target:
+	case best < target/4 || best > target*4:
+		t.Fatalf("N=%v takes %v, want roughly %v", params.N, best, target)
+	}
+}
+
+func TestBenchmarkKDFRejects(t *testing.T) {
+	if _, err := BenchmarkKDF(ScryptParams{N: 3000, R: 8, P: 1}); err == nil {
+		t.Fatal("BenchmarkKDF accepted invalid parameters")
+	}
+	for _, target := range []time.Duration{0, -time.Second} {
+		if _, err := RecommendScryptParams(target); err == nil {
+			t.Fatalf("RecommendScryptParams(%v) succeeded", target)
+		}
+	}
+}
@@ -0,0 +1,250 @@
+package sdk
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"github.com/quantosnetwork/dev-0.1.0/common"
+	account2 "github.com/quantosnetwork/dev-0.1.0/core/account"
+	"math/big"
+	"time"
+)
+
+// keyScheme is the key material handling behind a KeyType. Private and
+// public keys are passed around in their raw byte forms.
+type keyScheme interface {
+	// newKey creates a fresh keypair. id is the keypair ID, which the
+	// native scheme derives its keys from.
+	newKey(id string) (priv, pub []byte, err error)
+	fromSeed(seed []byte) (priv, pub []byte, err error)
+	publicKey(priv []byte) ([]byte, error)
+	sign(priv, message []byte) ([]byte, error)
+	verify(pub, message, sig []byte) (bool, error)
+}
+
+var keySchemes = map[KeyType]keyScheme{
+	KeyTypeQuantos: quantosScheme{},
+	KeyTypeEd25519: ed25519Scheme{},
+	KeyTypeP256:    p256Scheme{},
+}
+
+func schemeFor(keyType KeyType) (keyScheme, error) {
+	scheme, ok := keySchemes[keyType]
+	if !ok {
+		return nil, fmt.Errorf("%w %v", ErrUnsupportedKey, keyType)
+	}
+	return scheme, nil
+}
+
+// publicKeyFor derives the public key of a raw private key.
+func publicKeyFor(keyType KeyType, raw []byte) ([]byte, error) {
+	scheme, err := schemeFor(keyType)
+	if err != nil {
+		return nil, err
+	}
+	return scheme.publicKey(raw)
+}
+
+func signMessage(keyType KeyType, raw, message []byte) ([]byte, error) {
+	scheme, err := schemeFor(keyType)
+	if err != nil {
+		return nil, err
+	}
+	return scheme.sign(raw, message)
+}
+
+// quantosScheme wraps core/account, which only exposes key generation, so
+// keys of this type cannot be re-derived or used for signing here.
+type quantosScheme struct{}
+
+func (quantosScheme) newKey(id string) ([]byte, []byte, error) {
+	priv, pub := account2.NewKeyPair(id)
+	privb, err := priv.MarshalBinary()
+	if err != nil {
+		return nil, nil, err
+	}
+	pubb, err := pub.MarshalBinary()
+	if err != nil {
+		wipeBytes(privb)
+		return nil, nil, err
+	}
+	return privb, pubb, nil
+}
+
+func (s quantosScheme) fromSeed(seed []byte) ([]byte, []byte, error) {
+	return s.newKey(hex.EncodeToString(seed))
+}
+
+func (quantosScheme) publicKey(priv []byte) ([]byte, error) {
+	return nil, fmt.Errorf("%w %v: cannot derive public key", ErrUnsupportedKey, KeyTypeQuantos)
+}
+
+func (quantosScheme) sign(priv, message []byte) ([]byte, error) {
+	return nil, fmt.Errorf("%w %v: signing not available", ErrUnsupportedKey, KeyTypeQuantos)
+}
+
+func (quantosScheme) verify(pub, message, sig []byte) (bool, error) {
+	return false, fmt.Errorf("%w %v: verification not available", ErrUnsupportedKey, KeyTypeQuantos)
+}
+
+// ed25519Scheme stores the 32 byte seed as the raw key; the 64 byte
+// expanded form is accepted too.
+type ed25519Scheme struct{}
+
+func (ed25519Scheme) newKey(string) ([]byte, []byte, error) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	return priv.Seed(), pub, nil
+}
+
+func (ed25519Scheme) fromSeed(seed []byte) ([]byte, []byte, error) {
+	if len(seed) < ed25519.SeedSize {
+		return nil, nil, fmt.Errorf("ed25519 needs a seed of at least %v bytes", ed25519.SeedSize)
+	}
+	priv := ed25519.NewKeyFromSeed(seed[:ed25519.SeedSize])
+	return priv.Seed(), priv.Public().(ed25519.PublicKey), nil
+}
+
+func (ed25519Scheme) key(raw []byte) (ed25519.PrivateKey, error) {
+	switch len(raw) {
+	case ed25519.SeedSize:
+		return ed25519.NewKeyFromSeed(raw), nil
+	case ed25519.PrivateKeySize:
+		return ed25519.PrivateKey(raw), nil
+	}
+	return nil, fmt.Errorf("%w: ed25519 private key of %v bytes", ErrInvalidKeyPair, len(raw))
+}
+
+func (s ed25519Scheme) publicKey(raw []byte) ([]byte, error) {
+	priv, err := s.key(raw)
+	if err != nil {
+		return nil, err
+	}
+	return priv.Public().(ed25519.PublicKey), nil
+}
+
+func (s ed25519Scheme) sign(raw, message []byte) ([]byte, error) {
+	priv, err := s.key(raw)
+	if err != nil {
+		return nil, err
+	}
+	return ed25519.Sign(priv, message), nil
+}
+
+func (ed25519Scheme) verify(pub, message, sig []byte) (bool, error) {
+	if len(pub) != ed25519.PublicKeySize {
+		return false, fmt.Errorf("%w: ed25519 public key of %v bytes", ErrInvalidKeyPair, len(pub))
+	}
+	return ed25519.Verify(pub, message, sig), nil
+}
+
+// p256Scheme is ECDSA over NIST P-256. The raw key is the 32 byte scalar,
+// the public key the compressed point, and messages are SHA-256 hashed
+// before signing.
+type p256Scheme struct{}
+
+func (p256Scheme) newKey(string) ([]byte, []byte, error) {
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	return priv.D.FillBytes(make([]byte, 32)), elliptic.MarshalCompressed(priv.Curve, priv.X, priv.Y), nil
+}
+
+// fromSeed maps the seed hash onto [1, n-1].
+func (s p256Scheme) fromSeed(seed []byte) ([]byte, []byte, error) {
+	h := sha256.Sum256(seed)
+	n1 := new(big.Int).Sub(elliptic.P256().Params().N, big.NewInt(1))
+	d := new(big.Int).Mod(new(big.Int).SetBytes(h[:]), n1)
+	d.Add(d, big.NewInt(1))
+	priv := d.FillBytes(make([]byte, 32))
+	pub, err := s.publicKey(priv)
+	if err != nil {
+		return nil, nil, err
+	}
+	return priv, pub, nil
+}
+
+func (p256Scheme) key(raw []byte) (*ecdsa.PrivateKey, error) {
+	curve := elliptic.P256()
+	d := new(big.Int).SetBytes(raw)
+	if len(raw) != 32 || d.Sign() == 0 || d.Cmp(curve.Params().N) >= 0 {
+		return nil, fmt.Errorf("%w: invalid p256 private key", ErrInvalidKeyPair)
+	}
+	priv := &ecdsa.PrivateKey{D: d}
+	priv.Curve = curve
+	priv.X, priv.Y = curve.ScalarBaseMult(raw)
+	return priv, nil
+}
+
+func (s p256Scheme) publicKey(raw []byte) ([]byte, error) {
+	priv, err := s.key(raw)
+	if err != nil {
+		return nil, err
+	}
+	return elliptic.MarshalCompressed(priv.Curve, priv.X, priv.Y), nil
+}
+
+func (s p256Scheme) sign(raw, message []byte) ([]byte, error) {
+	priv, err := s.key(raw)
+	if err != nil {
+		return nil, err
+	}
+	digest := sha256.Sum256(message)
+	return ecdsa.SignASN1(rand.Reader, priv, digest[:])
+}
+
+func (p256Scheme) verify(pub, message, sig []byte) (bool, error) {
+	curve := elliptic.P256()
+	x, y := elliptic.UnmarshalCompressed(curve, pub)
+	if x == nil {
+		return false, fmt.Errorf("%w: invalid p256 public key", ErrInvalidKeyPair)
+	}
+	digest := sha256.Sum256(message)
+	return ecdsa.VerifyASN1(&ecdsa.PublicKey{Curve: curve, X: x, Y: y}, digest[:], sig), nil
+}
+
+// Sign signs message with the signer registered for perm or, failing
+// that, the keypair held under perm, which must already be decrypted. The
+// keypair's validity window and usage limit are enforced, and each
+// signature counts towards MaxUses once the account is saved.
+func (a *AccountInfo) Sign(perm string, message []byte) ([]byte, error) {
+	signer, err := a.Signer(perm)
+	if err != nil {
+		return nil, err
+	}
+	kp := a.Keypairs[perm]
+	if kp != nil {
+		if err := kp.checkPolicy(time.Now()); err != nil {
+			return nil, fmt.Errorf("%v: %w", perm, err)
+		}
+	}
+	sig, err := signer.Sign(message)
+	if err != nil {
+		return nil, fmt.Errorf("%v: %w", perm, err)
+	}
+	if kp != nil {
+		kp.Uses++
+	}
+	return sig, nil
+}
+
+// VerifySignature checks sig over message against a base58 public key. The
+// algorithm is told apart by key length: 32 bytes is ed25519, 33 is a
+// compressed P-256 point.
+func VerifySignature(pubKey string, message, sig []byte) (bool, error) {
+	pub := common.DecodeBase58(pubKey)
+	switch len(pub) {
+	case ed25519.PublicKeySize:
+		return ed25519Scheme{}.verify(pub, message, sig)
+	case 33:
+		return p256Scheme{}.verify(pub, message, sig)
+	}
+	return false, fmt.Errorf("%w: public key of %v bytes", ErrUnsupportedKey, len(pub))
+}
@@ -0,0 +1,81 @@
+package sdk
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestSignVerify(t *testing.T) {
+	message := []byte("transfer 10 QTS to bob")
+	for _, keyType := range []KeyType{KeyTypeEd25519, KeyTypeP256} {
+		t.Run(string(keyType), func(t *testing.T) {
+			a := NewAccountInfo()
+			a.Name = "alice"
+			kp := newTestKeyPair(t, keyType)
+			if err := a.AddKeyPair(PermActive, kp); err != nil {
+				t.Fatal(err)
+			}
+			sig, err := a.Sign(PermActive, message)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tampered := append([]byte{}, sig...)
+			tampered[len(tampered)-1] ^= 1
+			tests := []struct {
+				name    string
+				message []byte
+				sig     []byte
+				want    bool
+			}{
+				{"valid", message, sig, true},
+				{"tampered message", []byte("transfer 99 QTS to bob"), sig, false},
+				{"tampered signature", message, tampered, false},
+				{"empty signature", message, nil, false},
+			}
+			for _, tt := range tests {
+				ok, err := VerifySignature(kp.PubKey, tt.message, tt.sig)
+				if err != nil {
+					t.Fatalf("%v: %v", tt.name, err)
+				}
+				if ok != tt.want {
+					t.Fatalf("%v: VerifySignature() = %v, want %v", tt.name, ok, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestSignRejects(t *testing.T) {
+	a := NewAccountInfo()
+	a.Name = "alice"
+	encrypted := newTestKeyPair(t, KeyTypeEd25519)
+	if err := encrypted.EncryptWithParams([]byte("pw"), fastScrypt); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.AddKeyPair(PermOwner, encrypted); err != nil {
+		t.Fatal(err)
+	}
+	watchOnly, err := NewWatchOnlyKeyPair(newTestKeyPair(t, KeyTypeEd25519).PubKey, "ed25519")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := a.AddKeyPair(PermActive, watchOnly); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		perm    string
+		wantErr error
+	}{
+		{PermOwner, ErrStillEncrypted},
+		{PermActive, ErrWatchOnly},
+		{"missing", ErrInvalidPermission},
+	}
+	for _, tt := range tests {
+		if _, err := a.Sign(tt.perm, []byte("msg")); !errors.Is(err, tt.wantErr) {
+			t.Fatalf("Sign(%v) = %v, want %v", tt.perm, err, tt.wantErr)
+		}
+	}
+	if _, err := VerifySignature("abcd", []byte("msg"), []byte("sig")); !errors.Is(err, ErrUnsupportedKey) {
+		t.Fatalf("VerifySignature() with a short key = %v, want %v", err, ErrUnsupportedKey)
+	}
+}
@@ -0,0 +1,235 @@
+package sdk
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/quantosnetwork/dev-0.1.0/common"
+	"golang.org/x/crypto/scrypt"
+	"golang.org/x/crypto/sha3"
+	"lukechampine.com/frand"
+)
+
+// keystoreV3 is the Web3 Secret Storage (version 3) document used by
+// go-ethereum and friends. PublicKey and KeyType are our own additions so a
+// round-trip keeps the keypair intact; other tools ignore them.
+type keystoreV3 struct {
+	Crypto    keystoreV3Crypto `json:"crypto"`
+	ID        string           `json:"id"`
+	Version   int              `json:"version"`
+	PublicKey string           `json:"publickey,omitempty"`
+	KeyType   string           `json:"keytype,omitempty"`
+}
+
+type keystoreV3Crypto struct {
+	Cipher       string `json:"cipher"`
+	CipherParams struct {
+		IV string `json:"iv"`
+	} `json:"cipherparams"`
+	CipherText string `json:"ciphertext"`
+	KDF        string `json:"kdf"`
+	KDFParams  struct {
+		DKLen int    `json:"dklen"`
+		N     int    `json:"n"`
+		R     int    `json:"r"`
+		P     int    `json:"p"`
+		Salt  string `json:"salt"`
+	} `json:"kdfparams"`
+	MAC string `json:"mac"`
+}
+
+// ExportV3 writes the keypair as a v3 keystore protected by password. An
+// encrypted keypair is unlocked with the same password first; the keypair
+// itself is left untouched either way. An aes-gcm keypair fails with
+// ErrAADRequired; export it with AccountInfo.ExportV3.
+func (k *KeyPairInfo) ExportV3(password []byte) ([]byte, error) {
+	return k.exportV3(password, nil)
+}
+
+// ExportV3 is KeyPairInfo.ExportV3 for the keypair of perm, unlocking it
+// with the associated data it was sealed over.
+func (a *AccountInfo) ExportV3(perm string, password []byte) ([]byte, error) {
+	kp, ok := a.Keypairs[perm]
+	if !ok {
+		return nil, fmt.Errorf("%w %v", ErrInvalidPermission, perm)
+	}
+	return kp.exportV3(password, a.keyPairAAD(perm, kp))
+}
+
+func (k *KeyPairInfo) exportV3(password, aad []byte) ([]byte, error) {
+	plain := *k
+	if plain.IsEncrypted() {
+		if err := plain.DecryptWithAAD(password, aad); err != nil {
+			return nil, err
+		}
+		// only a decrypted copy owns its raw key; otherwise it is k's
+		defer plain.Wipe()
+	}
+	priv := plain.RawKey.decode()
+	defer wipeBytes(priv)
+	if len(priv) == 0 {
+		return nil, ErrEmptyKey
+	}
+	params := DefaultScryptParams
+	if k.ScryptParams != nil {
+		params = *k.ScryptParams
+	}
+	salt := make([]byte, 32)
+	frand.Read(salt)
+	iv := make([]byte, aes.BlockSize)
+	frand.Read(iv)
+	key, err := scrypt.Key(password, salt, params.N, params.R, params.P, 32)
+	if err != nil {
+		return nil, err
+	}
+	defer wipeBytes(key)
+	aesBlock, err := aes.NewCipher(key[0:16])
+	if err != nil {
+		return nil, err
+	}
+	cipherText := make([]byte, len(priv))
+	cipher.NewCTR(aesBlock, iv).XORKeyStream(cipherText, priv)
+
+	id, err := uuid.Parse(k.ID)
+	if err != nil {
+		id = uuid.New()
+	}
+	v3 := keystoreV3{ID: id.String(), Version: 3, PublicKey: k.PubKey, KeyType: string(k.KeyType)}
+	v3.Crypto.Cipher = "aes-128-ctr"
+	v3.Crypto.CipherParams.IV = hex.EncodeToString(iv)
+	v3.Crypto.CipherText = hex.EncodeToString(cipherText)
+	v3.Crypto.KDF = KDFScrypt
+	v3.Crypto.KDFParams.DKLen = 32
+	v3.Crypto.KDFParams.N = params.N
+	v3.Crypto.KDFParams.R = params.R
+	v3.Crypto.KDFParams.P = params.P
+	v3.Crypto.KDFParams.Salt = hex.EncodeToString(salt)
+	v3.Crypto.MAC = hex.EncodeToString(keccakMAC(key[16:32], cipherText))
+	return json.MarshalIndent(v3, "", "  ")
+}
+
+// ImportV3 reads a v3 keystore written by ExportV3 and returns the
+// decrypted keypair. Only the scrypt KDF and the aes-128-ctr cipher are
+// understood. Keystores from other tools do not record a key type; read
+// them with ImportV3As.
+func ImportV3(data []byte, password []byte) (*KeyPairInfo, error) {
+	return ImportV3As(data, password, "")
+}
+
+// ImportV3As is ImportV3 for a keystore whose key is of keyType. A key type
+// recorded in the keystore has to agree with it.
+func ImportV3As(data []byte, password []byte, keyType string) (*KeyPairInfo, error) {
+	var v3 keystoreV3
+	if err := json.Unmarshal(data, &v3); err != nil {
+		return nil, fmt.Errorf("invalid v3 keystore, %v", err)
+	}
+	if v3.Version != 3 {
+		return nil, fmt.Errorf("%w %v", ErrUnsupportedVersion, v3.Version)
+	}
+	if keyType == "" {
+		if v3.KeyType == "" {
+			return nil, fmt.Errorf("%w: v3 keystore does not record a key type", ErrUnsupportedKey)
+		}
+		keyType = v3.KeyType
+	}
+	kt, err := ParseKeyType(keyType)
+	if err != nil {
+		return nil, err
+	}
+	if v3.KeyType != "" {
+		recorded, err := ParseKeyType(v3.KeyType)
+		if err != nil {
+			return nil, err
+		}
+		if recorded != kt {
+			return nil, fmt.Errorf("%w: v3 keystore holds a %v key, not %v", ErrInvalidKeyPair, recorded, kt)
+		}
+	}
+	c := v3.Crypto
+	if c.KDF != KDFScrypt {
+		return nil, fmt.Errorf("unsupported kdf %v", c.KDF)
+	}
+	if c.Cipher != "aes-128-ctr" {
+		return nil, fmt.Errorf("unsupported cipher %v", c.Cipher)
+	}
+	if c.KDFParams.DKLen != 32 {
+		return nil, fmt.Errorf("unsupported dklen %v", c.KDFParams.DKLen)
+	}
+	params := ScryptParams{N: c.KDFParams.N, R: c.KDFParams.R, P: c.KDFParams.P}
+	if err := params.validate(); err != nil {
+		return nil, err
+	}
+	salt, err := hex.DecodeString(c.KDFParams.Salt)
+	if err != nil {
+		return nil, fmt.Errorf("invalid v3 salt, %v", err)
+	}
+	iv, err := hex.DecodeString(c.CipherParams.IV)
+	if err != nil || len(iv) != aes.BlockSize {
+		return nil, fmt.Errorf("invalid v3 iv")
+	}
+	cipherText, err := hex.DecodeString(c.CipherText)
+	if err != nil {
+		return nil, fmt.Errorf("invalid v3 ciphertext, %v", err)
+	}
+	mac, err := hex.DecodeString(c.MAC)
+	if err != nil {
+		return nil, fmt.Errorf("invalid v3 mac, %v", err)
+	}
+	key, err := scrypt.Key(password, salt, params.N, params.R, params.P, 32)
+	if err != nil {
+		return nil, err
+	}
+	defer wipeBytes(key)
+	if subtle.ConstantTimeCompare(keccakMAC(key[16:32], cipherText), mac) != 1 {
+		return nil, ErrWrongPassword
+	}
+	aesBlock, err := aes.NewCipher(key[0:16])
+	if err != nil {
+		return nil, err
+	}
+	priv := make([]byte, len(cipherText))
+	defer wipeBytes(priv)
+	cipher.NewCTR(aesBlock, iv).XORKeyStream(priv, cipherText)
+	pubKey := v3.PublicKey
+	pub, err := publicKeyFor(kt, priv)
+	switch {
+	case err == nil:
+		if pubKey != "" && !bytes.Equal(pub, common.DecodeBase58(pubKey)) {
+			return nil, ErrPublicKeyMismatch
+		}
+		pubKey = common.EncodeBase58(pub)
+	case !errors.Is(err, ErrUnsupportedKey) || pubKey == "":
+		// only the native scheme cannot derive, and then the keystore has
+		// to carry the public key
+		return nil, err
+	}
+	id := v3.ID
+	if id == "" {
+		id = newKeyPairID()
+	}
+	kp := &KeyPairInfo{
+		ID:      id,
+		RawKey:  NewSecretKey(common.EncodeBase58(priv)),
+		KeyType: kt,
+		PubKey:  pubKey,
+	}
+	if err := kp.Validate(); err != nil {
+		kp.Wipe()
+		return nil, err
+	}
+	return kp, nil
+}
+
+// keccakMAC is the v3 MAC: legacy Keccak-256 over the MAC key and ciphertext.
+func keccakMAC(macKey, cipherText []byte) []byte {
+	h := sha3.NewLegacyKeccak256()
+	h.Write(macKey)
+	h.Write(cipherText)
+	return h.Sum(nil)
+}
@@ -0,0 +1,151 @@
+package sdk
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"testing"
+)
+
+// testdata/v3_scrypt_vector.json is the scrypt test vector of the Web3
+// Secret Storage definition, also used by go-ethereum.
+const (
+	v3VectorPassword = "testpassword"
+	v3VectorKey      = "7a28b5ba57c53603b0b07b56bba752f7784bf506fa95edc395f5cf6c7514fe9d"
+)
+
+func TestImportV3Vector(t *testing.T) {
+	data, err := os.ReadFile("testdata/v3_scrypt_vector.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name     string
+		password string
+		keyType  string
+		wantErr  error
+	}{
+		{"ed25519", v3VectorPassword, "ed25519", nil},
+		{"p256", v3VectorPassword, "p256", nil},
+		{"wrong password", "wrong", "ed25519", ErrWrongPassword},
+		{"no key type", v3VectorPassword, "", ErrUnsupportedKey},
+		{"unknown key type", v3VectorPassword, "rsa", ErrUnsupportedKey},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kp, err := ImportV3As(data, []byte(tt.password), tt.keyType)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("ImportV3As() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			raw := kp.RawKey.decode()
+			if got := hex.EncodeToString(raw); got != v3VectorKey {
+				t.Fatalf("private key = %v, want %v", got, v3VectorKey)
+			}
+			if kp.ID != "3198bc9c-6672-5ab3-d995-4942343ae5b6" {
+				t.Fatalf("id = %v", kp.ID)
+			}
+			if string(kp.KeyType) != tt.keyType {
+				t.Fatalf("key type = %v, want %v", kp.KeyType, tt.keyType)
+			}
+			if err := kp.VerifyPublicKey(); err != nil {
+				t.Fatalf("public key not derived from the private key: %v", err)
+			}
+		})
+	}
+}
+
+func TestV3RoundTrip(t *testing.T) {
+	for _, kt := range []KeyType{KeyTypeEd25519, KeyTypeP256} {
+		t.Run(string(kt), func(t *testing.T) {
+			kp := newTestKeyPair(t, kt)
+			want := kp.RawKey.Reveal()
+			if err := kp.EncryptWithParams([]byte("pw"), fastScrypt); err != nil {
+				t.Fatal(err)
+			}
+			data, err := kp.ExportV3([]byte("pw"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !kp.IsEncrypted() {
+				t.Fatal("ExportV3 decrypted the keypair")
+			}
+			got, err := ImportV3(data, []byte("pw"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.RawKey.Reveal() != want || got.PubKey != kp.PubKey || got.KeyType != kt || got.ID != kp.ID {
+				t.Fatalf("round trip changed the keypair: %+v", got)
+			}
+		})
+	}
+}
+
+func TestImportV3Rejects(t *testing.T) {
+	kp := newTestKeyPair(t, KeyTypeEd25519)
+	data, err := kp.ExportV3([]byte("pw"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	other := newTestKeyPair(t, KeyTypeEd25519)
+	tests := []struct {
+		name    string
+		edit    func(*keystoreV3)
+		keyType string
+		wantErr error
+	}{
+		{"key type disagrees", nil, "p256", ErrInvalidKeyPair},
+		{"unknown recorded key type", func(v *keystoreV3) { v.KeyType = "rsa" }, "ed25519", ErrUnsupportedKey},
+		{"foreign public key", func(v *keystoreV3) { v.PublicKey = other.PubKey }, "", ErrPublicKeyMismatch},
+		{"tampered mac", func(v *keystoreV3) { v.Crypto.MAC = hex.EncodeToString(make([]byte, 32)) }, "", ErrWrongPassword},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var v3 keystoreV3
+			if err := json.Unmarshal(data, &v3); err != nil {
+				t.Fatal(err)
+			}
+			if tt.edit != nil {
+				tt.edit(&v3)
+			}
+			edited, err := json.Marshal(v3)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := ImportV3As(edited, []byte("pw"), tt.keyType); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ImportV3As() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestImportV3KeyTypeCase(t *testing.T) {
+	kp := newTestKeyPair(t, KeyTypeEd25519)
+	data, err := kp.ExportV3([]byte("pw"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var v3 keystoreV3
+	if err := json.Unmarshal(data, &v3); err != nil {
+		t.Fatal(err)
+	}
+	v3.KeyType = "ED25519"
+	if data, err = json.Marshal(v3); err != nil {
+		t.Fatal(err)
+	}
+	for _, keyType := range []string{"", "ed25519", "Ed25519"} {
+		got, err := ImportV3As(data, []byte("pw"), keyType)
+		if err != nil {
+			t.Fatalf("ImportV3As(%q): %v", keyType, err)
+		}
+		if got.KeyType != KeyTypeEd25519 || got.RawKey.Reveal() != kp.RawKey.Reveal() {
+			t.Fatalf("ImportV3As(%q) = %+v", keyType, got)
+		}
+	}
+}
@@ -0,0 +1,29 @@
+package sdk
+
+import (
+	"fmt"
+	"strings"
+)
+
+// KeyType names the signature algorithm of a keypair. It is stored in
+// keystores as its plain string form.
+type KeyType string
+
+const (
+	// KeyTypeQuantos is the network's native scheme from core/account.
+	KeyTypeQuantos KeyType = "quantos"
+	KeyTypeEd25519 KeyType = "ed25519"
+	KeyTypeP256    KeyType = "p256"
+)
+
+var knownKeyTypes = []KeyType{KeyTypeQuantos, KeyTypeEd25519, KeyTypeP256}
+
+func ParseKeyType(s string) (KeyType, error) {
+	kt := KeyType(strings.ToLower(strings.TrimSpace(s)))
+	for _, known := range knownKeyTypes {
+		if kt == known {
+			return kt, nil
+		}
+	}
+	return "", fmt.Errorf("%w %q", ErrUnsupportedKey, s)
+}
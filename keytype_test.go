@@ -0,0 +1,85 @@
+package sdk
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestParseKeyType(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    KeyType
+		wantErr bool
+	}{
+		{"quantos", KeyTypeQuantos, false},
+		{"ed25519", KeyTypeEd25519, false},
+		{"p256", KeyTypeP256, false},
+		{" ED25519 ", KeyTypeEd25519, false},
+		{"", "", true},
+		{"ed2519", "", true},
+		{"secp256k1", "", true},
+	}
+	for _, tt := range tests {
+		got, err := ParseKeyType(tt.in)
+		if tt.wantErr {
+			if !errors.Is(err, ErrUnsupportedKey) {
+				t.Fatalf("ParseKeyType(%q) error = %v, want %v", tt.in, err, ErrUnsupportedKey)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Fatalf("ParseKeyType(%q) = %v, %v, want %v", tt.in, got, err, tt.want)
+		}
+	}
+	if _, err := NewKeyPairInfo("abc", "ed2519"); !errors.Is(err, ErrUnsupportedKey) {
+		t.Fatalf("NewKeyPairInfo with a misspelt key type = %v, want %v", err, ErrUnsupportedKey)
+	}
+}
+
+func TestKeyTypeJSON(t *testing.T) {
+	data, err := json.Marshal(KeyPairInfo{KeyType: KeyTypeP256})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc map[string]interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc["key_type"] != "p256" {
+		t.Fatalf("key_type is stored as %v, want the plain string", doc["key_type"])
+	}
+}
+
+func TestLoadNormalizesKeyType(t *testing.T) {
+	for _, spelling := range []string{"ED25519", "Ed25519", " ed25519"} {
+		t.Run(spelling, func(t *testing.T) {
+			a := NewAccountInfo()
+			a.Name = "alice"
+			if err := a.AddKeyPair(PermOwner, newTestKeyPair(t, KeyTypeEd25519)); err != nil {
+				t.Fatal(err)
+			}
+			doc := encodeAccount(t, a)
+			doc["keypairs"].(map[string]interface{})[PermOwner].(map[string]interface{})["key_type"] = spelling
+			data, err := json.Marshal(doc)
+			if err != nil {
+				t.Fatal(err)
+			}
+			loaded, err := LoadAccount(bytes.NewReader(data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if kt := loaded.Keypairs[PermOwner].KeyType; kt != KeyTypeEd25519 {
+				t.Fatalf("key type %q, want %q", kt, KeyTypeEd25519)
+			}
+			sig, err := loaded.Sign(PermOwner, []byte("msg"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ok, err := VerifySignature(loaded.Keypairs[PermOwner].PubKey, []byte("msg"), sig); !ok || err != nil {
+				t.Fatalf("VerifySignature() = %v, %v", ok, err)
+			}
+		})
+	}
+}
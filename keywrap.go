@@ -0,0 +1,137 @@
+package sdk
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"fmt"
+	"github.com/quantosnetwork/dev-0.1.0/common"
+	"lukechampine.com/frand"
+)
+
+// KDFKeyWrapper marks keypairs encrypted with EncryptWithKeyWrapper.
+const KDFKeyWrapper = "wrapped"
+
+// KeyWrapper protects the symmetric key a keypair is encrypted with, e.g.
+// by encrypting it to a PIV smartcard's key management slot so only the
+// card can unwrap it. ID names the wrapping key and is stored in the
+// keystore to pick the right wrapper on decryption.
+type KeyWrapper interface {
+	ID() string
+	WrapKey(key []byte) ([]byte, error)
+	UnwrapKey(wrapped []byte) ([]byte, error)
+}
+
+// EncryptWithKeyWrapper encrypts with a fresh random AES-256 key, which is
+// stored wrapped by w next to w.ID(). Decrypting needs the same wrapper
+// and no password.
+func (k *KeyPairInfo) EncryptWithKeyWrapper(w KeyWrapper) error {
+	if k.IsEncrypted() {
+		return ErrAlreadyEncrypted
+	}
+	if k.IsWatchOnly() {
+		return ErrWatchOnly
+	}
+	if w.ID() == "" {
+		return fmt.Errorf("key wrapper has no id")
+	}
+	key := make([]byte, 32)
+	frand.Read(key)
+	defer wipeBytes(key)
+	wrapped, err := w.WrapKey(key)
+	if err != nil {
+		return fmt.Errorf("wrap key with %v: %w", w.ID(), err)
+	}
+	if err := k.EncryptWithKey(key); err != nil {
+		return err
+	}
+	k.KDF = KDFKeyWrapper
+	k.Wrapper = w.ID()
+	k.WrappedKey = common.EncodeBase58(wrapped)
+	return nil
+}
+
+// DecryptWithKeyWrapper reverses EncryptWithKeyWrapper. w must have the
+// ID recorded in the keypair.
+func (k *KeyPairInfo) DecryptWithKeyWrapper(w KeyWrapper) error {
+	if !k.IsEncrypted() {
+		return ErrNotEncrypted
+	}
+	if k.KDF != KDFKeyWrapper {
+		return fmt.Errorf("keypair is not protected by a key wrapper")
+	}
+	if w.ID() != k.Wrapper {
+		return fmt.Errorf("keypair is wrapped by %v, not %v", k.Wrapper, w.ID())
+	}
+	if err := k.checkSizes(); err != nil {
+		return err
+	}
+	wrapped, err := decodeSealed("wrapped_key", k.WrappedKey)
+	if err != nil {
+		return err
+	}
+	key, err := w.UnwrapKey(wrapped)
+	if err != nil {
+		return fmt.Errorf("unwrap key with %v: %w", w.ID(), err)
+	}
+	defer wipeBytes(key)
+	if len(key) != k.KeySize {
+		return fmt.Errorf("%w: unwrapped key is %v bytes, want %v", ErrCorruptKeystore, len(key), k.KeySize)
+	}
+	salt, err := k.decodeSalt()
+	if err != nil {
+		return err
+	}
+	derived, err := expandKey(key, salt[0:32], 2*len(key))
+	if err != nil {
+		return err
+	}
+	defer wipeBytes(derived)
+	return k.open(derived, len(key), salt, nil)
+}
+
+// SoftwareKeyWrapper is a reference KeyWrapper that wraps with AES-256-GCM
+// under a key held in memory. It is meant for tests and as a model for
+// hardware wrappers; it protects nothing a password would not.
+type SoftwareKeyWrapper struct {
+	id   string
+	aead cipher.AEAD
+}
+
+func NewSoftwareKeyWrapper(id string, kek []byte) (*SoftwareKeyWrapper, error) {
+	if len(kek) != 32 {
+		return nil, fmt.Errorf("%w %v", ErrInvalidKeySize, len(kek))
+	}
+	block, err := aes.NewCipher(kek)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &SoftwareKeyWrapper{id: id, aead: aead}, nil
+}
+
+func (w *SoftwareKeyWrapper) ID() string {
+	return w.id
+}
+
+// WrapKey returns a random nonce followed by the sealed key, with the ID as
+// associated data.
+func (w *SoftwareKeyWrapper) WrapKey(key []byte) ([]byte, error) {
+	nonce := make([]byte, w.aead.NonceSize())
+	frand.Read(nonce)
+	return w.aead.Seal(nonce, nonce, key, []byte(w.id)), nil
+}
+
+func (w *SoftwareKeyWrapper) UnwrapKey(wrapped []byte) ([]byte, error) {
+	n := w.aead.NonceSize()
+	if len(wrapped) < n+w.aead.Overhead() {
+		return nil, fmt.Errorf("%w: wrapped key too short", ErrCorruptKeystore)
+	}
+	key, err := w.aead.Open(nil, wrapped[:n], wrapped[n:], []byte(w.id))
+	if err != nil {
+		return nil, ErrWrongPassword
+	}
+	return key, nil
+}
@@ -0,0 +1,159 @@
+package sdk
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// plainWrapper "wraps" by copying the key, with unwrap free to alter it.
+type plainWrapper struct {
+	id     string
+	unwrap func(wrapped []byte) ([]byte, error)
+}
+
+func (w plainWrapper) ID() string { return w.id }
+
+func (w plainWrapper) WrapKey(key []byte) ([]byte, error) {
+	return append([]byte(nil), key...), nil
+}
+
+func (w plainWrapper) UnwrapKey(wrapped []byte) ([]byte, error) {
+	return w.unwrap(wrapped)
+}
+
+func newSoftwareKeyWrapper(t *testing.T, id string, seed byte) *SoftwareKeyWrapper {
+	t.Helper()
+	kek := make([]byte, 32)
+	for i := range kek {
+		kek[i] = seed
+	}
+	w, err := NewSoftwareKeyWrapper(id, kek)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return w
+}
+
+func TestKeyWrapper(t *testing.T) {
+	for _, keyType := range []KeyType{KeyTypeEd25519, KeyTypeP256} {
+		t.Run(string(keyType), func(t *testing.T) {
+			w := newSoftwareKeyWrapper(t, "piv-9d", 1)
+			s := NewFileAccountStore(t.TempDir())
+			a := NewAccountInfo()
+			a.Name = "alice"
+			kp := newTestKeyPair(t, keyType)
+			raw := kp.RawKey.Reveal()
+			if err := a.AddKeyPair(PermOwner, kp); err != nil {
+				t.Fatal(err)
+			}
+			if err := kp.EncryptWithKeyWrapper(w); err != nil {
+				t.Fatal(err)
+			}
+			if kp.KDF != KDFKeyWrapper || kp.Wrapper != "piv-9d" || kp.WrappedKey == "" || !kp.RawKey.IsEmpty() {
+				t.Fatalf("wrapped keypair = %+v", kp)
+			}
+			if err := s.SaveAccount(a); err != nil {
+				t.Fatal(err)
+			}
+			got, err := s.LoadAccount("alice")
+			if err != nil {
+				t.Fatal(err)
+			}
+			data, err := json.Marshal(got)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(string(data), `"wrapper":"piv-9d"`) || strings.Contains(string(data), raw) {
+				t.Fatalf("keystore = %s", data)
+			}
+			loaded := got.Keypairs[PermOwner]
+			if err := loaded.Decrypt([]byte("pw")); err == nil {
+				t.Fatal("decrypted a wrapped keypair with a password")
+			}
+			if err := loaded.DecryptWithKeyWrapper(w); err != nil {
+				t.Fatal(err)
+			}
+			if loaded.RawKey.Reveal() != raw {
+				t.Fatal("unwrapped another key")
+			}
+			if err := loaded.VerifyPublicKey(); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestKeyWrapperRejects(t *testing.T) {
+	w := newSoftwareKeyWrapper(t, "piv-9d", 1)
+	wrapped := func(t *testing.T) *KeyPairInfo {
+		kp := newTestKeyPair(t, KeyTypeEd25519)
+		if err := kp.EncryptWithKeyWrapper(w); err != nil {
+			t.Fatal(err)
+		}
+		return kp
+	}
+	errUnwrap := errors.New("card removed")
+	tests := []struct {
+		name    string
+		run     func(t *testing.T) error
+		wantErr error
+	}{
+		{"already encrypted", func(t *testing.T) error { return wrapped(t).EncryptWithKeyWrapper(w) }, ErrAlreadyEncrypted},
+		{"watch-only", func(t *testing.T) error {
+			kp, err := NewWatchOnlyKeyPair(newTestKeyPair(t, KeyTypeEd25519).PubKey, "ed25519")
+			if err != nil {
+				t.Fatal(err)
+			}
+			return kp.EncryptWithKeyWrapper(w)
+		}, ErrWatchOnly},
+		{"no wrapper id", func(t *testing.T) error {
+			return newTestKeyPair(t, KeyTypeEd25519).EncryptWithKeyWrapper(newSoftwareKeyWrapper(t, "", 1))
+		}, nil},
+		{"not encrypted", func(t *testing.T) error { return newTestKeyPair(t, KeyTypeEd25519).DecryptWithKeyWrapper(w) }, ErrNotEncrypted},
+		{"password encrypted", func(t *testing.T) error {
+			kp := newTestKeyPair(t, KeyTypeEd25519)
+			if err := kp.EncryptWithParams([]byte("pw"), fastScrypt); err != nil {
+				t.Fatal(err)
+			}
+			return kp.DecryptWithKeyWrapper(w)
+		}, nil},
+		{"other wrapper id", func(t *testing.T) error {
+			return wrapped(t).DecryptWithKeyWrapper(newSoftwareKeyWrapper(t, "piv-9a", 1))
+		}, nil},
+		{"other wrapping key", func(t *testing.T) error {
+			return wrapped(t).DecryptWithKeyWrapper(newSoftwareKeyWrapper(t, "piv-9d", 2))
+		}, ErrWrongPassword},
+		{"truncated wrapped key", func(t *testing.T) error {
+			kp := wrapped(t)
+			kp.WrappedKey = kp.WrappedKey[:4]
+			return kp.DecryptWithKeyWrapper(w)
+		}, ErrCorruptKeystore},
+		{"unwrap fails", func(t *testing.T) error {
+			kp := wrapped(t)
+			return kp.DecryptWithKeyWrapper(plainWrapper{"piv-9d", func([]byte) ([]byte, error) { return nil, errUnwrap }})
+		}, errUnwrap},
+		{"unwrapped key of another size", func(t *testing.T) error {
+			kp := newTestKeyPair(t, KeyTypeEd25519)
+			short := plainWrapper{"plain", func(b []byte) ([]byte, error) { return b[:16], nil }}
+			if err := kp.EncryptWithKeyWrapper(short); err != nil {
+				t.Fatal(err)
+			}
+			return kp.DecryptWithKeyWrapper(short)
+		}, ErrCorruptKeystore},
+		{"no wrapper id in keystore", func(t *testing.T) error {
+			kp := wrapped(t)
+			kp.Wrapper = ""
+			return kp.Validate()
+		}, ErrInvalidKeyPair},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.run(t)
+			if err == nil || tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
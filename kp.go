@@ -2,164 +2,916 @@ package sdk
 
 import (
 	"bytes"
+	"context"
 	"crypto/aes"
 	"crypto/cipher"
+	"crypto/subtle"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"github.com/google/uuid"
 	"github.com/quantosnetwork/dev-0.1.0/common"
 	account2 "github.com/quantosnetwork/dev-0.1.0/core/account"
-	"golang.org/x/crypto/scrypt"
+	"io"
+	"io/fs"
 	"lukechampine.com/frand"
 	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
 	"time"
 )
 
 type KeyPairInfo struct {
-	ID           string `json:"kp_id"`
-	RawKey       string `json:"raw_key,omitempty"`
-	KeyType      string `json:"key_type"`
-	PubKey       string `json:"public_key"`
-	Salt         string `json:"salt,omitempty"`
-	EncryptedKey string `json:"encrypted_key,omitempty"`
-	Mac          string `json:"mac,omitempty"`
+	ID           string        `json:"kp_id"`
+	RawKey       SecretKey     `json:"raw_key,omitempty"`
+	KeyType      KeyType       `json:"key_type"`
+	PubKey       string        `json:"public_key"`
+	Salt         string        `json:"salt,omitempty"`
+	EncryptedKey string        `json:"encrypted_key,omitempty"`
+	Mac          string        `json:"mac,omitempty"`
+	KeySize      int           `json:"key_size,omitempty"`
+	KDF          string        `json:"kdf,omitempty"`
+	ScryptParams *ScryptParams `json:"scrypt_params,omitempty"`
+	Argon2Params *Argon2Params `json:"argon2_params,omitempty"`
+	Path         string        `json:"path,omitempty"`
+	// Cipher is CipherAESGCM for keypairs sealed with AES-GCM; empty means
+	// AES-CTR with a separate SHA3 MAC.
+	Cipher string `json:"cipher,omitempty"`
+	// NotBefore and NotAfter are optional RFC3339 times bounding when
+	// AccountInfo.Sign may use the keypair. MaxUses, when positive, caps the
+	// signatures it makes; Uses counts them. See SetValidity.
+	NotBefore string `json:"not_before,omitempty"`
+	NotAfter  string `json:"not_after,omitempty"`
+	MaxUses   int    `json:"max_uses,omitempty"`
+	Uses      int    `json:"uses,omitempty"`
+	// Wrapper and WrappedKey are set for KDFKeyWrapper keypairs: the ID of
+	// the KeyWrapper and the encryption key as it wrapped it.
+	Wrapper    string `json:"wrapper,omitempty"`
+	WrappedKey string `json:"wrapped_key,omitempty"`
+	// Label is a free-form plaintext name such as "YubiKey 2023". Like the
+	// account's Note it is not covered by any MAC.
+	Label string `json:"label,omitempty"`
 }
 
+// EncryptOptions tunes how a keypair is encrypted. KeySize is the AES key
+// length in bytes: 16 for AES-128 (the default) or 32 for AES-256. KDF picks
+// the password hash, scrypt unless set to KDFArgon2id; zero parameters fall
+// back to the package defaults. A non-nil Policy rejects passwords that do
+// not satisfy it before any key derivation runs. Cipher selects
+// CipherAESGCM instead of AES-CTR, authenticating AAD along with the key;
+// AAD must then be set, and the same AAD passed to DecryptWithAAD.
+type EncryptOptions struct {
+	KeySize int
+	KDF     string
+	Scrypt  ScryptParams
+	Argon2  Argon2Params
+	Policy  *PasswordPolicy
+	Cipher  string
+	AAD     []byte
+}
+
+// NewKeyPairInfo wraps rawKey as a keypair of keyType, deriving PubKey from
+// it as ImportPrivateKey does. The native scheme cannot derive a public key,
+// so for KeyTypeQuantos a fresh public key is generated instead, and it
+// does not belong to rawKey.
 func NewKeyPairInfo(rawKey string, keyType string) (*KeyPairInfo, error) {
 	if rawKey == "" {
-		return nil, fmt.Errorf("empty key")
+		return nil, ErrEmptyKey
+	}
+	kt, err := ParseKeyType(keyType)
+	if err != nil {
+		return nil, err
+	}
+	kp, err := ImportPrivateKey(rawKey, string(kt))
+	if !errors.Is(err, ErrUnsupportedKey) {
+		return kp, err
+	}
+	scheme, err := schemeFor(kt)
+	if err != nil {
+		return nil, err
 	}
-	kp := &KeyPairInfo{}
-	kp.RawKey = rawKey
-	kp.KeyType = keyType
-	id, _ := uuid.NewUUID()
-	kp.ID = id.String()
-	_, pub := account2.NewKeyPair(id.String())
-	pubb, err := pub.MarshalBinary()
+	kp = &KeyPairInfo{}
+	kp.RawKey = NewSecretKey(rawKey)
+	kp.KeyType = kt
+	kp.ID = newKeyPairID()
+	priv, pub, err := scheme.newKey(kp.ID)
 	if err != nil {
 		return nil, err
 	}
-	kp.PubKey = common.EncodeBase58(pubb)
+	wipeBytes(priv)
+	kp.PubKey = common.EncodeBase58(pub)
 	return kp, nil
 }
 
+// ImportPrivateKey wraps an existing base58 private key, deriving PubKey
+// from it. Key types that cannot derive a public key, such as
+// KeyTypeQuantos, return ErrUnsupportedKey.
+func ImportPrivateKey(rawKey string, keyType string) (*KeyPairInfo, error) {
+	if rawKey == "" {
+		return nil, ErrEmptyKey
+	}
+	kt, err := ParseKeyType(keyType)
+	if err != nil {
+		return nil, err
+	}
+	raw := common.DecodeBase58(rawKey)
+	defer wipeBytes(raw)
+	if len(raw) == 0 {
+		return nil, fmt.Errorf("%w: private key is not valid base58", ErrInvalidKeyPair)
+	}
+	pub, err := publicKeyFor(kt, raw)
+	if err != nil {
+		return nil, err
+	}
+	return &KeyPairInfo{
+		ID:      newKeyPairID(),
+		RawKey:  NewSecretKey(rawKey),
+		KeyType: kt,
+		PubKey:  common.EncodeBase58(pub),
+	}, nil
+}
+
+// newKeyPairID returns a random (version 4) UUID. Older keystores carry
+// version 1 IDs, which are still accepted.
+func newKeyPairID() string {
+	return uuid.New().String()
+}
+
+// keyPairFromSeed deterministically derives a keypair from seed material,
+// as produced by a mnemonic or an HD wallet.
+func keyPairFromSeed(seed []byte, keyType KeyType) (*KeyPairInfo, error) {
+	scheme, err := schemeFor(keyType)
+	if err != nil {
+		return nil, err
+	}
+	priv, pub, err := scheme.fromSeed(seed)
+	if err != nil {
+		return nil, err
+	}
+	defer wipeBytes(priv)
+	return &KeyPairInfo{
+		ID:      newKeyPairID(),
+		RawKey:  NewSecretKey(common.EncodeBase58(priv)),
+		KeyType: keyType,
+		PubKey:  common.EncodeBase58(pub),
+	}, nil
+}
+
+// ToKeyPair loads the decrypted key into core/account and returns its
+// LoadedKeys, including PubKeySign. Only KeyTypeQuantos keys can be loaded;
+// other key types fail with ErrUnsupportedKey, and their keypairs sign
+// through Sign instead. The loaded public key must match PubKey.
 func (k *KeyPairInfo) ToKeyPair() (*account2.LoadedKeys, error) {
-	if k.RawKey == "" {
-		return nil, fmt.Errorf("empty keypair")
+	if k.IsWatchOnly() {
+		return nil, ErrWatchOnly
+	}
+	if k.IsEncrypted() {
+		return nil, ErrStillEncrypted
 	}
-	priv, pub := account2.NewKeyPair(k.ID)
-	lk := &account2.LoadedKeys{
-		Priv:       priv,
-		Pub:        pub,
-		PubKeySign: nil,
+	if k.KeyType != KeyTypeQuantos {
+		return nil, fmt.Errorf("%w %v: core/account only loads %v keys", ErrUnsupportedKey, k.KeyType, KeyTypeQuantos)
+	}
+	raw := k.RawKey.decode()
+	defer wipeBytes(raw)
+	if len(raw) == 0 {
+		return nil, fmt.Errorf("%w: raw_key is not valid base58", ErrInvalidKeyPair)
+	}
+	pub := common.DecodeBase58(k.PubKey)
+	if account2.NewAccountFromKeys(k.ID, hex.EncodeToString(raw), hex.EncodeToString(pub)) == nil {
+		return nil, fmt.Errorf("%w: core/account did not accept the stored keys", ErrInvalidKeyPair)
+	}
+	lk := account2.Keys{}.GetLoadedKeys(k.ID)
+	if lk == nil || lk.Pub == nil {
+		return nil, fmt.Errorf("%w: core/account did not load keypair %v", ErrInvalidKeyPair, k.ID)
+	}
+	loaded, err := lk.Pub.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(loaded, pub) {
+		return nil, ErrPublicKeyMismatch
 	}
 	return lk, nil
+}
 
+// DerivePublicKey recomputes the base58 public key from the decrypted
+// RawKey, for key types that allow it.
+func (k *KeyPairInfo) DerivePublicKey() (string, error) {
+	if k.IsWatchOnly() {
+		return "", ErrWatchOnly
+	}
+	if k.IsEncrypted() {
+		return "", ErrStillEncrypted
+	}
+	raw := k.RawKey.decode()
+	defer wipeBytes(raw)
+	pub, err := publicKeyFor(k.KeyType, raw)
+	if err != nil {
+		return "", err
+	}
+	return common.EncodeBase58(pub), nil
+}
+
+// VerifyPublicKey checks that PubKey belongs to the decrypted RawKey.
+func (k *KeyPairInfo) VerifyPublicKey() error {
+	if k.IsWatchOnly() {
+		return ErrWatchOnly
+	}
+	if k.IsEncrypted() {
+		return ErrStillEncrypted
+	}
+	raw := k.RawKey.decode()
+	defer wipeBytes(raw)
+	return k.verifyPublicKey(raw)
+}
+
+func (k *KeyPairInfo) verifyPublicKey(raw []byte) error {
+	pub, err := publicKeyFor(k.KeyType, raw)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(pub, common.DecodeBase58(k.PubKey)) {
+		return ErrPublicKeyMismatch
+	}
+	return nil
 }
 
 func (k *KeyPairInfo) IsEncrypted() bool {
-	return k.EncryptedKey != "" || k.RawKey == ""
+	return k.EncryptedKey != ""
+}
+
+// IsWatchOnly reports whether the keypair holds only a public key, e.g. to
+// monitor an address. It has nothing to encrypt, and signing with it fails
+// with ErrWatchOnly.
+func (k *KeyPairInfo) IsWatchOnly() bool {
+	return k.EncryptedKey == "" && k.RawKey.IsEmpty()
 }
 
+// Encrypt accepts any password, including an empty one. Use
+// EncryptWithOptions with a PasswordPolicy to refuse weak passwords.
 func (k *KeyPairInfo) Encrypt(password []byte) error {
+	return k.EncryptWithOptions(password, EncryptOptions{KeySize: 16})
+}
+
+func (k *KeyPairInfo) EncryptWithParams(password []byte, params ScryptParams) error {
+	return k.EncryptWithOptions(password, EncryptOptions{KeySize: 16, Scrypt: params})
+}
+
+func (k *KeyPairInfo) EncryptWithOptions(password []byte, opts EncryptOptions) error {
 	if k.IsEncrypted() {
-		return errors.New("already encrypted")
+		return ErrAlreadyEncrypted
 	}
-	salt := make([]byte, 48)
-	frand.Read(salt[0:32])
-	key, err := scrypt.Key(password, salt[0:32], 32768, 8, 1, 32)
+	if k.IsWatchOnly() {
+		return ErrWatchOnly
+	}
+	keySize := opts.KeySize
+	if keySize == 0 {
+		keySize = 16
+	}
+	if keySize != 16 && keySize != 32 {
+		return fmt.Errorf("%w %v", ErrInvalidKeySize, keySize)
+	}
+	if opts.Cipher != "" && opts.Cipher != CipherAESGCM {
+		return fmt.Errorf("unsupported cipher %v", opts.Cipher)
+	}
+	if opts.Cipher == CipherAESGCM && len(opts.AAD) == 0 {
+		return ErrAADRequired
+	}
+	if opts.Policy != nil {
+		if err := ValidatePassword(password, *opts.Policy); err != nil {
+			return err
+		}
+	}
+	hdr := KeyPairInfo{KDF: opts.KDF}
+	switch hdr.KDF {
+	case "", KDFScrypt:
+		params := opts.Scrypt
+		if params == (ScryptParams{}) {
+			params = DefaultScryptParams
+		}
+		hdr.KDF, hdr.ScryptParams = KDFScrypt, &params
+	case KDFArgon2id:
+		params := opts.Argon2
+		if params == (Argon2Params{}) {
+			params = DefaultArgon2Params
+		}
+		hdr.Argon2Params = &params
+	default:
+		return fmt.Errorf("unsupported kdf %v", opts.KDF)
+	}
+	// salt[0:32] feeds scrypt, salt[32:48] is the CTR IV
+	salt := make([]byte, saltSize)
+	frand.Read(salt)
+	// the derived key is the AES key followed by a MAC key of the same length
+	key, err := hdr.deriveKey(password, salt[0:32], 2*keySize)
+	if err != nil {
+		return err
+	}
+	defer wipeBytes(key)
+	if err := k.seal(key, keySize, salt, opts.Cipher, opts.AAD); err != nil {
+		return err
+	}
+	k.KDF = hdr.KDF
+	k.ScryptParams = hdr.ScryptParams
+	k.Argon2Params = hdr.Argon2Params
+	return nil
+}
+
+// EncryptWithKey encrypts with a 16 or 32 byte key, e.g. one held by a KMS,
+// instead of a password. No KDF runs; the AES and MAC keys are expanded
+// from key with HKDF and KDF is recorded as KDFNone.
+func (k *KeyPairInfo) EncryptWithKey(key []byte) error {
+	if k.IsEncrypted() {
+		return ErrAlreadyEncrypted
+	}
+	if k.IsWatchOnly() {
+		return ErrWatchOnly
+	}
+	if len(key) != 16 && len(key) != 32 {
+		return fmt.Errorf("%w %v", ErrInvalidKeySize, len(key))
+	}
+	salt := make([]byte, saltSize)
+	frand.Read(salt)
+	derived, err := expandKey(key, salt[0:32], 2*len(key))
 	if err != nil {
 		return err
 	}
-	aesBlock, err := aes.NewCipher(key[0:16])
+	defer wipeBytes(derived)
+	if err := k.seal(derived, len(key), salt, "", nil); err != nil {
+		return err
+	}
+	k.KDF = KDFNone
+	k.ScryptParams = nil
+	k.Argon2Params = nil
+	return nil
+}
+
+// seal encrypts the raw key with the AES and MAC keys in key, or with
+// AES-GCM over aad when cipherName is CipherAESGCM.
+func (k *KeyPairInfo) seal(key []byte, keySize int, salt []byte, cipherName string, aad []byte) error {
+	aesBlock, err := aes.NewCipher(key[0:keySize])
 	if err != nil {
 		return err
 	}
-	stream := cipher.NewCTR(aesBlock, salt[32:48])
-	inText := common.DecodeBase58(k.RawKey)
-	outText := make([]byte, len(inText))
-	stream.XORKeyStream(outText, inText)
-	mac := common.Sha3(append(key[16:32], outText...))
+	if k.RawKey.IsEmpty() {
+		return ErrEmptyKey
+	}
+	inText := k.RawKey.decode()
+	defer wipeBytes(inText)
+	if len(inText) == 0 {
+		return fmt.Errorf("%w: raw_key is not valid base58", ErrCorruptKeystore)
+	}
+	var outText, mac []byte
+	if cipherName == CipherAESGCM {
+		outText, mac, err = gcmSealKey(aesBlock, salt, inText, aad)
+		if err != nil {
+			return err
+		}
+	} else {
+		stream := cipher.NewCTR(aesBlock, salt[32:48])
+		outText = make([]byte, len(inText))
+		stream.XORKeyStream(outText, inText)
+		macInput := append(key[keySize:2*keySize], outText...)
+		defer wipeBytes(macInput)
+		mac = common.Sha3(macInput)
+	}
+	k.EncryptedKey = common.EncodeBase58(outText)
 	k.Salt = common.EncodeBase58(salt)
 	k.Mac = common.EncodeBase58(mac)
-	k.RawKey = ""
+	k.Cipher = cipherName
+	k.KeySize = keySize
+	k.RawKey = SecretKey{}
 	return nil
 }
 
+// encryptOptions reports the options an encrypted keypair was sealed with.
+func (k *KeyPairInfo) encryptOptions() EncryptOptions {
+	opts := EncryptOptions{KeySize: k.KeySize, KDF: k.KDF, Cipher: k.Cipher}
+	if k.ScryptParams != nil {
+		opts.Scrypt = *k.ScryptParams
+	}
+	if k.Argon2Params != nil {
+		opts.Argon2 = *k.Argon2Params
+	}
+	return opts
+}
+
 func (k *KeyPairInfo) Decrypt(password []byte) error {
+	return k.DecryptWithAAD(password, nil)
+}
+
+// DecryptWithAAD decrypts a keypair sealed with CipherAESGCM, failing
+// unless aad is what it was encrypted with. AES-CTR keypairs ignore aad.
+// Without aad an aes-gcm keypair fails with ErrAADRequired; keypairs of an
+// account are better decrypted through the account, which supplies it.
+func (k *KeyPairInfo) DecryptWithAAD(password, aad []byte) error {
 	if !k.IsEncrypted() {
-		return fmt.Errorf("not encrypted")
+		return ErrNotEncrypted
 	}
-	salt := common.DecodeBase58(k.Salt)
-	key, err := scrypt.Key(password, salt[0:32], 32768, 8, 1, 32)
+	if k.Cipher == CipherAESGCM && len(aad) == 0 {
+		return ErrAADRequired
+	}
+	if err := k.checkSizes(); err != nil {
+		return err
+	}
+	// keystores written before KeySize existed are AES-128
+	keySize := k.KeySize
+	if keySize == 0 {
+		keySize = 16
+	}
+	if keySize != 16 && keySize != 32 {
+		return fmt.Errorf("%w %v", ErrInvalidKeySize, keySize)
+	}
+	salt, err := k.decodeSalt()
 	if err != nil {
 		return err
 	}
-	aesBlock, err := aes.NewCipher(key[0:16])
+	key, err := k.deriveKey(password, salt[0:32], 2*keySize)
 	if err != nil {
 		return err
 	}
-	stream := cipher.NewCTR(aesBlock, salt[32:48])
-	inText := common.DecodeBase58(k.EncryptedKey)
-	outText := make([]byte, len(inText))
-	stream.XORKeyStream(outText, inText)
-	mac := common.Sha3(append(key[16:32], inText...))
-	if !bytes.Equal(mac, common.DecodeBase58(k.Mac)) {
-		return fmt.Errorf("wrong password")
+	defer wipeBytes(key)
+	return k.open(key, keySize, salt, aad)
+}
+
+// VerifyPassword reports whether password unlocks the keypair, leaving it
+// encrypted. Only a wrong password gives false without an error. An aes-gcm
+// keypair fails with ErrAADRequired; use VerifyPasswordWithAAD or
+// AccountInfo.VerifyKeyPairPassword.
+func (k *KeyPairInfo) VerifyPassword(password []byte) (bool, error) {
+	return k.VerifyPasswordWithAAD(password, nil)
+}
+
+// VerifyPasswordWithAAD is VerifyPassword for a keypair sealed over aad.
+func (k *KeyPairInfo) VerifyPasswordWithAAD(password, aad []byte) (bool, error) {
+	if !k.IsEncrypted() {
+		return false, ErrNotEncrypted
+	}
+	c := k.Clone()
+	defer c.Wipe()
+	err := c.DecryptWithAAD(password, aad)
+	if errors.Is(err, ErrWrongPassword) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// DecryptWithKey reverses EncryptWithKey.
+func (k *KeyPairInfo) DecryptWithKey(key []byte) error {
+	if !k.IsEncrypted() {
+		return ErrNotEncrypted
+	}
+	if k.KDF != KDFNone {
+		return fmt.Errorf("keypair is not protected by a plain key")
 	}
-	k.RawKey = common.EncodeBase58(outText)
+	if err := k.checkSizes(); err != nil {
+		return err
+	}
+	if len(key) != k.KeySize {
+		return fmt.Errorf("%w %v, keypair uses %v", ErrInvalidKeySize, len(key), k.KeySize)
+	}
+	salt, err := k.decodeSalt()
+	if err != nil {
+		return err
+	}
+	derived, err := expandKey(key, salt[0:32], 2*len(key))
+	if err != nil {
+		return err
+	}
+	defer wipeBytes(derived)
+	return k.open(derived, len(key), salt, nil)
+}
+
+// open checks the MAC with the AES and MAC keys in key and, if it matches,
+// decrypts the raw key. AES-GCM keypairs are authenticated over aad
+// instead.
+func (k *KeyPairInfo) open(key []byte, keySize int, salt, aad []byte) error {
+	aesBlock, err := aes.NewCipher(key[0:keySize])
+	if err != nil {
+		return err
+	}
+	inText, err := decodeSealed("encrypted_key", k.EncryptedKey)
+	if err != nil {
+		return err
+	}
+	wantMac, err := decodeSealed("mac", k.Mac)
+	if err != nil {
+		return err
+	}
+	var outText []byte
+	switch k.Cipher {
+	case "":
+		if len(wantMac) != macSize {
+			return fmt.Errorf("%w: mac is %v bytes, want %v", ErrCorruptKeystore, len(wantMac), macSize)
+		}
+		stream := cipher.NewCTR(aesBlock, salt[32:48])
+		outText = make([]byte, len(inText))
+		stream.XORKeyStream(outText, inText)
+		macInput := append(key[keySize:2*keySize], inText...)
+		defer wipeBytes(macInput)
+		mac := common.Sha3(macInput)
+		if subtle.ConstantTimeCompare(mac, wantMac) != 1 {
+			return ErrWrongPassword
+		}
+	case CipherAESGCM:
+		if outText, err = gcmOpenKey(aesBlock, salt, inText, wantMac, aad); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unsupported cipher %v", k.Cipher)
+	}
+	defer wipeBytes(outText)
+	// catch a swapped or corrupted public key while we hold the plaintext;
+	// key types we cannot derive from are left unchecked
+	if err := k.verifyPublicKey(outText); err != nil && !errors.Is(err, ErrUnsupportedKey) {
+		return err
+	}
+	k.RawKey = NewSecretKey(common.EncodeBase58(outText))
+	k.EncryptedKey = ""
+	k.Salt = ""
+	k.Mac = ""
+	k.KeySize = 0
+	k.KDF = ""
+	k.ScryptParams = nil
+	k.Argon2Params = nil
+	k.Cipher = ""
+	k.Wrapper = ""
+	k.WrappedKey = ""
 	return nil
+}
+
+// saltSize is the KDF salt followed by the CTR IV; macSize is a SHA3-256
+// digest.
+const (
+	saltSize = 48
+	macSize  = 32
+)
 
+// decodeSalt returns the salt of an encrypted keypair, failing with
+// ErrCorruptKeystore if it is too short to hold both parts.
+func (k *KeyPairInfo) decodeSalt() ([]byte, error) {
+	salt, err := decodeSealed("salt", k.Salt)
+	if err != nil {
+		return nil, err
+	}
+	if len(salt) < saltSize {
+		return nil, fmt.Errorf("%w: salt is %v bytes, want %v", ErrCorruptKeystore, len(salt), saltSize)
+	}
+	return salt, nil
+}
+
+// Validate checks that the keypair is internally consistent: encoded fields
+// decode, and an encrypted entry carries the salt and MAC needed to open it.
+// A key type spelled in another case, e.g. "ED25519", is normalized.
+func (k *KeyPairInfo) Validate() error {
+	if err := k.checkSizes(); err != nil {
+		return err
+	}
+	if k.ID == "" {
+		return fmt.Errorf("%w: missing id", ErrInvalidKeyPair)
+	}
+	if k.KeySize != 0 && k.KeySize != 16 && k.KeySize != 32 {
+		return fmt.Errorf("%w %v", ErrInvalidKeySize, k.KeySize)
+	}
+	kt, err := ParseKeyType(string(k.KeyType))
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidKeyPair, err)
+	}
+	k.KeyType = kt
+	if _, err := decodeBase58Field("public_key", k.PubKey); err != nil {
+		return err
+	}
+	if err := k.validatePolicy(); err != nil {
+		return err
+	}
+	if !k.RawKey.IsEmpty() {
+		if _, err := decodeBase58Field("raw_key", k.RawKey.Reveal()); err != nil {
+			return err
+		}
+	}
+	if k.EncryptedKey != "" {
+		if k.Salt == "" || k.Mac == "" {
+			return fmt.Errorf("%w: encrypted key without salt or mac", ErrInvalidKeyPair)
+		}
+		for name, v := range map[string]string{"encrypted_key": k.EncryptedKey, "salt": k.Salt, "mac": k.Mac} {
+			if _, err := decodeBase58Field(name, v); err != nil {
+				return err
+			}
+		}
+		if k.KDF == KDFKeyWrapper {
+			if k.Wrapper == "" {
+				return fmt.Errorf("%w: wrapped key without wrapper id", ErrInvalidKeyPair)
+			}
+			if _, err := decodeBase58Field("wrapped_key", k.WrappedKey); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// decodeSealed decodes a field needed to encrypt or decrypt a keypair. A
+// field that does not decode fails with ErrCorruptKeystore rather than
+// turning into an empty key, salt or MAC.
+func decodeSealed(name, v string) ([]byte, error) {
+	b := common.DecodeBase58(v)
+	if len(b) == 0 {
+		return nil, fmt.Errorf("%w: %v is not valid base58", ErrCorruptKeystore, name)
+	}
+	return b, nil
+}
+
+func decodeBase58Field(name, v string) ([]byte, error) {
+	b := common.DecodeBase58(v)
+	if len(b) == 0 {
+		return nil, fmt.Errorf("%w: %v is not valid base58", ErrInvalidKeyPair, name)
+	}
+	return b, nil
+}
+
+// Wipe zeroes and drops the decrypted key. The caller's password is never
+// retained, so it is theirs to clear.
+func (k *KeyPairInfo) Wipe() {
+	k.RawKey.wipe()
+}
+
+func wipeBytes(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
 }
 
 type AccountInfo struct {
 	Name     string                  `json:"name"`
 	Keypairs map[string]*KeyPairInfo `json:"keypairs"`
+	Checksum string                  `json:"checksum,omitempty"`
+	Version  int                     `json:"version,omitempty"`
+	// Metadata holds free-form tags such as "role": "cold". It is stored
+	// in plaintext, so never put secrets in it.
+	Metadata map[string]string `json:"metadata,omitempty"`
+	// Note is a free-form plaintext remark such as "hardware backup in
+	// safe #3". Like Metadata it is not covered by any MAC or checksum.
+	Note string `json:"notes,omitempty"`
+	// CreatedAt and UpdatedAt are RFC3339 UTC times set by
+	// FileAccountStore.SaveAccount.
+	CreatedAt string `json:"created_at,omitempty"`
+	UpdatedAt string `json:"updated_at,omitempty"`
+	// Thresholds holds permissions satisfied by several keys, added with
+	// AddThresholdPermission.
+	Thresholds map[string]*MultiKeyPermission `json:"thresholds,omitempty"`
+
+	signers map[string]Signer // registered with RegisterSigner, never saved
 }
 
+// Recommended Keypairs keys. The map accepts any permission name, these are
+// the conventional ones.
+const (
+	PermOwner  = "owner"
+	PermActive = "active"
+)
+
 func NewAccountInfo() *AccountInfo {
 	return &AccountInfo{Name: "", Keypairs: make(map[string]*KeyPairInfo)}
 }
 
+// Permissions returns the account's permission names in sorted order.
+func (a *AccountInfo) Permissions() []string {
+	perms := make([]string, 0, len(a.Keypairs))
+	for perm := range a.Keypairs {
+		perms = append(perms, perm)
+	}
+	sort.Strings(perms)
+	return perms
+}
+
+// NamedKeyPair is a keypair together with the permission it is held under.
+type NamedKeyPair struct {
+	Perm    string
+	KeyPair *KeyPairInfo
+}
+
+// OrderedKeypairs returns the keypairs sorted by permission, for callers
+// that need a stable order where ranging over Keypairs gives none.
+func (a *AccountInfo) OrderedKeypairs() []NamedKeyPair {
+	perms := a.Permissions()
+	kps := make([]NamedKeyPair, len(perms))
+	for i, perm := range perms {
+		kps[i] = NamedKeyPair{Perm: perm, KeyPair: a.Keypairs[perm]}
+	}
+	return kps
+}
+
+func (a *AccountInfo) HasPermission(perm string) bool {
+	_, ok := a.Keypairs[perm]
+	return ok
+}
+
 func (a *AccountInfo) GetKeyPair(perm string) (*account2.LoadedKeys, error) {
 	kp, ok := a.Keypairs[perm]
 	if !ok {
-		return nil, fmt.Errorf("invalid permission %v", perm)
+		return nil, fmt.Errorf("%w %v", ErrInvalidPermission, perm)
 	}
 	return kp.ToKeyPair()
 
 }
 
+// AddKeyPair registers kp under perm. A missing public key is derived from
+// the raw key where the key type allows it, then the entry is validated.
+// An optional label replaces kp.Label.
+func (a *AccountInfo) AddKeyPair(perm string, kp *KeyPairInfo, label ...string) error {
+	if a.HasPermission(perm) || a.Thresholds[perm] != nil {
+		return fmt.Errorf("%w: %v", ErrPermissionExists, perm)
+	}
+	switch len(label) {
+	case 0:
+	case 1:
+		kp.Label = label[0]
+	default:
+		return fmt.Errorf("AddKeyPair takes one label, got %v", len(label))
+	}
+	if kp.PubKey == "" && !kp.RawKey.IsEmpty() {
+		raw := kp.RawKey.decode()
+		pub, err := publicKeyFor(kp.KeyType, raw)
+		wipeBytes(raw)
+		if err != nil {
+			return err
+		}
+		kp.PubKey = common.EncodeBase58(pub)
+	}
+	if err := kp.Validate(); err != nil {
+		return err
+	}
+	for other, existing := range a.Keypairs {
+		if existing.ID == kp.ID {
+			return fmt.Errorf("%w %v, already used by %v", ErrDuplicateID, kp.ID, other)
+		}
+	}
+	if a.Keypairs == nil {
+		a.Keypairs = make(map[string]*KeyPairInfo)
+	}
+	a.Keypairs[perm] = kp
+	return nil
+}
+
+// RemoveKeyPair deletes perm, but never the account's only keypair; use
+// ForceRemoveKeyPair for that.
+func (a *AccountInfo) RemoveKeyPair(perm string) error {
+	return a.removeKeyPair(perm, false)
+}
+
+func (a *AccountInfo) ForceRemoveKeyPair(perm string) error {
+	return a.removeKeyPair(perm, true)
+}
+
+func (a *AccountInfo) removeKeyPair(perm string, force bool) error {
+	if !a.HasPermission(perm) {
+		return fmt.Errorf("%w %v", ErrInvalidPermission, perm)
+	}
+	if len(a.Keypairs) == 1 && !force {
+		return fmt.Errorf("%w %v", ErrLastKeyPair, perm)
+	}
+	delete(a.Keypairs, perm)
+	delete(a.signers, perm)
+	return nil
+}
+
+// holdsKey reports whether the account has key material for perm, rather
+// than a registered Signer or a watch-only public key.
+func (a *AccountInfo) holdsKey(perm string) bool {
+	return a.signers[perm] == nil && !a.Keypairs[perm].IsWatchOnly()
+}
+
+// IsEncrypted, Decrypt and Encrypt leave keypairs backed by a registered
+// Signer and watch-only ones alone, as there is no key material to work on.
 func (a *AccountInfo) IsEncrypted() bool {
-	for _, kp := range a.Keypairs {
-		if kp.IsEncrypted() {
+	for perm, kp := range a.Keypairs {
+		if a.holdsKey(perm) && kp.IsEncrypted() {
 			return true
 		}
 	}
 	return false
 }
 
+// EncState classifies an account by which of its keypairs are encrypted.
+type EncState int
+
+const (
+	StateAllPlaintext EncState = iota
+	StateAllEncrypted
+	StateMixed
+)
+
+func (s EncState) String() string {
+	switch s {
+	case StateAllPlaintext:
+		return "plaintext"
+	case StateAllEncrypted:
+		return "encrypted"
+	case StateMixed:
+		return "mixed"
+	}
+	return fmt.Sprintf("EncState(%d)", int(s))
+}
+
+// EncryptionState tells an all encrypted account from one where only some
+// keypairs are, which IsEncrypted does not. Keypairs backed by a registered
+// Signer and watch-only ones are not counted, and an account without
+// keypairs is plaintext.
+func (a *AccountInfo) EncryptionState() EncState {
+	encrypted, plain := 0, 0
+	for perm, kp := range a.Keypairs {
+		switch {
+		case !a.holdsKey(perm):
+		case kp.IsEncrypted():
+			encrypted++
+		default:
+			plain++
+		}
+	}
+	switch {
+	case encrypted > 0 && plain > 0:
+		return StateMixed
+	case encrypted > 0:
+		return StateAllEncrypted
+	}
+	return StateAllPlaintext
+}
+
 func (a *AccountInfo) Decrypt(password []byte) error {
 	if !a.IsEncrypted() {
-		return fmt.Errorf("not encrypted")
+		return fmt.Errorf("account %w", ErrNotEncrypted)
 	}
-	for _, kp := range a.Keypairs {
-		err := kp.Decrypt(password)
+	for perm, kp := range a.Keypairs {
+		if !a.holdsKey(perm) {
+			continue
+		}
+		err := a.decryptKeyPair(perm, kp, password)
 		if err != nil {
 			return err
 		}
 	}
-	fmt.Println("decrypt keystore succeed")
+	currentLogger().Infof("decrypt keystore succeed")
 	return nil
 }
 
+// VerifyPassword reports whether password unlocks every encrypted keypair
+// Decrypt would, without decrypting the account.
+func (a *AccountInfo) VerifyPassword(password []byte) (bool, error) {
+	if !a.IsEncrypted() {
+		return false, fmt.Errorf("account %w", ErrNotEncrypted)
+	}
+	for _, perm := range a.Permissions() {
+		kp := a.Keypairs[perm]
+		if !a.holdsKey(perm) || !kp.IsEncrypted() {
+			continue
+		}
+		ok, err := kp.VerifyPasswordWithAAD(password, a.keyPairAAD(perm, kp))
+		if err != nil {
+			return false, fmt.Errorf("%v: %w", perm, err)
+		}
+		if !ok {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// VerifyKeyPairPassword is KeyPairInfo.VerifyPassword for the keypair of
+// perm, with the associated data an aes-gcm keypair was sealed over.
+func (a *AccountInfo) VerifyKeyPairPassword(perm string, password []byte) (bool, error) {
+	kp, ok := a.Keypairs[perm]
+	if !ok {
+		return false, fmt.Errorf("%w %v", ErrInvalidPermission, perm)
+	}
+	return kp.VerifyPasswordWithAAD(password, a.keyPairAAD(perm, kp))
+}
+
 func (a *AccountInfo) Encrypt(password []byte) error {
+	return a.EncryptWithOptions(password, EncryptOptions{KeySize: 16})
+}
+
+// EncryptWithOptions encrypts every keypair with opts. With CipherAESGCM
+// each keypair is bound to the account name, its permission and key type,
+// so it no longer decrypts once moved elsewhere; opts.AAD is ignored.
+func (a *AccountInfo) EncryptWithOptions(password []byte, opts EncryptOptions) error {
 	if a.IsEncrypted() {
-		return fmt.Errorf("account already encrypted")
+		return fmt.Errorf("account %w", ErrAlreadyEncrypted)
 	}
-	for _, k := range a.Keypairs {
-		err := k.Encrypt(password)
+	for perm, k := range a.Keypairs {
+		if !a.holdsKey(perm) {
+			continue
+		}
+		o := opts
+		o.AAD = nil
+		if o.Cipher == CipherAESGCM {
+			o.AAD = keyPairAAD(a.Name, perm, k.KeyType)
+		}
+		err := k.EncryptWithOptions(password, o)
 		if err != nil {
 			return err
 		}
@@ -167,95 +919,779 @@ func (a *AccountInfo) Encrypt(password []byte) error {
 	return nil
 }
 
+func (a *AccountInfo) Wipe() {
+	for _, kp := range a.Keypairs {
+		kp.Wipe()
+	}
+}
+
+func (a *AccountInfo) checkDuplicateIDs() error {
+	seen := make(map[string]string, len(a.Keypairs))
+	for _, perm := range a.Permissions() {
+		id := a.Keypairs[perm].ID
+		if other, ok := seen[id]; ok {
+			return fmt.Errorf("%w %v, used by both %v and %v", ErrDuplicateID, id, other, perm)
+		}
+		seen[id] = perm
+	}
+	return nil
+}
+
+// computeChecksum hashes the account name and every keypair's ID and public
+// key, so keypairs added to or dropped from the file are detected on load.
+func (a *AccountInfo) computeChecksum() string {
+	entries := make([]string, 0, len(a.Keypairs))
+	for _, kp := range a.Keypairs {
+		entries = append(entries, fmt.Sprintf("%d:%s%d:%s", len(kp.ID), kp.ID, len(kp.PubKey), kp.PubKey))
+	}
+	for perm, m := range a.Thresholds {
+		entries = append(entries, fmt.Sprintf("%d:%s%d:%d", len(perm), perm, m.Threshold, len(m.PubKeys))+strings.Join(m.PubKeys, ","))
+	}
+	sort.Strings(entries)
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "%d:%s", len(a.Name), a.Name)
+	for _, e := range entries {
+		buf.WriteString(e)
+	}
+	return common.EncodeBase58(common.Sha3(buf.Bytes()))
+}
+
+func (a *AccountInfo) EncryptPermission(perm string, password []byte) error {
+	kp, ok := a.Keypairs[perm]
+	if !ok {
+		return fmt.Errorf("%w %v", ErrInvalidPermission, perm)
+	}
+	return kp.Encrypt(password)
+}
+
+func (a *AccountInfo) DecryptPermission(perm string, password []byte) error {
+	kp, ok := a.Keypairs[perm]
+	if !ok {
+		return fmt.Errorf("%w %v", ErrInvalidPermission, perm)
+	}
+	return a.decryptKeyPair(perm, kp, password)
+}
+
+func (a *AccountInfo) IsPermissionEncrypted(perm string) bool {
+	kp, ok := a.Keypairs[perm]
+	return ok && kp.IsEncrypted()
+}
+
+// EncryptEach encrypts every keypair with its own password, keyed by
+// permission. A permission without a password fails the call up front.
+// Watch-only and signer-backed permissions need none and are skipped.
+func (a *AccountInfo) EncryptEach(passwords map[string][]byte) error {
+	return a.eachWithPassword(passwords, func(_ string, kp *KeyPairInfo, password []byte) error {
+		return kp.Encrypt(password)
+	})
+}
+
+// DecryptEach decrypts every keypair with its own password. A wrong password
+// only fails its own keypair; the others are still decrypted and the
+// failures are reported together as KeyPairErrors.
+func (a *AccountInfo) DecryptEach(passwords map[string][]byte) error {
+	return a.eachWithPassword(passwords, a.decryptKeyPair)
+}
+
+func (a *AccountInfo) eachWithPassword(passwords map[string][]byte, fn func(string, *KeyPairInfo, []byte) error) error {
+	for perm := range a.Keypairs {
+		if _, ok := passwords[perm]; !ok && a.holdsKey(perm) {
+			return fmt.Errorf("no password given for permission %v", perm)
+		}
+	}
+	errs := KeyPairErrors{}
+	for perm, kp := range a.Keypairs {
+		if !a.holdsKey(perm) {
+			continue
+		}
+		if err := fn(perm, kp, passwords[perm]); err != nil {
+			errs[perm] = err
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// ChangePassword re-encrypts every keypair under newPassword, keeping each
+// one's KDF and cipher settings. The work happens on copies, so if any
+// keypair fails to open with oldPassword the account is left as it was.
+func (a *AccountInfo) ChangePassword(oldPassword, newPassword []byte) error {
+	return a.rekey(oldPassword, newPassword, (*KeyPairInfo).encryptOptions)
+}
+
+// rekey decrypts every encrypted keypair with oldPassword and encrypts it
+// again with newPassword and the options opts picks for it. Plaintext
+// keypairs are left alone. The account only changes if every keypair
+// succeeds.
+func (a *AccountInfo) rekey(oldPassword, newPassword []byte, opts func(*KeyPairInfo) EncryptOptions) error {
+	rekeyed := make(map[string]KeyPairInfo, len(a.Keypairs))
+	for perm, kp := range a.Keypairs {
+		if !a.holdsKey(perm) || !kp.IsEncrypted() {
+			continue
+		}
+		c := *kp
+		aad := a.keyPairAAD(perm, kp)
+		if err := c.DecryptWithAAD(oldPassword, aad); err != nil {
+			return fmt.Errorf("%v: %w", perm, err)
+		}
+		// sealing drops the decrypted key without clearing it
+		plain := c.RawKey
+		o := opts(kp)
+		o.AAD = aad
+		err := c.EncryptWithOptions(newPassword, o)
+		plain.wipe()
+		if err != nil {
+			return fmt.Errorf("%v: %w", perm, err)
+		}
+		rekeyed[perm] = c
+	}
+	for perm, c := range rekeyed {
+		*a.Keypairs[perm] = c
+	}
+	return nil
+}
+
+// SaveTo writes the keystore to a temporary file next to fileName and
+// renames it into place, so a failed save never leaves a truncated file.
 func (a *AccountInfo) SaveTo(fileName string) error {
-	data, err := json.MarshalIndent(a, "", "  ")
+	currentLogger().Infof("saving keyfile of account %v to %v", a.Name, fileName)
+	return writeFileAtomic(fileName, 0400, a)
+}
+
+// stamp sets the version and checksum a keystore is written with.
+func (a *AccountInfo) stamp() {
+	a.Version = KeystoreVersion
+	a.Checksum = a.computeChecksum()
+}
+
+// writeAccount saves a to fileName in the store's codec, gzipped if the
+// store compresses.
+func (s *FileAccountStore) writeAccount(a *AccountInfo, fileName string, mode os.FileMode) error {
+	data, err := s.encodeAccount(a)
+	if err != nil {
+		return err
+	}
+	if s.Compress {
+		if data, err = gzipKeystore(data); err != nil {
+			return err
+		}
+	}
+	currentLogger().Infof("saving keyfile of account %v to %v", a.Name, fileName)
+	return writeFileAtomic(fileName, mode, bytes.NewReader(data))
+}
+
+// writeFileAtomic writes src to a temporary file next to fileName and
+// renames it into place.
+func writeFileAtomic(fileName string, mode os.FileMode, src io.WriterTo) error {
+	f, err := os.CreateTemp(filepath.Dir(fileName), "."+filepath.Base(fileName)+".tmp*")
 	if err != nil {
 		return err
 	}
-	fmt.Printf("saving keyfile of account %v to %v\n", a.Name, fileName)
-	err = os.WriteFile(fileName, data, 0400)
+	tmpName := f.Name()
+	_, err = src.WriteTo(f)
+	if err == nil {
+		err = f.Sync()
+	}
+	if err == nil {
+		err = f.Chmod(mode)
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmpName, fileName)
+	}
+	if err != nil {
+		os.Remove(tmpName)
+	}
 	return err
 }
 
+// WriteTo serializes the keystore to w, stamping the current version and
+// checksum on a first as SaveTo does. It implements io.WriterTo.
+//
+// The output is byte-for-byte stable for the same account: encoding/json
+// writes map keys, and so Keypairs, in sorted order, and every other field
+// in declaration order.
+func (a *AccountInfo) WriteTo(w io.Writer) (int64, error) {
+	a.stamp()
+	data, err := json.MarshalIndent(a, "", "  ")
+	if err != nil {
+		return 0, err
+	}
+	n, err := w.Write(data)
+	return int64(n), err
+}
+
 func LoadAccountFrom(fileName string) (*AccountInfo, error) {
-	data, err := os.ReadFile(fileName)
+	return loadAccountFrom(fileName, false)
+}
+
+// LoadAccount reads a keystore from r, with the same checks as
+// LoadAccountFrom.
+func LoadAccount(r io.Reader) (*AccountInfo, error) {
+	return decodeAccount(r, "input", false)
+}
+
+func loadAccountFrom(fileName string, strict bool) (*AccountInfo, error) {
+	f, err := os.Open(fileName)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return decodeAccount(f, fileName, strict)
+}
+
+// decodeAccount parses, migrates and validates a keystore; source names it
+// in errors. With strict set, fields this package does not know are an
+// error rather than silently dropped.
+func decodeAccount(r io.Reader, source string, strict bool) (*AccountInfo, error) {
+	data, err := readKeystore(r, source)
+	if err == nil {
+		data, err = gunzipKeystore(data, source)
+	}
 	if err != nil {
 		return nil, err
 	}
 	a := NewAccountInfo()
-	err = json.Unmarshal(data, a)
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if strict {
+		dec.DisallowUnknownFields()
+	}
+	err = dec.Decode(a)
 	if err != nil {
 		return nil, fmt.Errorf("key store should be a json file, %v", err)
 	}
+	return checkAccount(a, source)
+}
+
+// checkAccount migrates a freshly decoded keystore and validates it.
+func checkAccount(a *AccountInfo, source string) (*AccountInfo, error) {
+	if err := a.migrate(); err != nil {
+		return nil, fmt.Errorf("keystore %v: %w", source, err)
+	}
+	errs := KeyPairErrors{}
+	for perm, kp := range a.Keypairs {
+		if kp == nil {
+			errs[perm] = fmt.Errorf("%w: empty keypair", ErrInvalidKeyPair)
+		} else if err := kp.Validate(); err != nil {
+			errs[perm] = err
+		}
+	}
+	for perm, m := range a.Thresholds {
+		if m == nil {
+			errs[perm] = fmt.Errorf("%w: empty threshold permission", ErrInvalidPermission)
+		} else if a.HasPermission(perm) {
+			errs[perm] = fmt.Errorf("%w: %v is both a keypair and a threshold permission", ErrPermissionExists, perm)
+		} else if err := m.Validate(); err != nil {
+			errs[perm] = err
+		}
+	}
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("keystore %v: %w", source, errs)
+	}
+	if err := a.checkDuplicateIDs(); err != nil {
+		return nil, fmt.Errorf("keystore %v: %w", source, err)
+	}
+	if a.Checksum == "" {
+		currentLogger().Warnf("keystore %v has no checksum, one will be added on next save", source)
+	} else if a.Checksum != a.computeChecksum() {
+		return nil, fmt.Errorf("keystore %v failed checksum verification", source)
+	}
 	return a, nil
 }
 
+type AccountStore interface {
+	LoadAccount(name string) (*AccountInfo, error)
+	SaveAccount(a *AccountInfo) error
+	DeleteAccount(name string) error
+	ListAccounts() ([]*AccountInfo, error)
+}
+
 type FileAccountStore struct {
 	AccountDir string
+	// Concurrency bounds how many keystores ListAccounts parses at once,
+	// defaulting to runtime.NumCPU().
+	Concurrency int
+	// StrictDecode rejects keystore files containing unknown fields, which
+	// usually means a misspelled key.
+	StrictDecode bool
+	// RejectMixed makes SaveAccount refuse accounts in StateMixed, which
+	// would put some private keys on disk in plaintext. ForceSaveAccount
+	// saves them anyway.
+	RejectMixed bool
+	// FileMode and DirMode are the permissions of keystore files and of
+	// the directories holding them, 0400 and 0700 when zero. Modes granting
+	// world write access are refused.
+	FileMode os.FileMode
+	DirMode  os.FileMode
+	// Audit, when set, is told about every load, save, delete, rename and
+	// DecryptAccount.
+	Audit AuditSink
+	// Codec is the keystore format, CodecJSON unless set to CodecCBOR.
+	// StrictDecode only applies to JSON.
+	Codec string
+	// FS, when set, is read instead of the local disk, with AccountDir as a
+	// path inside it. Such a store is read-only.
+	FS fs.FS
+	// BackupTimeFormat is the time layout in backup file names,
+	// DefaultBackupTimeFormat when empty. Backups named with an earlier
+	// layout or with time.RFC3339 are still listed. A layout without
+	// sub-second digits makes a second save within its resolution fail
+	// rather than replace the first backup.
+	BackupTimeFormat string
+	// Compress gzips the keystores SaveAccount writes, named .json.gz (or
+	// .cbor.gz). Keystores are read whether compressed or not, so a
+	// directory may hold both.
+	Compress bool
+	// LockTimeout is how long writes wait for another process holding the
+	// store's lock file, DefaultLockTimeout when zero. A negative timeout
+	// fails straight away with ErrLocked.
+	LockTimeout time.Duration
+
+	locks sync.Map // account name -> *sync.Mutex
+
+	procMu    sync.Mutex // guards procLocks
+	procLocks int        // holders of the lock file within this process
+
+	addrMu    sync.Mutex // guards addrIndex
+	addrIndex *addressIndex
+}
+
+// lockAccount serializes writers of a single account's keystore, across
+// processes through the lock file, and returns the matching unlock.
+// Unrelated accounts in one process never contend.
+func (s *FileAccountStore) lockAccount(name string) (func(), error) {
+	if err := s.acquire(s.LockTimeout); err != nil {
+		return nil, err
+	}
+	m, _ := s.locks.LoadOrStore(name, &sync.Mutex{})
+	mu := m.(*sync.Mutex)
+	mu.Lock()
+	return func() {
+		mu.Unlock()
+		if err := s.Unlock(); err != nil {
+			currentLogger().Warnf("releasing lock of %v: %v", s.AccountDir, err)
+		}
+	}, nil
 }
 
 func NewFileAccountStore(accountDir string) *FileAccountStore {
-	return &FileAccountStore{accountDir}
+	return &FileAccountStore{AccountDir: accountDir}
+}
+
+// Init creates the account directory with DirMode, for first runs. An
+// existing directory is left as it is.
+func (s *FileAccountStore) Init() error {
+	if err := s.writable(); err != nil {
+		return err
+	}
+	_, dirMode, err := s.modes()
+	if err != nil {
+		return err
+	}
+	return os.MkdirAll(s.AccountDir, dirMode)
+}
+
+// path returns where file lives, inside s.FS when that is set.
+func (s *FileAccountStore) path(file string) string {
+	if s.FS != nil {
+		return path.Join(".", s.AccountDir, file)
+	}
+	return s.AccountDir + "/" + file
+}
+
+func (s *FileAccountStore) stat(file string) (fs.FileInfo, error) {
+	if s.FS != nil {
+		return fs.Stat(s.FS, s.path(file))
+	}
+	return os.Stat(s.path(file))
+}
+
+func (s *FileAccountStore) readFile(file string) ([]byte, error) {
+	if s.FS != nil {
+		return fs.ReadFile(s.FS, s.path(file))
+	}
+	return os.ReadFile(s.path(file))
+}
+
+// readDir lists dir, relative to the account directory.
+func (s *FileAccountStore) readDir(dir string) ([]fs.DirEntry, error) {
+	if s.FS != nil {
+		return fs.ReadDir(s.FS, s.path(dir))
+	}
+	return os.ReadDir(s.path(dir))
+}
+
+func (s *FileAccountStore) writable() error {
+	if s.FS != nil {
+		return fmt.Errorf("%w: it is backed by an fs.FS", ErrReadOnly)
+	}
+	return nil
+}
+
+// modes returns the file and directory modes to save with, warning about
+// ones that let other users read keystores.
+func (s *FileAccountStore) modes() (os.FileMode, os.FileMode, error) {
+	fileMode, dirMode := s.FileMode, s.DirMode
+	if fileMode == 0 {
+		fileMode = 0400
+	}
+	if dirMode == 0 {
+		dirMode = 0700
+	}
+	if fileMode&0002 != 0 || dirMode&0002 != 0 {
+		return 0, 0, fmt.Errorf("refusing world-writable keystore mode %v / %v", fileMode, dirMode)
+	}
+	if fileMode&^0600 != 0 {
+		currentLogger().Warnf("keystore file mode %v is looser than 0600", fileMode)
+	}
+	if dirMode&^0700 != 0 {
+		currentLogger().Warnf("keystore directory mode %v is looser than 0700", dirMode)
+	}
+	return fileMode, dirMode, nil
+}
+
+func (s *FileAccountStore) loadFile(file string) (*AccountInfo, error) {
+	data, err := s.readFile(file)
+	if err != nil {
+		return nil, err
+	}
+	return s.decodeFile(data, s.path(file))
 }
 
 func (s *FileAccountStore) LoadAccount(name string) (*AccountInfo, error) {
-	fileName := s.AccountDir + "/" + name + ".json"
-	_, err := os.Stat(fileName)
+	a, err := s.loadAccount(name)
+	s.audit(AuditLoad, name, err)
+	return a, err
+}
+
+func (s *FileAccountStore) loadAccount(name string) (*AccountInfo, error) {
+	file := s.keystoreFile(name)
+	_, err := s.stat(file)
 	if err != nil {
-		return nil, fmt.Errorf("account is not imported at %s: %v. use 'iwallet account import %s <private-key>' to import it", fileName, err, name)
+		return nil, fmt.Errorf("account is not imported at %s: %v. use 'iwallet account import %s <private-key>' to import it", s.path(file), err, name)
+	}
+	return s.loadFile(file)
+}
+
+// HasAccount reports whether a keystore file exists for name without
+// parsing it. Errors other than the file not existing are returned.
+func (s *FileAccountStore) HasAccount(name string) (bool, error) {
+	_, err := s.stat(s.keystoreFile(name))
+	if err == nil {
+		return true, nil
+	}
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
 	}
-	return LoadAccountFrom(fileName)
+	return false, err
 }
 
 func (s *FileAccountStore) SaveAccount(a *AccountInfo) error {
+	if s.RejectMixed && a.EncryptionState() == StateMixed {
+		err := fmt.Errorf("account %v: %w", a.Name, ErrMixedEncryption)
+		s.audit(AuditSave, a.Name, err)
+		return err
+	}
+	return s.ForceSaveAccount(a)
+}
+
+// ForceSaveAccount is SaveAccount without the RejectMixed check.
+func (s *FileAccountStore) ForceSaveAccount(a *AccountInfo) error {
+	err := s.saveAccount(a)
+	s.audit(AuditSave, a.Name, err)
+	return err
+}
+
+func (s *FileAccountStore) saveAccount(a *AccountInfo) error {
+	if err := s.writable(); err != nil {
+		return err
+	}
+	fileMode, dirMode, err := s.modes()
+	if err != nil {
+		return err
+	}
+	unlock, err := s.lockAccount(a.Name)
+	if err != nil {
+		return err
+	}
+	defer unlock()
 	dir := s.AccountDir
-	err := os.MkdirAll(s.AccountDir, 0700)
+	err = os.MkdirAll(s.AccountDir, dirMode)
 	if err != nil {
 		return err
 	}
-	fileName := dir + "/" + a.Name + ".json"
-	// back up old keystore file if needed
-	if _, err := os.Stat(fileName); !os.IsNotExist(err) {
-		timeStr := time.Now().Format(time.RFC3339)
-		backupDir := dir + "/backup"
-		err = os.MkdirAll(backupDir, 0700)
-		if err != nil {
-			return err
+	fileName := dir + "/" + a.Name + s.ext()
+	now := time.Now().UTC().Format(time.RFC3339)
+	// back up old keystore file if needed, which may be compressed
+	// differently than the new one
+	oldFile := s.keystoreFile(a.Name)
+	if _, err := os.Stat(dir + "/" + oldFile); !os.IsNotExist(err) {
+		// the file on disk knows best when the account was created
+		if old, err := s.loadFile(oldFile); err == nil && old.CreatedAt != "" {
+			a.CreatedAt = old.CreatedAt
 		}
-		backupFileName := backupDir + "/" + a.Name + "." + timeStr + ".json"
-		fmt.Printf("backing up %v to %v\n", fileName, backupFileName)
-		err = os.Rename(fileName, backupFileName)
-		if err != nil {
+		if err := s.backupFile(a.Name, dir+"/"+oldFile, dirMode); err != nil {
 			return err
 		}
 	}
-	return a.SaveTo(fileName)
+	if a.CreatedAt == "" {
+		a.CreatedAt = now
+	}
+	a.UpdatedAt = now
+	return s.writeAccount(a, fileName, fileMode)
+}
+
+// SavePlan is what SaveAccount would do: write Path, first moving the file
+// already there to BackupPath when Overwrite is set.
+type SavePlan struct {
+	Path       string
+	Overwrite  bool
+	BackupPath string
+}
+
+// SaveAccountDryRun reports what SaveAccount(a) would do without touching
+// the file system.
+func (s *FileAccountStore) SaveAccountDryRun(a *AccountInfo) (SavePlan, error) {
+	if err := s.writable(); err != nil {
+		return SavePlan{}, err
+	}
+	if _, _, err := s.modes(); err != nil {
+		return SavePlan{}, err
+	}
+	plan := SavePlan{Path: s.AccountDir + "/" + a.Name + s.ext()}
+	oldFile := s.keystoreFile(a.Name)
+	_, err := os.Stat(s.AccountDir + "/" + oldFile)
+	switch {
+	case err == nil:
+		plan.Overwrite = true
+		plan.BackupPath = s.backupName(a.Name, s.keystoreExt(oldFile), time.Now())
+	case !os.IsNotExist(err):
+		return SavePlan{}, err
+	}
+	return plan, nil
+}
+
+// DefaultBackupTimeFormat is a UTC timestamp without the colons of
+// RFC3339, which Windows does not allow in file names. It has nanoseconds
+// so that saves in quick succession each keep their backup.
+const DefaultBackupTimeFormat = "20060102T150405.000000000Z"
+
+// legacyBackupTimeFormat is the default of earlier versions, which only
+// had seconds.
+const legacyBackupTimeFormat = "20060102T150405Z"
+
+func (s *FileAccountStore) backupTimeFormat() string {
+	if s.BackupTimeFormat != "" {
+		return s.BackupTimeFormat
+	}
+	return DefaultBackupTimeFormat
+}
+
+// backupName is the backup file of account name taken at t, from a
+// keystore file with extension ext.
+func (s *FileAccountStore) backupName(name, ext string, t time.Time) string {
+	return s.AccountDir + "/backup/" + name + "." + t.UTC().Format(s.backupTimeFormat()) + ext
+}
+
+// backupFile moves an account's keystore file into the backup directory.
+func (s *FileAccountStore) backupFile(name, fileName string, dirMode os.FileMode) error {
+	err := os.MkdirAll(s.AccountDir+"/backup", dirMode)
+	if err != nil {
+		return err
+	}
+	backupFileName := s.backupName(name, s.keystoreExt(fileName), time.Now())
+	// a coarse BackupTimeFormat can name an earlier backup the same; it is
+	// not replaced
+	if _, err := os.Lstat(backupFileName); err == nil {
+		return fmt.Errorf("backup %v already exists, BackupTimeFormat is too coarse", backupFileName)
+	}
+	currentLogger().Infof("backing up %v to %v", fileName, backupFileName)
+	return os.Rename(fileName, backupFileName)
+}
+
+// RenameAccount moves account oldName to newName, updating both the Name
+// stored in the keystore and its file name. The new keystore is written
+// first; only then is the old file moved into the backup directory, so a
+// failed rename leaves the account under its old name.
+func (s *FileAccountStore) RenameAccount(oldName, newName string) error {
+	err := s.renameAccount(oldName, newName)
+	s.audit(AuditRename, oldName+" -> "+newName, err)
+	return err
+}
+
+func (s *FileAccountStore) renameAccount(oldName, newName string) error {
+	if oldName == newName {
+		return fmt.Errorf("account %v already has that name", oldName)
+	}
+	if err := s.writable(); err != nil {
+		return err
+	}
+	fileMode, dirMode, err := s.modes()
+	if err != nil {
+		return err
+	}
+	// lock in a fixed order so two opposite renames cannot deadlock
+	first, second := oldName, newName
+	if second < first {
+		first, second = second, first
+	}
+	unlockFirst, err := s.lockAccount(first)
+	if err != nil {
+		return err
+	}
+	defer unlockFirst()
+	unlockSecond, err := s.lockAccount(second)
+	if err != nil {
+		return err
+	}
+	defer unlockSecond()
+
+	a, err := s.loadAccount(oldName)
+	if err != nil {
+		return err
+	}
+	for _, perm := range a.Permissions() {
+		if a.Keypairs[perm].Cipher == CipherAESGCM {
+			return fmt.Errorf("cannot rename %v: keypair %v is bound to the account name, decrypt it first", oldName, perm)
+		}
+	}
+	newFile := s.AccountDir + "/" + newName + s.ext()
+	if _, err := os.Stat(s.AccountDir + "/" + s.keystoreFile(newName)); !os.IsNotExist(err) {
+		return fmt.Errorf("cannot rename %v: account %v already exists", oldName, newName)
+	}
+	a.Name = newName
+	if err := s.writeAccount(a, newFile, fileMode); err != nil {
+		os.Remove(newFile)
+		return fmt.Errorf("rename %v to %v: %v", oldName, newName, err)
+	}
+	if err := s.backupFile(oldName, s.AccountDir+"/"+s.keystoreFile(oldName), dirMode); err != nil {
+		os.Remove(newFile)
+		return fmt.Errorf("rename %v to %v: %v", oldName, newName, err)
+	}
+	return nil
 }
 
 func (s *FileAccountStore) DeleteAccount(name string) error {
-	f := s.AccountDir + "/" + name + ".json"
-	err := os.Remove(f)
+	err := s.deleteAccount(name)
+	s.audit(AuditDelete, name, err)
+	return err
+}
+
+func (s *FileAccountStore) deleteAccount(name string) error {
+	if err := s.writable(); err != nil {
+		return err
+	}
+	unlock, err := s.lockAccount(name)
 	if err != nil {
 		return err
 	}
-	fmt.Println("File", f, "has been removed.")
+	defer unlock()
+	f := s.AccountDir + "/" + s.keystoreFile(name)
+	if err := os.Remove(f); err != nil {
+		return err
+	}
+	currentLogger().Infof("file %v has been removed", f)
 	return nil
 }
 
 func (s *FileAccountStore) ListAccounts() ([]*AccountInfo, error) {
-	files, err := os.ReadDir(s.AccountDir)
+	return s.ListAccountsContext(context.Background())
+}
+
+// AccountOrder is a sort order for ListAccountsSorted.
+type AccountOrder int
+
+const (
+	ByName AccountOrder = iota
+	ByCreatedAt
+	ByUpdatedAt
+)
+
+// ListAccountsSorted is ListAccounts in the given order, oldest first for
+// the time orders. Accounts saved before timestamps existed come first;
+// ties are broken by name.
+func (s *FileAccountStore) ListAccountsSorted(order AccountOrder) ([]*AccountInfo, error) {
+	accs, err := s.ListAccounts()
 	if err != nil {
 		return nil, err
 	}
-	accs := make([]*AccountInfo, 0)
-	for _, f := range files {
-		fileName := s.AccountDir + "/" + f.Name()
-		acc, err := LoadAccountFrom(fileName)
-		if err != nil {
-			fmt.Println("loading account failed", fileName, err)
-			continue
+	var key func(a *AccountInfo) string
+	switch order {
+	case ByName:
+		return accs, nil
+	case ByCreatedAt:
+		key = func(a *AccountInfo) string { return a.CreatedAt }
+	case ByUpdatedAt:
+		key = func(a *AccountInfo) string { return a.UpdatedAt }
+	default:
+		return nil, fmt.Errorf("unknown account order %v", order)
+	}
+	// RFC3339 UTC timestamps sort as strings; accs is already by name
+	sort.SliceStable(accs, func(i, j int) bool { return key(accs[i]) < key(accs[j]) })
+	return accs, nil
+}
+
+// ListAccountsContext is ListAccounts, checking ctx between files so that
+// scanning a large directory can be abandoned. Files are parsed by a pool of
+// s.Concurrency workers and the result is sorted by account name.
+func (s *FileAccountStore) ListAccountsContext(ctx context.Context) ([]*AccountInfo, error) {
+	files, err := s.readDir("")
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, notInitializedError{err}
+	}
+	if err != nil {
+		return nil, err
+	}
+	workers := s.Concurrency
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	fileNames := make(chan string)
+	results := make(chan *AccountInfo)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for fileName := range fileNames {
+				acc, err := s.loadFile(fileName)
+				if err != nil {
+					currentLogger().Warnf("loading account %v failed: %v", s.path(fileName), err)
+					continue
+				}
+				results <- acc
+			}
+		}()
+	}
+	go func() {
+		defer close(fileNames)
+		for _, f := range files {
+			// skip the backup directory, READMEs and the like
+			if f.IsDir() || s.keystoreExt(f.Name()) == "" {
+				continue
+			}
+			select {
+			case fileNames <- f.Name():
+			case <-ctx.Done():
+				return
+			}
 		}
+	}()
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+	accs := make([]*AccountInfo, 0)
+	for acc := range results {
 		accs = append(accs, acc)
 	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	sort.Slice(accs, func(i, j int) bool { return accs[i].Name < accs[j].Name })
 	return accs, nil
 }
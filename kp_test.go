@@ -0,0 +1,2225 @@
+package sdk
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/quantosnetwork/dev-0.1.0/common"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fastScrypt keeps key derivation in tests cheap
+var fastScrypt = ScryptParams{N: minScryptN, R: 8, P: 1}
+
+// newTestKeyPair imports a fresh private key of keyType.
+func newTestKeyPair(t testing.TB, keyType KeyType) *KeyPairInfo {
+	t.Helper()
+	scheme, err := schemeFor(keyType)
+	if err != nil {
+		t.Fatal(err)
+	}
+	priv, _, err := scheme.newKey(newKeyPairID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	kp, err := ImportPrivateKey(common.EncodeBase58(priv), string(keyType))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return kp
+}
+
+func TestInvalidKeySize(t *testing.T) {
+	tests := []struct {
+		name string
+		run  func(kp *KeyPairInfo) error
+	}{
+		{"EncryptWithOptions", func(kp *KeyPairInfo) error {
+			return kp.EncryptWithOptions([]byte("pw"), EncryptOptions{KeySize: 24, Scrypt: fastScrypt})
+		}},
+		{"EncryptWithKey", func(kp *KeyPairInfo) error {
+			return kp.EncryptWithKey(make([]byte, 24))
+		}},
+		{"DecryptWithKey", func(kp *KeyPairInfo) error {
+			if err := kp.EncryptWithKey(make([]byte, 16)); err != nil {
+				return err
+			}
+			return kp.DecryptWithKey(make([]byte, 32))
+		}},
+		{"Decrypt", func(kp *KeyPairInfo) error {
+			if err := kp.EncryptWithParams([]byte("pw"), fastScrypt); err != nil {
+				return err
+			}
+			kp.KeySize = 1 << 30
+			return kp.Decrypt([]byte("pw"))
+		}},
+		{"Validate", func(kp *KeyPairInfo) error {
+			kp.KeySize = 24
+			return kp.Validate()
+		}},
+		{"EncryptionInfo", func(kp *KeyPairInfo) error {
+			if err := kp.EncryptWithParams([]byte("pw"), fastScrypt); err != nil {
+				return err
+			}
+			kp.KeySize = 8
+			_, err := kp.EncryptionInfo()
+			return err
+		}},
+		{"NewSoftwareKeyWrapper", func(*KeyPairInfo) error {
+			_, err := NewSoftwareKeyWrapper("kek", make([]byte, 16))
+			return err
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.run(newTestKeyPair(t, KeyTypeEd25519)); !errors.Is(err, ErrInvalidKeySize) {
+				t.Fatalf("error = %v, want ErrInvalidKeySize", err)
+			}
+		})
+	}
+}
+
+func TestChangePassword(t *testing.T) {
+	tests := []struct {
+		name string
+		opts EncryptOptions
+	}{
+		{"scrypt aes-ctr", EncryptOptions{KeySize: 16, Scrypt: fastScrypt}},
+		{"scrypt aes-gcm", EncryptOptions{KeySize: 32, Scrypt: fastScrypt, Cipher: CipherAESGCM}},
+		{"argon2id", EncryptOptions{KeySize: 32, KDF: KDFArgon2id, Argon2: Argon2Params{Time: 1, Memory: 1024, Threads: 1}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := NewAccountInfo()
+			a.Name = "alice"
+			kp := newTestKeyPair(t, KeyTypeEd25519)
+			raw := kp.RawKey.Reveal()
+			if err := a.AddKeyPair(PermOwner, kp); err != nil {
+				t.Fatal(err)
+			}
+			if err := a.EncryptWithOptions([]byte("old"), tt.opts); err != nil {
+				t.Fatal(err)
+			}
+			before := *a.Keypairs[PermOwner]
+			if err := a.ChangePassword([]byte("old"), []byte("new")); err != nil {
+				t.Fatal(err)
+			}
+			after := a.Keypairs[PermOwner]
+			if after.Salt == before.Salt || after.EncryptedKey == before.EncryptedKey {
+				t.Fatal("keypair was not re-encrypted")
+			}
+			if !reflect.DeepEqual(after.encryptOptions(), before.encryptOptions()) {
+				t.Fatalf("encryption settings changed from %+v to %+v", before.encryptOptions(), after.encryptOptions())
+			}
+			if err := a.Decrypt([]byte("old")); !errors.Is(err, ErrWrongPassword) {
+				t.Fatalf("old password: Decrypt() = %v, want %v", err, ErrWrongPassword)
+			}
+			if err := a.Decrypt([]byte("new")); err != nil {
+				t.Fatalf("new password: %v", err)
+			}
+			if after.RawKey.Reveal() != raw {
+				t.Fatal("raw key changed")
+			}
+		})
+	}
+}
+
+func TestChangePasswordMixedAccount(t *testing.T) {
+	newAccount := func(t *testing.T) *AccountInfo {
+		a := NewAccountInfo()
+		a.Name = "mixed"
+		for _, perm := range []string{PermOwner, PermActive} {
+			if err := a.AddKeyPair(perm, newTestKeyPair(t, KeyTypeEd25519)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := a.Keypairs[PermOwner].EncryptWithParams([]byte("old"), fastScrypt); err != nil {
+			t.Fatal(err)
+		}
+		return a
+	}
+	tests := []struct {
+		name    string
+		old     string
+		wantErr error
+	}{
+		{"right password", "old", nil},
+		{"wrong password", "wrong", ErrWrongPassword},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := newAccount(t)
+			owner, active := *a.Keypairs[PermOwner], *a.Keypairs[PermActive]
+			err := a.ChangePassword([]byte(tt.old), []byte("new"))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ChangePassword() = %v, want %v", err, tt.wantErr)
+			}
+			if got := a.Keypairs[PermActive]; got.IsEncrypted() || got.RawKey.Reveal() != active.RawKey.Reveal() {
+				t.Fatal("plaintext keypair was changed")
+			}
+			if tt.wantErr != nil {
+				if a.Keypairs[PermOwner].EncryptedKey != owner.EncryptedKey {
+					t.Fatal("failed ChangePassword changed the account")
+				}
+				return
+			}
+			if err := a.Keypairs[PermOwner].Decrypt([]byte("new")); err != nil {
+				t.Fatalf("owner does not open with the new password: %v", err)
+			}
+		})
+	}
+}
+
+func TestImportPrivateKeyVectors(t *testing.T) {
+	tests := []struct {
+		name    string
+		keyType KeyType
+		priv    string
+		pub     string
+	}{
+		// RFC 8032, section 7.1, test 1
+		{"ed25519", KeyTypeEd25519,
+			"9d61b19deffd5a60ba844af492ec2cc44449c5697b326919703bac031cae7f60",
+			"d75a980182b10ab7d54bfed3c964073a0ee172f3daa62325af021a68f707511a"},
+		// d = 1 gives the compressed base point
+		{"p256", KeyTypeP256,
+			"0000000000000000000000000000000000000000000000000000000000000001",
+			"036b17d1f2e12c4247f8bce6e563a440f277037d812deb33a0f4a13945d898c296"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			priv, err := hex.DecodeString(tt.priv)
+			if err != nil {
+				t.Fatal(err)
+			}
+			rawKey := common.EncodeBase58(priv)
+			kp, err := ImportPrivateKey(rawKey, string(tt.keyType))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := hex.EncodeToString(common.DecodeBase58(kp.PubKey)); got != tt.pub {
+				t.Fatalf("public key %v, want %v", got, tt.pub)
+			}
+			if kp.RawKey.Reveal() != rawKey || kp.KeyType != tt.keyType {
+				t.Fatalf("imported %v key %v, want %v key %v", kp.KeyType, kp.RawKey.Reveal(), tt.keyType, rawKey)
+			}
+		})
+	}
+	raw := common.EncodeBase58(make([]byte, 32))
+	if _, err := ImportPrivateKey(raw, "quantos"); !errors.Is(err, ErrUnsupportedKey) {
+		t.Fatalf("ImportPrivateKey(quantos) = %v, want %v", err, ErrUnsupportedKey)
+	}
+	if _, err := ImportPrivateKey("0OIl", "ed25519"); !errors.Is(err, ErrInvalidKeyPair) {
+		t.Fatalf("ImportPrivateKey with a non-base58 key = %v, want %v", err, ErrInvalidKeyPair)
+	}
+}
+
+func TestNewKeyPairInfoRoundTrip(t *testing.T) {
+	tests := []struct {
+		keyType KeyType
+		pubLen  int
+	}{
+		{KeyTypeEd25519, 32},
+		{KeyTypeP256, 33},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.keyType), func(t *testing.T) {
+			priv, _, err := keySchemes[tt.keyType].newKey("")
+			if err != nil {
+				t.Fatal(err)
+			}
+			rawKey := common.EncodeBase58(priv)
+			kp, err := NewKeyPairInfo(rawKey, string(tt.keyType))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if kp.KeyType != tt.keyType {
+				t.Fatalf("KeyType = %v, want %v", kp.KeyType, tt.keyType)
+			}
+			if n := len(common.DecodeBase58(kp.PubKey)); n != tt.pubLen {
+				t.Fatalf("public key is %v bytes, want %v", n, tt.pubLen)
+			}
+			if err := kp.VerifyPublicKey(); err != nil {
+				t.Fatal(err)
+			}
+			pub := kp.PubKey
+			if err := kp.EncryptWithParams([]byte("pw"), fastScrypt); err != nil {
+				t.Fatal(err)
+			}
+			if err := kp.Decrypt([]byte("pw")); err != nil {
+				t.Fatal(err)
+			}
+			if kp.RawKey.Reveal() != rawKey || kp.PubKey != pub {
+				t.Fatal("round trip changed the keypair")
+			}
+		})
+	}
+}
+
+func TestNewKeyPairInfoRejects(t *testing.T) {
+	tests := []struct {
+		name    string
+		rawKey  string
+		keyType string
+		wantErr error
+	}{
+		{"empty", "", "ed25519", ErrEmptyKey},
+		{"unknown type", common.EncodeBase58(make([]byte, 32)), "rsa", ErrUnsupportedKey},
+		{"short ed25519 key", common.EncodeBase58([]byte{1, 2, 3}), "ed25519", ErrInvalidKeyPair},
+		{"zero p256 scalar", common.EncodeBase58(make([]byte, 32)), "p256", ErrInvalidKeyPair},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewKeyPairInfo(tt.rawKey, tt.keyType); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("NewKeyPairInfo() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// fuzzSeeds returns keystores for FuzzLoadAccount: valid ones in each
+// encryption mode and near-valid ones with a single field broken.
+func fuzzSeeds(t testing.TB) [][]byte {
+	encode := func(a *AccountInfo) []byte {
+		var buf bytes.Buffer
+		if _, err := a.WriteTo(&buf); err != nil {
+			t.Fatal(err)
+		}
+		return buf.Bytes()
+	}
+	account := func(opts *EncryptOptions) *AccountInfo {
+		a := NewAccountInfo()
+		a.Name = "fuzz"
+		for _, kt := range []KeyType{KeyTypeEd25519, KeyTypeP256} {
+			if err := a.AddKeyPair(string(kt), newTestKeyPair(t, kt)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if opts != nil {
+			if err := a.EncryptWithOptions([]byte("pw"), *opts); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return a
+	}
+	seeds := [][]byte{
+		encode(account(nil)),
+		encode(account(&EncryptOptions{Scrypt: fastScrypt})),
+		encode(account(&EncryptOptions{KeySize: 32, Scrypt: fastScrypt, Cipher: CipherAESGCM})),
+		encode(account(&EncryptOptions{KDF: KDFArgon2id, Argon2: Argon2Params{Time: 1, Memory: 64, Threads: 1}})),
+	}
+	breaks := []func(kp *KeyPairInfo){
+		func(kp *KeyPairInfo) { kp.Salt = kp.Salt[:2] },
+		func(kp *KeyPairInfo) { kp.Mac = "" },
+		func(kp *KeyPairInfo) { kp.EncryptedKey = kp.EncryptedKey[:len(kp.EncryptedKey)/2] },
+		func(kp *KeyPairInfo) { kp.KeySize = 1 << 30 },
+		func(kp *KeyPairInfo) { kp.KDF = "bcrypt" },
+		func(kp *KeyPairInfo) { kp.ScryptParams = &ScryptParams{N: 1 << 30, R: 1 << 30, P: 1 << 30} },
+		func(kp *KeyPairInfo) { kp.Cipher = CipherAESGCM },
+	}
+	for _, brk := range breaks {
+		a := account(&EncryptOptions{Scrypt: fastScrypt})
+		brk(a.Keypairs[string(KeyTypeEd25519)])
+		seeds = append(seeds, encode(a))
+	}
+	gz, err := gzipKeystore(seeds[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	seeds = append(seeds, gz, seeds[1][:len(seeds[1])/2], []byte(`{"name":"fuzz","keypairs":{"owner":null}}`))
+	return seeds
+}
+
+func FuzzLoadAccount(f *testing.F) {
+	for _, seed := range fuzzSeeds(f) {
+		f.Add(seed, []byte("pw"))
+	}
+	f.Fuzz(func(t *testing.T, data, password []byte) {
+		a, err := LoadAccount(bytes.NewReader(data))
+		if err != nil {
+			return
+		}
+		for _, kp := range a.Keypairs {
+			if _, err := kp.EncryptionInfo(); err != nil || !kp.IsEncrypted() {
+				continue
+			}
+			// keep each input cheap; the parameter bounds have their own tests
+			if p := kp.ScryptParams; p != nil && uint64(p.N)*uint64(p.R)*uint64(p.P) > 1<<14 {
+				continue
+			}
+			if p := kp.Argon2Params; p != nil && uint64(p.Memory)*uint64(p.Time) > 1<<12 {
+				continue
+			}
+			c := kp.Clone()
+			c.Decrypt(password)
+			c.Wipe()
+		}
+		var buf bytes.Buffer
+		if _, err := a.WriteTo(&buf); err != nil {
+			t.Fatalf("WriteTo failed on a loaded keystore: %v", err)
+		}
+		if _, err := LoadAccount(&buf); err != nil {
+			t.Fatalf("rewritten keystore does not load: %v", err)
+		}
+	})
+}
+
+func TestToKeyPairRejects(t *testing.T) {
+	encrypted := newTestKeyPair(t, KeyTypeEd25519)
+	if err := encrypted.EncryptWithParams([]byte("pw"), fastScrypt); err != nil {
+		t.Fatal(err)
+	}
+	watchOnly, err := NewWatchOnlyKeyPair(newTestKeyPair(t, KeyTypeEd25519).PubKey, "ed25519")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name    string
+		kp      *KeyPairInfo
+		wantErr error
+	}{
+		{"encrypted", encrypted, ErrStillEncrypted},
+		{"watch-only", watchOnly, ErrWatchOnly},
+		{"ed25519", newTestKeyPair(t, KeyTypeEd25519), ErrUnsupportedKey},
+		{"p256", newTestKeyPair(t, KeyTypeP256), ErrUnsupportedKey},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.kp.ToKeyPair(); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ToKeyPair() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestToKeyPairNative(t *testing.T) {
+	priv, pub, err := quantosScheme{}.newKey(newKeyPairID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	kp := &KeyPairInfo{
+		ID:      newKeyPairID(),
+		RawKey:  NewSecretKey(common.EncodeBase58(priv)),
+		KeyType: KeyTypeQuantos,
+		PubKey:  common.EncodeBase58(pub),
+	}
+	lk, err := kp.ToKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := lk.Pub.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if common.EncodeBase58(got) != kp.PubKey {
+		t.Fatalf("loaded public key %v, want the stored %v", common.EncodeBase58(got), kp.PubKey)
+	}
+	if lk.PubKeySign == nil {
+		t.Fatal("PubKeySign is not populated")
+	}
+
+	other, otherPub, err := quantosScheme{}.newKey(newKeyPairID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	wipeBytes(other)
+	kp.PubKey = common.EncodeBase58(otherPub)
+	if _, err := kp.ToKeyPair(); err == nil {
+		t.Fatal("ToKeyPair accepted a public key of another keypair")
+	}
+}
+
+func TestEncryptRandomIV(t *testing.T) {
+	kp := newTestKeyPair(t, KeyTypeEd25519)
+	zero := make([]byte, 16)
+	var ivs [][]byte
+	for i := 0; i < 2; i++ {
+		c := kp.Clone()
+		if err := c.EncryptWithParams([]byte("pw"), fastScrypt); err != nil {
+			t.Fatal(err)
+		}
+		salt, err := c.decodeSalt()
+		if err != nil {
+			t.Fatal(err)
+		}
+		iv := salt[32:48]
+		if bytes.Equal(iv, zero) {
+			t.Fatal("IV is all zero")
+		}
+		ivs = append(ivs, iv)
+	}
+	if bytes.Equal(ivs[0], ivs[1]) {
+		t.Fatal("two encryptions of the same key reused the IV")
+	}
+}
+
+func TestEncryptDecryptRoundTrip(t *testing.T) {
+	tamper := func(kp *KeyPairInfo) {
+		ct := common.DecodeBase58(kp.EncryptedKey)
+		ct[0] ^= 1
+		kp.EncryptedKey = common.EncodeBase58(ct)
+	}
+	tests := []struct {
+		name     string
+		password string
+		edit     func(*KeyPairInfo)
+		wantErr  error
+	}{
+		{"right password", "pw", nil, nil},
+		{"wrong password", "wrong", nil, ErrWrongPassword},
+		{"empty password", "", nil, ErrWrongPassword},
+		{"tampered ciphertext", "pw", tamper, ErrWrongPassword},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kp := newTestKeyPair(t, KeyTypeEd25519)
+			want := kp.RawKey.Reveal()
+			if err := kp.EncryptWithParams([]byte("pw"), fastScrypt); err != nil {
+				t.Fatal(err)
+			}
+			if !kp.RawKey.IsEmpty() || kp.EncryptedKey == "" || kp.Mac == "" {
+				t.Fatal("Encrypt did not replace the raw key with ciphertext and mac")
+			}
+			if tt.edit != nil {
+				tt.edit(kp)
+			}
+			err := kp.Decrypt([]byte(tt.password))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Decrypt() = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				if !kp.IsEncrypted() {
+					t.Fatal("failed Decrypt changed the keypair")
+				}
+				return
+			}
+			if got := kp.RawKey.Reveal(); got != want {
+				t.Fatal("Decrypt did not recover the raw key")
+			}
+		})
+	}
+}
+
+func TestEncryptKeySize(t *testing.T) {
+	tests := []struct {
+		keySize int
+		want    int
+	}{
+		{0, 16},
+		{16, 16},
+		{32, 32},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprint(tt.keySize), func(t *testing.T) {
+			kp := newTestKeyPair(t, KeyTypeEd25519)
+			want := kp.RawKey.Reveal()
+			if err := kp.EncryptWithOptions([]byte("pw"), EncryptOptions{KeySize: tt.keySize, Scrypt: fastScrypt}); err != nil {
+				t.Fatal(err)
+			}
+			// the key size has to survive the keystore file
+			data, err := json.Marshal(kp)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var loaded KeyPairInfo
+			if err := json.Unmarshal(data, &loaded); err != nil {
+				t.Fatal(err)
+			}
+			if loaded.KeySize != tt.want {
+				t.Fatalf("KeySize = %v, want %v", loaded.KeySize, tt.want)
+			}
+			if err := loaded.Decrypt([]byte("pw")); err != nil {
+				t.Fatal(err)
+			}
+			if loaded.RawKey.Reveal() != want {
+				t.Fatal("Decrypt did not recover the raw key")
+			}
+		})
+	}
+}
+
+func TestDecryptChecksMac(t *testing.T) {
+	tests := []struct {
+		name    string
+		mac     func(mac []byte) []byte
+		wantErr error
+	}{
+		{"intact", func(mac []byte) []byte { return mac }, nil},
+		{"last byte flipped", func(mac []byte) []byte { mac[len(mac)-1] ^= 1; return mac }, ErrWrongPassword},
+		{"first byte flipped", func(mac []byte) []byte { mac[0] ^= 1; return mac }, ErrWrongPassword},
+		{"truncated", func(mac []byte) []byte { return mac[:len(mac)-1] }, ErrCorruptKeystore},
+		{"extended", func(mac []byte) []byte { return append(mac, 0) }, ErrCorruptKeystore},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kp := newTestKeyPair(t, KeyTypeEd25519)
+			if err := kp.EncryptWithParams([]byte("pw"), fastScrypt); err != nil {
+				t.Fatal(err)
+			}
+			kp.Mac = common.EncodeBase58(tt.mac(common.DecodeBase58(kp.Mac)))
+			if err := kp.Decrypt([]byte("pw")); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Decrypt() = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDecryptShortFields(t *testing.T) {
+	truncate := func(v string, n int) string { return common.EncodeBase58(common.DecodeBase58(v)[:n]) }
+	encrypt := map[string]func(kp *KeyPairInfo) error{
+		"aes-ctr": func(kp *KeyPairInfo) error { return kp.EncryptWithParams([]byte("pw"), fastScrypt) },
+		"aes-gcm": func(kp *KeyPairInfo) error {
+			return kp.EncryptWithOptions([]byte("pw"), EncryptOptions{Scrypt: fastScrypt, Cipher: CipherAESGCM, AAD: []byte("aad")})
+		},
+		"plain key": func(kp *KeyPairInfo) error { return kp.EncryptWithKey(make([]byte, 32)) },
+	}
+	decrypt := func(kp *KeyPairInfo) error {
+		if kp.KDF == KDFNone {
+			return kp.DecryptWithKey(make([]byte, 32))
+		}
+		return kp.DecryptWithAAD([]byte("pw"), []byte("aad"))
+	}
+	tests := []struct {
+		name    string
+		corrupt func(kp *KeyPairInfo)
+	}{
+		{"salt without iv", func(kp *KeyPairInfo) { kp.Salt = truncate(kp.Salt, 32) }},
+		{"salt one byte short", func(kp *KeyPairInfo) { kp.Salt = truncate(kp.Salt, saltSize-1) }},
+		{"one byte salt", func(kp *KeyPairInfo) { kp.Salt = truncate(kp.Salt, 1) }},
+		{"one byte mac", func(kp *KeyPairInfo) { kp.Mac = truncate(kp.Mac, 1) }},
+	}
+	for cipher, enc := range encrypt {
+		for _, tt := range tests {
+			t.Run(cipher+"/"+tt.name, func(t *testing.T) {
+				kp := newTestKeyPair(t, KeyTypeEd25519)
+				if err := enc(kp); err != nil {
+					t.Fatal(err)
+				}
+				tt.corrupt(kp)
+				if err := decrypt(kp); !errors.Is(err, ErrCorruptKeystore) {
+					t.Fatalf("decrypting = %v, want %v", err, ErrCorruptKeystore)
+				}
+				if !kp.IsEncrypted() {
+					t.Fatal("failed decryption changed the keypair")
+				}
+			})
+		}
+	}
+}
+
+func TestCorruptBase58(t *testing.T) {
+	const bad = "0OIl"
+	encrypted := func(t *testing.T) *KeyPairInfo {
+		kp := newTestKeyPair(t, KeyTypeEd25519)
+		if err := kp.EncryptWithParams([]byte("pw"), fastScrypt); err != nil {
+			t.Fatal(err)
+		}
+		return kp
+	}
+	decrypt := func(kp *KeyPairInfo) error { return kp.Decrypt([]byte("pw")) }
+	tests := []struct {
+		field   string
+		setup   func(t *testing.T) *KeyPairInfo
+		corrupt func(kp *KeyPairInfo)
+		run     func(kp *KeyPairInfo) error
+	}{
+		{"salt", encrypted, func(kp *KeyPairInfo) { kp.Salt = bad }, decrypt},
+		{"mac", encrypted, func(kp *KeyPairInfo) { kp.Mac = bad }, decrypt},
+		{"encrypted_key", encrypted, func(kp *KeyPairInfo) { kp.EncryptedKey = bad }, decrypt},
+		{"raw_key", func(t *testing.T) *KeyPairInfo { return newTestKeyPair(t, KeyTypeEd25519) },
+			func(kp *KeyPairInfo) { kp.RawKey = NewSecretKey(bad) },
+			func(kp *KeyPairInfo) error { return kp.EncryptWithParams([]byte("pw"), fastScrypt) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.field, func(t *testing.T) {
+			kp := tt.setup(t)
+			tt.corrupt(kp)
+			before := *kp
+			err := tt.run(kp)
+			if !errors.Is(err, ErrCorruptKeystore) || !strings.Contains(err.Error(), tt.field) {
+				t.Fatalf("error = %v, want %v naming %v", err, ErrCorruptKeystore, tt.field)
+			}
+			if kp.Salt != before.Salt || kp.Mac != before.Mac || kp.EncryptedKey != before.EncryptedKey || kp.RawKey.Reveal() != before.RawKey.Reveal() {
+				t.Fatal("keypair changed by the failed call")
+			}
+		})
+	}
+}
+
+func TestWipe(t *testing.T) {
+	a := NewAccountInfo()
+	var bufs [][]byte
+	for _, perm := range []string{PermOwner, PermActive} {
+		kp := newTestKeyPair(t, KeyTypeEd25519)
+		bufs = append(bufs, kp.RawKey.b)
+		if err := a.AddKeyPair(perm, kp); err != nil {
+			t.Fatal(err)
+		}
+	}
+	a.Wipe()
+	for perm, kp := range a.Keypairs {
+		if !kp.RawKey.IsEmpty() {
+			t.Fatalf("%v still holds its raw key", perm)
+		}
+	}
+	for _, b := range bufs {
+		if !bytes.Equal(b, make([]byte, len(b))) {
+			t.Fatal("raw key bytes were dropped without being zeroed")
+		}
+	}
+}
+
+func TestWipeBytes(t *testing.T) {
+	for _, n := range []int{0, 1, 48} {
+		b := bytes.Repeat([]byte{0xff}, n)
+		wipeBytes(b)
+		if !bytes.Equal(b, make([]byte, n)) {
+			t.Fatalf("wipeBytes left %x", b)
+		}
+	}
+}
+
+// encodeAccount writes a the way SaveAccount does and returns the decoded
+// JSON object, for tests that edit keystores by hand.
+func encodeAccount(t testing.TB, a *AccountInfo) map[string]interface{} {
+	t.Helper()
+	var buf bytes.Buffer
+	if _, err := a.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	var doc map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	return doc
+}
+
+func TestAccountChecksum(t *testing.T) {
+	extra, err := json.Marshal(newTestKeyPair(t, KeyTypeEd25519))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name string
+		edit func(doc map[string]interface{})
+		ok   bool
+	}{
+		{"untouched", func(map[string]interface{}) {}, true},
+		{"no checksum", func(doc map[string]interface{}) { delete(doc, "checksum") }, true},
+		{"renamed", func(doc map[string]interface{}) { doc["name"] = "mallory" }, false},
+		{"keypair removed", func(doc map[string]interface{}) {
+			delete(doc["keypairs"].(map[string]interface{}), PermActive)
+		}, false},
+		{"keypair added", func(doc map[string]interface{}) {
+			var kp interface{}
+			json.Unmarshal(extra, &kp)
+			doc["keypairs"].(map[string]interface{})["backup"] = kp
+		}, false},
+		{"public key swapped", func(doc map[string]interface{}) {
+			kps := doc["keypairs"].(map[string]interface{})
+			owner, active := kps[PermOwner].(map[string]interface{}), kps[PermActive].(map[string]interface{})
+			owner["public_key"], active["public_key"] = active["public_key"], owner["public_key"]
+		}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := NewAccountInfo()
+			a.Name = "alice"
+			for _, perm := range []string{PermOwner, PermActive} {
+				if err := a.AddKeyPair(perm, newTestKeyPair(t, KeyTypeEd25519)); err != nil {
+					t.Fatal(err)
+				}
+			}
+			doc := encodeAccount(t, a)
+			tt.edit(doc)
+			data, err := json.Marshal(doc)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := LoadAccount(bytes.NewReader(data))
+			if (err == nil) != tt.ok {
+				t.Fatalf("LoadAccount() error = %v, want ok=%v", err, tt.ok)
+			}
+			if err != nil {
+				return
+			}
+			// a missing checksum is written on the next save
+			if doc := encodeAccount(t, got); doc["checksum"] != got.computeChecksum() {
+				t.Fatalf("saved checksum %v, want %v", doc["checksum"], got.computeChecksum())
+			}
+		})
+	}
+}
+
+// cancelFS cancels a context once the first keystore is opened.
+type cancelFS struct {
+	fs.FS
+	cancel context.CancelFunc
+	opened int32
+}
+
+func (c *cancelFS) Open(name string) (fs.File, error) {
+	if strings.HasSuffix(name, ".json") && atomic.AddInt32(&c.opened, 1) == 1 {
+		c.cancel()
+	}
+	return c.FS.Open(name)
+}
+
+func TestListAccountsContext(t *testing.T) {
+	names := make([]string, 20)
+	for i := range names {
+		names[i] = fmt.Sprintf("acc%02d", i)
+	}
+	dir := newTestStore(t, names...).AccountDir
+
+	all, err := NewFileAccountStore(dir).ListAccountsContext(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != len(names) {
+		t.Fatalf("listed %v accounts, want %v", len(all), len(names))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	fsys := &cancelFS{FS: os.DirFS(dir), cancel: cancel}
+	s := &FileAccountStore{AccountDir: ".", FS: fsys, Concurrency: 1}
+	accs, err := s.ListAccountsContext(ctx)
+	if !errors.Is(err, context.Canceled) || accs != nil {
+		t.Fatalf("ListAccountsContext() = %v accounts, %v, want context.Canceled", len(accs), err)
+	}
+	if n := atomic.LoadInt32(&fsys.opened); n >= int32(len(names)) {
+		t.Fatalf("opened %v keystores after cancellation, want an early return", n)
+	}
+}
+
+// writeTestAccounts saves n plaintext accounts named acc000, acc001, ...
+func writeTestAccounts(t testing.TB, s *FileAccountStore, n int) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		a := NewAccountInfo()
+		a.Name = fmt.Sprintf("acc%03d", i)
+		if err := a.AddKeyPair(PermOwner, newTestKeyPair(t, KeyTypeEd25519)); err != nil {
+			t.Fatal(err)
+		}
+		if err := s.SaveAccount(a); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestListAccountsConcurrency(t *testing.T) {
+	s := NewFileAccountStore(t.TempDir())
+	writeTestAccounts(t, s, 40)
+	// broken and foreign files are skipped, not fatal
+	for name, data := range map[string]string{"broken.json": "{", "README.md": "hi"} {
+		if err := os.WriteFile(s.path(name), []byte(data), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, workers := range []int{0, 1, 3, 64} {
+		t.Run(fmt.Sprint(workers), func(t *testing.T) {
+			s.Concurrency = workers
+			accs, err := s.ListAccounts()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(accs) != 40 {
+				t.Fatalf("listed %v accounts, want 40", len(accs))
+			}
+			for i, a := range accs {
+				if want := fmt.Sprintf("acc%03d", i); a.Name != want {
+					t.Fatalf("account %v is %v, want %v", i, a.Name, want)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkListAccounts(b *testing.B) {
+	s := NewFileAccountStore(b.TempDir())
+	writeTestAccounts(b, s, 500)
+	for _, workers := range []int{1, 2, 4, 8} {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			s.Concurrency = workers
+			for i := 0; i < b.N; i++ {
+				if _, err := s.ListAccounts(); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestSaveAccountConcurrent(t *testing.T) {
+	s := NewFileAccountStore(t.TempDir())
+	a := NewAccountInfo()
+	a.Name = "shared"
+	if err := a.AddKeyPair(PermOwner, newTestKeyPair(t, KeyTypeEd25519)); err != nil {
+		t.Fatal(err)
+	}
+	data, err := json.Marshal(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var wg sync.WaitGroup
+	errs := make(chan error, 32)
+	for i := 0; i < cap(errs); i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			b := NewAccountInfo()
+			if err := json.Unmarshal(data, b); err != nil {
+				errs <- err
+				return
+			}
+			b.Note = fmt.Sprint(i)
+			errs <- s.SaveAccount(b)
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	entries, err := os.ReadDir(s.AccountDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, e := range entries {
+		if !strings.HasPrefix(e.Name(), ".") {
+			names = append(names, e.Name())
+		}
+	}
+	if want := []string{"backup", "shared.json"}; fmt.Sprint(names) != fmt.Sprint(want) {
+		t.Fatalf("account directory holds %v, want %v", names, want)
+	}
+	raw, err := os.ReadFile(s.path("shared.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !json.Valid(raw) {
+		t.Fatalf("keystore is not valid JSON: %q", raw)
+	}
+	loaded, err := s.LoadAccount("shared")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Keypairs[PermOwner] == nil {
+		t.Fatal("owner keypair lost")
+	}
+}
+
+func TestLockAccountIndependent(t *testing.T) {
+	s := NewFileAccountStore(t.TempDir())
+	if err := s.Init(); err != nil {
+		t.Fatal(err)
+	}
+	unlock, err := s.lockAccount("a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer unlock()
+	done := make(chan error)
+	go func() {
+		unlockB, err := s.lockAccount("b")
+		if err == nil {
+			unlockB()
+		}
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("locking an unrelated account blocked")
+	}
+}
+
+func TestKeyPairValidate(t *testing.T) {
+	const notBase58 = "0OIl"
+	tests := []struct {
+		name    string
+		change  func(k *KeyPairInfo)
+		wantErr error
+	}{
+		{"valid", func(k *KeyPairInfo) {}, nil},
+		{"missing id", func(k *KeyPairInfo) { k.ID = "" }, ErrInvalidKeyPair},
+		{"unknown key type", func(k *KeyPairInfo) { k.KeyType = "rsa" }, ErrInvalidKeyPair},
+		{"bad key size", func(k *KeyPairInfo) { k.KeySize = 24 }, ErrInvalidKeySize},
+		{"bad public key", func(k *KeyPairInfo) { k.PubKey = notBase58 }, ErrInvalidKeyPair},
+		{"bad encrypted key", func(k *KeyPairInfo) { k.EncryptedKey = notBase58 }, ErrInvalidKeyPair},
+		{"bad salt", func(k *KeyPairInfo) { k.Salt = notBase58 }, ErrInvalidKeyPair},
+		{"bad mac", func(k *KeyPairInfo) { k.Mac = notBase58 }, ErrInvalidKeyPair},
+		{"missing salt", func(k *KeyPairInfo) { k.Salt = "" }, ErrInvalidKeyPair},
+		{"missing mac", func(k *KeyPairInfo) { k.Mac = "" }, ErrInvalidKeyPair},
+		{"oversized label", func(k *KeyPairInfo) { k.Label = strings.Repeat("x", MaxKeyPairFieldSize+1) }, ErrKeystoreTooLarge},
+		{"bad raw key", func(k *KeyPairInfo) {
+			k.EncryptedKey, k.Salt, k.Mac = "", "", ""
+			k.RawKey = NewSecretKey(notBase58)
+		}, ErrInvalidKeyPair},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k := newTestKeyPair(t, KeyTypeEd25519)
+			if err := k.EncryptWithParams([]byte("pw"), fastScrypt); err != nil {
+				t.Fatal(err)
+			}
+			tt.change(k)
+			err := k.Validate()
+			if tt.wantErr == nil {
+				if err != nil {
+					t.Fatalf("Validate() = %v", err)
+				}
+			} else if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Validate() = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadAccountCollectsKeyPairErrors(t *testing.T) {
+	a := NewAccountInfo()
+	a.Name = "broken"
+	for _, perm := range []string{PermOwner, PermActive} {
+		if err := a.AddKeyPair(perm, newTestKeyPair(t, KeyTypeEd25519)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	m := encodeAccount(t, a)
+	delete(m, "checksum")
+	kps := m["keypairs"].(map[string]interface{})
+	kps[PermOwner].(map[string]interface{})["key_type"] = "rsa"
+	kps[PermActive].(map[string]interface{})["public_key"] = "0OIl"
+	data, err := json.Marshal(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = LoadAccount(bytes.NewReader(data))
+	var kpErrs KeyPairErrors
+	if !errors.As(err, &kpErrs) {
+		t.Fatalf("LoadAccount() = %v, want KeyPairErrors", err)
+	}
+	if len(kpErrs) != 2 || kpErrs[PermOwner] == nil || kpErrs[PermActive] == nil {
+		t.Fatalf("LoadAccount() reported %v, want both keypairs", kpErrs)
+	}
+	if !errors.Is(err, ErrInvalidKeyPair) {
+		t.Fatalf("LoadAccount() = %v, want %v", err, ErrInvalidKeyPair)
+	}
+}
+
+func TestVerifyPublicKey(t *testing.T) {
+	other := newTestKeyPair(t, KeyTypeEd25519).PubKey
+	for _, keyType := range []KeyType{KeyTypeEd25519, KeyTypeP256} {
+		t.Run(string(keyType), func(t *testing.T) {
+			k := newTestKeyPair(t, keyType)
+			if err := k.VerifyPublicKey(); err != nil {
+				t.Fatalf("VerifyPublicKey() = %v", err)
+			}
+			if err := k.EncryptWithParams([]byte("pw"), fastScrypt); err != nil {
+				t.Fatal(err)
+			}
+			if err := k.VerifyPublicKey(); !errors.Is(err, ErrStillEncrypted) {
+				t.Fatalf("VerifyPublicKey() on an encrypted keypair = %v, want %v", err, ErrStillEncrypted)
+			}
+			tampered := *k
+			tampered.PubKey = other
+			if err := k.Decrypt([]byte("pw")); err != nil {
+				t.Fatal(err)
+			}
+			if err := k.VerifyPublicKey(); err != nil {
+				t.Fatalf("VerifyPublicKey() after Decrypt = %v", err)
+			}
+			k.PubKey = other
+			if err := k.VerifyPublicKey(); !errors.Is(err, ErrPublicKeyMismatch) {
+				t.Fatalf("VerifyPublicKey() with a swapped public key = %v, want %v", err, ErrPublicKeyMismatch)
+			}
+			if err := tampered.Decrypt([]byte("pw")); err == nil {
+				t.Fatal("Decrypt accepted a swapped public key")
+			}
+		})
+	}
+	watchOnly, err := NewWatchOnlyKeyPair(other, "ed25519")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := watchOnly.VerifyPublicKey(); !errors.Is(err, ErrWatchOnly) {
+		t.Fatalf("VerifyPublicKey() on a watch-only keypair = %v, want %v", err, ErrWatchOnly)
+	}
+}
+
+func TestPermissions(t *testing.T) {
+	a := NewAccountInfo()
+	for _, perm := range []string{PermOwner, "custom", PermActive} {
+		if err := a.AddKeyPair(perm, newTestKeyPair(t, KeyTypeEd25519)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got, want := a.Permissions(), []string{PermActive, "custom", PermOwner}; fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Fatalf("Permissions() = %v, want %v", got, want)
+	}
+	for perm, want := range map[string]bool{PermOwner: true, PermActive: true, "custom": true, "": false, "Owner": false} {
+		if got := a.HasPermission(perm); got != want {
+			t.Fatalf("HasPermission(%q) = %v, want %v", perm, got, want)
+		}
+	}
+	if got := NewAccountInfo().Permissions(); len(got) != 0 {
+		t.Fatalf("empty account has permissions %v", got)
+	}
+}
+
+func TestAddRemoveKeyPair(t *testing.T) {
+	a := NewAccountInfo()
+	owner := newTestKeyPair(t, KeyTypeEd25519)
+	if err := a.AddKeyPair(PermOwner, owner, "cold"); err != nil {
+		t.Fatal(err)
+	}
+	if owner.Label != "cold" {
+		t.Fatalf("label %q, want cold", owner.Label)
+	}
+	derived := newTestKeyPair(t, KeyTypeP256)
+	wantPub := derived.PubKey
+	derived.PubKey = ""
+	if err := a.AddKeyPair(PermActive, derived); err != nil {
+		t.Fatal(err)
+	}
+	if derived.PubKey != wantPub {
+		t.Fatalf("derived public key %v, want %v", derived.PubKey, wantPub)
+	}
+	sameID := newTestKeyPair(t, KeyTypeEd25519)
+	sameID.ID = owner.ID
+	adds := []struct {
+		name    string
+		perm    string
+		kp      *KeyPairInfo
+		wantErr error
+	}{
+		{"duplicate permission", PermOwner, newTestKeyPair(t, KeyTypeEd25519), ErrPermissionExists},
+		{"duplicate id", "other", sameID, ErrDuplicateID},
+		{"invalid keypair", "other", &KeyPairInfo{KeyType: KeyTypeEd25519}, ErrInvalidKeyPair},
+	}
+	for _, tt := range adds {
+		if err := a.AddKeyPair(tt.perm, tt.kp); !errors.Is(err, tt.wantErr) {
+			t.Fatalf("%v: AddKeyPair() = %v, want %v", tt.name, err, tt.wantErr)
+		}
+	}
+	if a.Keypairs[PermOwner] != owner || a.HasPermission("other") {
+		t.Fatal("a rejected AddKeyPair changed the account")
+	}
+
+	removes := []struct {
+		name    string
+		remove  func(string) error
+		perm    string
+		wantErr error
+	}{
+		{"missing", a.RemoveKeyPair, "other", ErrInvalidPermission},
+		{"second to last", a.RemoveKeyPair, PermActive, nil},
+		{"last", a.RemoveKeyPair, PermOwner, ErrLastKeyPair},
+		{"forced last", a.ForceRemoveKeyPair, PermOwner, nil},
+	}
+	for _, tt := range removes {
+		err := tt.remove(tt.perm)
+		if !errors.Is(err, tt.wantErr) {
+			t.Fatalf("%v: removing %v = %v, want %v", tt.name, tt.perm, err, tt.wantErr)
+		}
+	}
+	if len(a.Keypairs) != 0 {
+		t.Fatalf("keypairs left: %v", a.Permissions())
+	}
+}
+
+func TestEncryptPermission(t *testing.T) {
+	a := NewAccountInfo()
+	a.Name = "alice"
+	for _, perm := range []string{PermOwner, PermActive} {
+		if err := a.AddKeyPair(perm, newTestKeyPair(t, KeyTypeEd25519)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	steps := []struct {
+		name          string
+		run           func() error
+		wantErr       error
+		owner, active bool
+	}{
+		{"plaintext", func() error { return nil }, nil, false, false},
+		{"lock owner", func() error { return a.EncryptPermission(PermOwner, []byte("owner")) }, nil, true, false},
+		{"lock owner again", func() error { return a.EncryptPermission(PermOwner, []byte("owner")) }, ErrAlreadyEncrypted, true, false},
+		{"unlock active", func() error { return a.DecryptPermission(PermActive, []byte("x")) }, ErrNotEncrypted, true, false},
+		{"missing permission", func() error { return a.EncryptPermission("missing", []byte("x")) }, ErrInvalidPermission, true, false},
+		{"lock active", func() error { return a.EncryptPermission(PermActive, []byte("active")) }, nil, true, true},
+		{"wrong password", func() error { return a.DecryptPermission(PermOwner, []byte("active")) }, ErrWrongPassword, true, true},
+		{"unlock owner", func() error { return a.DecryptPermission(PermOwner, []byte("owner")) }, nil, false, true},
+	}
+	for _, step := range steps {
+		if err := step.run(); !errors.Is(err, step.wantErr) {
+			t.Fatalf("%v: error = %v, want %v", step.name, err, step.wantErr)
+		}
+		owner, active := a.IsPermissionEncrypted(PermOwner), a.IsPermissionEncrypted(PermActive)
+		if owner != step.owner || active != step.active {
+			t.Fatalf("%v: owner/active encrypted = %v/%v, want %v/%v", step.name, owner, active, step.owner, step.active)
+		}
+		if want := step.owner || step.active; a.IsEncrypted() != want {
+			t.Fatalf("%v: IsEncrypted() = %v, want %v", step.name, a.IsEncrypted(), want)
+		}
+	}
+	if a.IsPermissionEncrypted("missing") {
+		t.Fatal("a missing permission reports encrypted")
+	}
+}
+
+func TestEncryptEachDecryptEach(t *testing.T) {
+	a := NewAccountInfo()
+	a.Name = "alice"
+	raw := map[string]string{}
+	for _, perm := range []string{PermOwner, PermActive} {
+		kp := newTestKeyPair(t, KeyTypeEd25519)
+		raw[perm] = kp.RawKey.Reveal()
+		if err := a.AddKeyPair(perm, kp); err != nil {
+			t.Fatal(err)
+		}
+	}
+	passwords := map[string][]byte{PermOwner: []byte("owner"), PermActive: []byte("active")}
+	if err := a.EncryptEach(map[string][]byte{PermOwner: []byte("owner")}); err == nil {
+		t.Fatal("EncryptEach succeeded without a password for every permission")
+	}
+	if a.IsEncrypted() {
+		t.Fatal("a rejected EncryptEach encrypted keypairs")
+	}
+	if err := a.EncryptEach(passwords); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.DecryptPermission(PermOwner, passwords[PermActive]); !errors.Is(err, ErrWrongPassword) {
+		t.Fatalf("owner opened with the active password: %v", err)
+	}
+
+	err := a.DecryptEach(map[string][]byte{PermOwner: []byte("wrong"), PermActive: []byte("active")})
+	var kpErrs KeyPairErrors
+	if !errors.As(err, &kpErrs) || len(kpErrs) != 1 || !errors.Is(kpErrs[PermOwner], ErrWrongPassword) {
+		t.Fatalf("DecryptEach() = %v, want only %v to fail", err, PermOwner)
+	}
+	if !a.IsPermissionEncrypted(PermOwner) || a.IsPermissionEncrypted(PermActive) {
+		t.Fatal("a wrong password affected the other keypair")
+	}
+	if a.Keypairs[PermActive].RawKey.Reveal() != raw[PermActive] {
+		t.Fatal("active key changed")
+	}
+	if err := a.DecryptPermission(PermOwner, passwords[PermOwner]); err != nil {
+		t.Fatal(err)
+	}
+	if a.Keypairs[PermOwner].RawKey.Reveal() != raw[PermOwner] {
+		t.Fatal("owner key changed")
+	}
+}
+
+func TestStrictDecode(t *testing.T) {
+	a := NewAccountInfo()
+	a.Name = "alice"
+	if err := a.AddKeyPair(PermOwner, newTestKeyPair(t, KeyTypeEd25519)); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name      string
+		change    func(doc map[string]interface{})
+		strictErr bool
+	}{
+		{"well-formed", func(map[string]interface{}) {}, false},
+		{"unknown account field", func(doc map[string]interface{}) { doc["nmae"] = "alice" }, true},
+		{"unknown keypair field", func(doc map[string]interface{}) {
+			doc["keypairs"].(map[string]interface{})[PermOwner].(map[string]interface{})["encyrpted_key"] = "abc"
+		}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewFileAccountStore(t.TempDir())
+			if err := s.Init(); err != nil {
+				t.Fatal(err)
+			}
+			doc := encodeAccount(t, a)
+			tt.change(doc)
+			data, err := json.Marshal(doc)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(s.path("alice.json"), data, 0600); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := s.LoadAccount("alice"); err != nil {
+				t.Fatalf("lenient load: %v", err)
+			}
+			s.StrictDecode = true
+			_, err = s.LoadAccount("alice")
+			if tt.strictErr != (err != nil) {
+				t.Fatalf("strict load error = %v, want error %v", err, tt.strictErr)
+			}
+		})
+	}
+}
+
+func TestRenameAccount(t *testing.T) {
+	tests := []struct {
+		name    string
+		setup   func(t *testing.T, s *FileAccountStore)
+		from    string
+		to      string
+		wantErr bool
+	}{
+		{"rename", func(*testing.T, *FileAccountStore) {}, "alice", "carol", false},
+		{"same name", func(*testing.T, *FileAccountStore) {}, "alice", "alice", true},
+		{"missing", func(*testing.T, *FileAccountStore) {}, "dave", "carol", true},
+		{"collision", func(*testing.T, *FileAccountStore) {}, "alice", "bob", true},
+		{"backup fails", func(t *testing.T, s *FileAccountStore) {
+			// a file where the backup directory should be
+			if err := os.WriteFile(s.path("backup"), nil, 0600); err != nil {
+				t.Fatal(err)
+			}
+		}, "alice", "carol", true},
+		{"bound to name", func(t *testing.T, s *FileAccountStore) {
+			a := NewAccountInfo()
+			a.Name = "alice"
+			if err := a.AddKeyPair(PermOwner, newTestKeyPair(t, KeyTypeEd25519)); err != nil {
+				t.Fatal(err)
+			}
+			if err := a.EncryptWithOptions([]byte("pw"), EncryptOptions{Scrypt: fastScrypt, Cipher: CipherAESGCM}); err != nil {
+				t.Fatal(err)
+			}
+			if err := s.SaveAccount(a); err != nil {
+				t.Fatal(err)
+			}
+		}, "alice", "carol", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewFileAccountStore(t.TempDir())
+			for _, name := range []string{"alice", "bob"} {
+				a := NewAccountInfo()
+				a.Name = name
+				if err := a.AddKeyPair(PermOwner, newTestKeyPair(t, KeyTypeEd25519)); err != nil {
+					t.Fatal(err)
+				}
+				if err := s.SaveAccount(a); err != nil {
+					t.Fatal(err)
+				}
+			}
+			tt.setup(t, s)
+			before, _ := s.LoadAccount(tt.from)
+			err := s.RenameAccount(tt.from, tt.to)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("RenameAccount succeeded")
+				}
+				if before != nil {
+					after, err := s.LoadAccount(tt.from)
+					if err != nil || after.Checksum != before.Checksum {
+						t.Fatalf("failed rename changed %v: %v", tt.from, err)
+					}
+				}
+				if tt.to != "bob" && tt.to != tt.from {
+					if ok, _ := s.HasAccount(tt.to); ok {
+						t.Fatalf("failed rename left %v behind", tt.to)
+					}
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ok, _ := s.HasAccount(tt.from); ok {
+				t.Fatalf("%v still exists", tt.from)
+			}
+			got, err := s.LoadAccount(tt.to)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Name != tt.to || got.Keypairs[PermOwner].PubKey != before.Keypairs[PermOwner].PubKey {
+				t.Fatalf("renamed account is %v with %v", got.Name, got.Keypairs[PermOwner].PubKey)
+			}
+			backups, err := os.ReadDir(s.path("backup"))
+			if err != nil || len(backups) != 1 || !strings.HasPrefix(backups[0].Name(), tt.from+".") {
+				t.Fatalf("backups %v, %v, want one of %v", backups, err, tt.from)
+			}
+		})
+	}
+}
+
+func TestHasAccount(t *testing.T) {
+	dir := t.TempDir()
+	s := NewFileAccountStore(dir)
+	writeTestAccounts(t, s, 1)
+	notDir := dir + "/plain"
+	if err := os.WriteFile(notDir, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name    string
+		dir     string
+		account string
+		want    bool
+		wantErr bool
+	}{
+		{"present", dir, "acc000", true, false},
+		{"absent", dir, "acc001", false, false},
+		{"missing directory", dir + "/missing", "acc000", false, false},
+		{"directory is a file", notDir, "acc000", false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewFileAccountStore(tt.dir).HasAccount(tt.account)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("HasAccount() error = %v, want error %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Fatalf("HasAccount() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestListAccountsSkipsNonKeystores(t *testing.T) {
+	s := NewFileAccountStore(t.TempDir())
+	writeTestAccounts(t, s, 2)
+	// saving again moves the first version into backup/
+	a, err := s.LoadAccount("acc000")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SaveAccount(a); err != nil {
+		t.Fatal(err)
+	}
+	for _, dir := range []string{"notes.json", "backup/nested"} {
+		if err := os.MkdirAll(s.path(dir), 0700); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for name, data := range map[string]string{"README": "hi", "README.md": "hi", "keys.txt": "{}"} {
+		if err := os.WriteFile(s.path(name), []byte(data), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	l := &captureLogger{}
+	SetLogger(l)
+	t.Cleanup(func() { SetLogger(nil) })
+	accs, err := s.ListAccounts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, a := range accs {
+		names = append(names, a.Name)
+	}
+	if got, want := fmt.Sprint(names), "[acc000 acc001]"; got != want {
+		t.Fatalf("listed %v, want %v", got, want)
+	}
+	if msgs := l.take(); len(msgs) != 0 {
+		t.Fatalf("ListAccounts logged %q", msgs)
+	}
+}
+
+//go:embed testdata/embedded
+var embeddedKeystores embed.FS
+
+func TestLoadAccountFromFS(t *testing.T) {
+	s := &FileAccountStore{AccountDir: "testdata/embedded", FS: embeddedKeystores}
+	a, err := s.LoadAccount("watcher")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Name != "watcher" || !a.Keypairs[PermOwner].IsWatchOnly() || a.Keypairs[PermOwner].Label != "watch only" {
+		t.Fatalf("loaded %+v", a)
+	}
+	accs, err := s.ListAccounts()
+	if err != nil || len(accs) != 1 {
+		t.Fatalf("ListAccounts() = %v, %v, want the embedded account", accs, err)
+	}
+	if ok, err := s.HasAccount("watcher"); !ok || err != nil {
+		t.Fatalf("HasAccount() = %v, %v", ok, err)
+	}
+	if _, err := s.LoadAccount("missing"); err == nil {
+		t.Fatal("loaded a missing account")
+	}
+	if err := s.SaveAccount(a); !errors.Is(err, ErrReadOnly) {
+		t.Fatalf("SaveAccount() on an fs.FS = %v, want %v", err, ErrReadOnly)
+	}
+	if err := s.DeleteAccount("watcher"); !errors.Is(err, ErrReadOnly) {
+		t.Fatalf("DeleteAccount() on an fs.FS = %v, want %v", err, ErrReadOnly)
+	}
+}
+
+func TestLoadAccountFromReader(t *testing.T) {
+	data, err := os.ReadFile("testdata/embedded/watcher.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, err := LoadAccount(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := LoadAccountFrom("testdata/embedded/watcher.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(a, b) {
+		t.Fatalf("LoadAccount() = %+v, LoadAccountFrom() = %+v", a, b)
+	}
+	for name, data := range map[string]string{"empty": "", "not json": "keystore", "truncated": string(data[:len(data)/2])} {
+		if _, err := LoadAccount(strings.NewReader(data)); err == nil {
+			t.Fatalf("%v: LoadAccount succeeded", name)
+		}
+	}
+}
+
+// shortWriter accepts only the first n bytes written to it.
+type shortWriter struct {
+	n int
+}
+
+func (w *shortWriter) Write(p []byte) (int, error) {
+	if len(p) > w.n {
+		n := w.n
+		w.n = 0
+		return n, io.ErrShortWrite
+	}
+	w.n -= len(p)
+	return len(p), nil
+}
+
+func TestAccountWriteTo(t *testing.T) {
+	a := NewAccountInfo()
+	a.Name = "alice"
+	for _, perm := range []string{PermOwner, PermActive} {
+		if err := a.AddKeyPair(perm, newTestKeyPair(t, KeyTypeEd25519)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var buf bytes.Buffer
+	n, err := a.WriteTo(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != int64(buf.Len()) {
+		t.Fatalf("WriteTo() = %v, wrote %v bytes", n, buf.Len())
+	}
+	first := buf.String()
+	got, err := LoadAccount(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Name != a.Name || got.Checksum != a.Checksum || got.Version != KeystoreVersion {
+		t.Fatalf("reloaded %v %v v%v, want %v %v v%v", got.Name, got.Checksum, got.Version, a.Name, a.Checksum, KeystoreVersion)
+	}
+	for _, perm := range a.Permissions() {
+		if got.Keypairs[perm].RawKey.Reveal() != a.Keypairs[perm].RawKey.Reveal() {
+			t.Fatalf("%v raw key did not survive", perm)
+		}
+	}
+
+	// the output is stable, and SaveTo writes the same bytes
+	buf.Reset()
+	if _, err := got.WriteTo(&buf); err != nil || buf.String() != first {
+		t.Fatalf("second WriteTo differs (%v):\n%s\nwant\n%s", err, buf.String(), first)
+	}
+	file := t.TempDir() + "/alice.json"
+	if err := a.SaveTo(file); err != nil {
+		t.Fatal(err)
+	}
+	if saved, err := os.ReadFile(file); err != nil || string(saved) != first {
+		t.Fatalf("SaveTo wrote %q (%v), want %q", saved, err, first)
+	}
+
+	w := &shortWriter{n: 10}
+	if n, err := a.WriteTo(w); err == nil || n != 10 {
+		t.Fatalf("WriteTo() into a short writer = %v, %v, want 10 and an error", n, err)
+	}
+}
+
+func TestAccountBytesDeterministic(t *testing.T) {
+	a := NewAccountInfo()
+	a.Name = "alice"
+	perms := []string{"zeta", PermOwner, "alpha", PermActive, "mid", "beta"}
+	for _, perm := range perms {
+		if err := a.AddKeyPair(perm, newTestKeyPair(t, KeyTypeEd25519)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	dir := t.TempDir()
+	var first []byte
+	for i := 0; i < 20; i++ {
+		file := fmt.Sprintf("%v/alice%v.json", dir, i)
+		if err := a.Clone().SaveTo(file); err != nil {
+			t.Fatal(err)
+		}
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if first == nil {
+			first = data
+		} else if !bytes.Equal(data, first) {
+			t.Fatalf("save %v differs:\n%s\nwant\n%s", i, data, first)
+		}
+	}
+	last := -1
+	for _, perm := range a.Permissions() {
+		i := bytes.Index(first, []byte(`"`+perm+`": {`))
+		if i < 0 || i < last {
+			t.Fatalf("keypair %v is missing or out of order", perm)
+		}
+		last = i
+	}
+}
+
+func TestStoreModes(t *testing.T) {
+	tests := []struct {
+		name              string
+		fileMode, dirMode os.FileMode
+		wantFile, wantDir os.FileMode
+		wantWarn          bool
+		wantErr           bool
+	}{
+		{"defaults", 0, 0, 0400, 0700, false, false},
+		{"owner read-write", 0600, 0700, 0600, 0700, false, false},
+		{"group readable", 0440, 0750, 0440, 0750, true, false},
+		{"world-writable file", 0602, 0700, 0, 0, false, true},
+		{"world-writable directory", 0600, 0703, 0, 0, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := &captureLogger{}
+			SetLogger(l)
+			t.Cleanup(func() { SetLogger(nil) })
+			s := NewFileAccountStore(t.TempDir() + "/accounts")
+			s.FileMode, s.DirMode = tt.fileMode, tt.dirMode
+			a := NewAccountInfo()
+			a.Name = "alice"
+			if err := a.AddKeyPair(PermOwner, newTestKeyPair(t, KeyTypeEd25519)); err != nil {
+				t.Fatal(err)
+			}
+			err := s.SaveAccount(a)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("SaveAccount succeeded")
+				}
+				if _, err := os.Stat(s.AccountDir); !os.IsNotExist(err) {
+					t.Fatalf("rejected modes still created the directory: %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			for path, want := range map[string]os.FileMode{s.path("alice.json"): tt.wantFile, s.AccountDir: tt.wantDir} {
+				fi, err := os.Stat(path)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got := fi.Mode().Perm(); got != want {
+					t.Fatalf("%v has mode %v, want %v", path, got, want)
+				}
+			}
+			warned := false
+			for _, msg := range l.take() {
+				warned = warned || strings.HasPrefix(msg, "warn: ")
+			}
+			if warned != tt.wantWarn {
+				t.Fatalf("warned = %v, want %v", warned, tt.wantWarn)
+			}
+		})
+	}
+}
+
+func TestLoadRejectsDuplicateIDs(t *testing.T) {
+	a := NewAccountInfo()
+	a.Name = "alice"
+	for _, perm := range []string{PermOwner, PermActive} {
+		if err := a.AddKeyPair(perm, newTestKeyPair(t, KeyTypeEd25519)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	a.Keypairs[PermActive].ID = a.Keypairs[PermOwner].ID
+	a.Checksum = a.computeChecksum()
+	data, err := json.Marshal(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = LoadAccount(bytes.NewReader(data))
+	if !errors.Is(err, ErrDuplicateID) {
+		t.Fatalf("LoadAccount() = %v, want %v", err, ErrDuplicateID)
+	}
+	if !strings.Contains(err.Error(), PermOwner) || !strings.Contains(err.Error(), PermActive) {
+		t.Fatalf("error %q does not name both permissions", err)
+	}
+}
+
+func TestKeyPairIDVersion(t *testing.T) {
+	for i := 0; i < 10; i++ {
+		id, err := uuid.Parse(newTestKeyPair(t, KeyTypeEd25519).ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if id.Version() != 4 || id.Variant() != uuid.RFC4122 {
+			t.Fatalf("id %v is version %v, want a random version 4 uuid", id, id.Version())
+		}
+	}
+	watchOnly, err := NewWatchOnlyKeyPair(newTestKeyPair(t, KeyTypeEd25519).PubKey, "ed25519")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fromSeed, err := keyPairFromSeed(make([]byte, 32), KeyTypeEd25519)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, kp := range []*KeyPairInfo{watchOnly, fromSeed} {
+		if id, err := uuid.Parse(kp.ID); err != nil || id.Version() != 4 {
+			t.Fatalf("id %v is not a version 4 uuid: %v", kp.ID, err)
+		}
+	}
+
+	// keystores written with version 1 ids still load
+	v1, err := uuid.NewUUID()
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := NewAccountInfo()
+	kp := newTestKeyPair(t, KeyTypeEd25519)
+	kp.ID = v1.String()
+	if err := a.AddKeyPair(PermOwner, kp); err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if _, err := a.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	got, err := LoadAccount(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Keypairs[PermOwner].ID != v1.String() {
+		t.Fatalf("loaded id %v, want the version 1 id %v", got.Keypairs[PermOwner].ID, v1)
+	}
+}
+
+func TestSaveAccountDryRun(t *testing.T) {
+	s := NewFileAccountStore(t.TempDir())
+	a := NewAccountInfo()
+	a.Name = "alice"
+	if err := a.AddKeyPair(PermOwner, newTestKeyPair(t, KeyTypeEd25519)); err != nil {
+		t.Fatal(err)
+	}
+	// listing names every path in the account directory
+	listing := func() string {
+		var names []string
+		filepath.WalkDir(s.AccountDir, func(path string, d fs.DirEntry, err error) error {
+			names = append(names, path)
+			return nil
+		})
+		return fmt.Sprint(names)
+	}
+
+	plan, err := s.SaveAccountDryRun(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := SavePlan{Path: s.AccountDir + "/alice.json"}
+	if plan != want {
+		t.Fatalf("plan for a new account = %+v, want %+v", plan, want)
+	}
+	if got := listing(); got != fmt.Sprint([]string{s.AccountDir}) {
+		t.Fatalf("dry run wrote %v", got)
+	}
+
+	if err := s.SaveAccount(a); err != nil {
+		t.Fatal(err)
+	}
+	before := listing()
+	plan, err = s.SaveAccountDryRun(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plan.Path != want.Path || !plan.Overwrite || !strings.HasPrefix(plan.BackupPath, s.AccountDir+"/backup/alice.") || !strings.HasSuffix(plan.BackupPath, ".json") {
+		t.Fatalf("plan for an existing account = %+v", plan)
+	}
+	if got := listing(); got != before {
+		t.Fatalf("dry run changed the directory from %v to %v", before, got)
+	}
+
+	s.FileMode = 0666
+	if _, err := s.SaveAccountDryRun(a); err == nil {
+		t.Fatal("dry run accepted a world-writable mode")
+	}
+}
+
+func TestEncryptWithKey(t *testing.T) {
+	tests := []struct {
+		name    string
+		key     []byte
+		wantErr error
+	}{
+		{"aes-128", bytes.Repeat([]byte{0x11}, 16), nil},
+		{"aes-256", bytes.Repeat([]byte{0x22}, 32), nil},
+		{"empty", nil, ErrInvalidKeySize},
+		{"24 bytes", make([]byte, 24), ErrInvalidKeySize},
+		{"64 bytes", make([]byte, 64), ErrInvalidKeySize},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k := newTestKeyPair(t, KeyTypeEd25519)
+			raw := k.RawKey.Reveal()
+			err := k.EncryptWithKey(tt.key)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("EncryptWithKey() = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				if k.IsEncrypted() || k.RawKey.Reveal() != raw {
+					t.Fatal("failed EncryptWithKey changed the keypair")
+				}
+				return
+			}
+			if k.KDF != KDFNone || k.ScryptParams != nil || k.KeySize != len(tt.key) || k.Mac == "" {
+				t.Fatalf("encrypted keypair %+v", k)
+			}
+			if err := k.Validate(); err != nil {
+				t.Fatal(err)
+			}
+
+			wrong := append([]byte{}, tt.key...)
+			wrong[0] ^= 1
+			if err := k.DecryptWithKey(wrong); !errors.Is(err, ErrWrongPassword) {
+				t.Fatalf("DecryptWithKey() with a wrong key = %v, want %v", err, ErrWrongPassword)
+			}
+			if err := k.DecryptWithKey(tt.key[:8]); !errors.Is(err, ErrInvalidKeySize) {
+				t.Fatalf("DecryptWithKey() with a short key = %v, want %v", err, ErrInvalidKeySize)
+			}
+			if err := k.Decrypt(tt.key); err == nil {
+				t.Fatal("Decrypt treated the key as a password")
+			}
+			if err := k.DecryptWithKey(tt.key); err != nil {
+				t.Fatal(err)
+			}
+			if k.RawKey.Reveal() != raw {
+				t.Fatal("DecryptWithKey gave another key")
+			}
+			if err := k.DecryptWithKey(tt.key); !errors.Is(err, ErrNotEncrypted) {
+				t.Fatalf("DecryptWithKey() twice = %v, want %v", err, ErrNotEncrypted)
+			}
+		})
+	}
+
+	k := newTestKeyPair(t, KeyTypeEd25519)
+	if err := k.EncryptWithParams([]byte("pw"), fastScrypt); err != nil {
+		t.Fatal(err)
+	}
+	if err := k.DecryptWithKey(make([]byte, 16)); err == nil {
+		t.Fatal("DecryptWithKey opened a password-protected keypair")
+	}
+}
+
+func TestEncryptionState(t *testing.T) {
+	watchOnly := func(t *testing.T) *KeyPairInfo {
+		kp, err := NewWatchOnlyKeyPair(newTestKeyPair(t, KeyTypeEd25519).PubKey, "ed25519")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return kp
+	}
+	encrypted := func(t *testing.T) *KeyPairInfo {
+		kp := newTestKeyPair(t, KeyTypeEd25519)
+		if err := kp.EncryptWithParams([]byte("pw"), fastScrypt); err != nil {
+			t.Fatal(err)
+		}
+		return kp
+	}
+	plain := func(t *testing.T) *KeyPairInfo { return newTestKeyPair(t, KeyTypeEd25519) }
+	tests := []struct {
+		name     string
+		keypairs []func(*testing.T) *KeyPairInfo
+		want     EncState
+	}{
+		{"empty", nil, StateAllPlaintext},
+		{"plaintext", []func(*testing.T) *KeyPairInfo{plain, plain}, StateAllPlaintext},
+		{"encrypted", []func(*testing.T) *KeyPairInfo{encrypted, encrypted}, StateAllEncrypted},
+		{"mixed", []func(*testing.T) *KeyPairInfo{encrypted, plain}, StateMixed},
+		{"encrypted and watch-only", []func(*testing.T) *KeyPairInfo{encrypted, watchOnly}, StateAllEncrypted},
+		{"watch-only", []func(*testing.T) *KeyPairInfo{watchOnly}, StateAllPlaintext},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := NewAccountInfo()
+			a.Name = "alice"
+			for i, kp := range tt.keypairs {
+				if err := a.AddKeyPair(fmt.Sprint("perm", i), kp(t)); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if got := a.EncryptionState(); got != tt.want {
+				t.Fatalf("EncryptionState() = %v, want %v", got, tt.want)
+			}
+			s := NewFileAccountStore(t.TempDir())
+			s.RejectMixed = true
+			err := s.SaveAccount(a)
+			if tt.want == StateMixed {
+				if !errors.Is(err, ErrMixedEncryption) {
+					t.Fatalf("SaveAccount() = %v, want %v", err, ErrMixedEncryption)
+				}
+				if ok, _ := s.HasAccount("alice"); ok {
+					t.Fatal("mixed account was written")
+				}
+				err = s.ForceSaveAccount(a)
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+	if got := fmt.Sprint(StateAllPlaintext, StateAllEncrypted, StateMixed); got != "plaintext encrypted mixed" {
+		t.Fatalf("state names %q", got)
+	}
+}
+
+func TestAccountTimestamps(t *testing.T) {
+	s := NewFileAccountStore(t.TempDir())
+	newAlice := func(t *testing.T) *AccountInfo {
+		a := NewAccountInfo()
+		a.Name = "alice"
+		if err := a.AddKeyPair(PermOwner, newTestKeyPair(t, KeyTypeEd25519)); err != nil {
+			t.Fatal(err)
+		}
+		return a
+	}
+	before := time.Now().UTC().Truncate(time.Second)
+	a := newAlice(t)
+	if err := s.SaveAccount(a); err != nil {
+		t.Fatal(err)
+	}
+	created, err := time.Parse(time.RFC3339, a.CreatedAt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if created.Before(before) || a.UpdatedAt != a.CreatedAt {
+		t.Fatalf("first save: created %v, updated %v", a.CreatedAt, a.UpdatedAt)
+	}
+
+	// pretend the keystore on disk is years old
+	const old = "2020-01-02T03:04:05Z"
+	a.CreatedAt, a.UpdatedAt = old, old
+	fileName := s.AccountDir + "/alice" + s.ext()
+	if err := s.writeAccount(a, fileName, 0600); err != nil {
+		t.Fatal(err)
+	}
+	// a fresh value without timestamps still keeps the creation time
+	b := newAlice(t)
+	if err := s.SaveAccount(b); err != nil {
+		t.Fatal(err)
+	}
+	got, err := s.LoadAccount("alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.CreatedAt != old || b.CreatedAt != old {
+		t.Fatalf("CreatedAt = %v (saved value %v), want %v", got.CreatedAt, b.CreatedAt, old)
+	}
+	if got.UpdatedAt <= old || got.UpdatedAt != b.UpdatedAt {
+		t.Fatalf("UpdatedAt = %v (saved value %v), want later than %v", got.UpdatedAt, b.UpdatedAt, old)
+	}
+}
+
+func TestListAccountsSorted(t *testing.T) {
+	s := NewFileAccountStore(t.TempDir())
+	if err := os.MkdirAll(s.AccountDir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	for _, acc := range []struct{ name, created, updated string }{
+		{"alice", "2021-01-01T00:00:00Z", "2023-01-01T00:00:00Z"},
+		{"bob", "2020-01-01T00:00:00Z", "2024-01-01T00:00:00Z"},
+		{"carol", "2022-01-01T00:00:00Z", "2022-06-01T00:00:00Z"},
+		{"dave", "2021-01-01T00:00:00Z", "2022-06-01T00:00:00Z"},
+		{"legacy", "", ""},
+	} {
+		a := NewAccountInfo()
+		a.Name = acc.name
+		a.CreatedAt, a.UpdatedAt = acc.created, acc.updated
+		if err := a.AddKeyPair(PermOwner, newTestKeyPair(t, KeyTypeEd25519)); err != nil {
+			t.Fatal(err)
+		}
+		if err := s.writeAccount(a, s.AccountDir+"/"+a.Name+s.ext(), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tests := []struct {
+		name  string
+		order AccountOrder
+		want  []string
+	}{
+		{"by name", ByName, []string{"alice", "bob", "carol", "dave", "legacy"}},
+		{"by created", ByCreatedAt, []string{"legacy", "bob", "alice", "dave", "carol"}},
+		{"by updated", ByUpdatedAt, []string{"legacy", "carol", "dave", "alice", "bob"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			accs, err := s.ListAccountsSorted(tt.order)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, a := range accs {
+				got = append(got, a.Name)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("order = %v, want %v", got, tt.want)
+			}
+		})
+	}
+	if _, err := s.ListAccountsSorted(AccountOrder(42)); err == nil {
+		t.Fatal("unknown order accepted")
+	}
+}
+
+func TestVerifyPassword(t *testing.T) {
+	marshal := func(t *testing.T, kp *KeyPairInfo) string {
+		data, err := json.Marshal(kp)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+	ciphers := []struct {
+		name string
+		opts EncryptOptions
+	}{
+		{"aes-ctr", EncryptOptions{Scrypt: fastScrypt}},
+		{"aes-gcm", EncryptOptions{KeySize: 32, Scrypt: fastScrypt, Cipher: CipherAESGCM, AAD: []byte("aad")}},
+		{"argon2id", EncryptOptions{KDF: KDFArgon2id, Argon2: Argon2Params{Time: 1, Memory: 1024, Threads: 1}}},
+	}
+	tests := []struct {
+		name     string
+		password string
+		corrupt  func(kp *KeyPairInfo)
+		want     bool
+		wantErr  error
+	}{
+		{"right password", "pw", nil, true, nil},
+		{"wrong password", "wrong", nil, false, nil},
+		{"corrupt salt", "pw", func(kp *KeyPairInfo) { kp.Salt = "0OIl" }, false, ErrCorruptKeystore},
+	}
+	for _, c := range ciphers {
+		for _, tt := range tests {
+			t.Run(c.name+"/"+tt.name, func(t *testing.T) {
+				kp := newTestKeyPair(t, KeyTypeEd25519)
+				if err := kp.EncryptWithOptions([]byte("pw"), c.opts); err != nil {
+					t.Fatal(err)
+				}
+				if tt.corrupt != nil {
+					tt.corrupt(kp)
+				}
+				before := marshal(t, kp)
+				ok, err := kp.VerifyPasswordWithAAD([]byte(tt.password), c.opts.AAD)
+				if ok != tt.want || !errors.Is(err, tt.wantErr) {
+					t.Fatalf("VerifyPassword() = %v, %v, want %v, %v", ok, err, tt.want, tt.wantErr)
+				}
+				if after := marshal(t, kp); after != before || !kp.RawKey.IsEmpty() {
+					t.Fatalf("VerifyPassword changed the keypair from %v to %v", before, after)
+				}
+			})
+		}
+	}
+	if _, err := newTestKeyPair(t, KeyTypeEd25519).VerifyPassword([]byte("pw")); !errors.Is(err, ErrNotEncrypted) {
+		t.Fatalf("VerifyPassword() on a plaintext keypair = %v, want %v", err, ErrNotEncrypted)
+	}
+}
+
+func TestAccountVerifyPassword(t *testing.T) {
+	tests := []struct {
+		name      string
+		passwords []string
+		want      bool
+		wantErr   error
+	}{
+		{"all match", []string{"pw", "pw"}, true, nil},
+		{"one differs", []string{"pw", "other"}, false, nil},
+		{"plaintext", nil, false, ErrNotEncrypted},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := NewAccountInfo()
+			a.Name = "alice"
+			for i, perm := range []string{PermOwner, PermActive} {
+				kp := newTestKeyPair(t, KeyTypeEd25519)
+				if tt.passwords != nil {
+					if err := kp.EncryptWithParams([]byte(tt.passwords[i]), fastScrypt); err != nil {
+						t.Fatal(err)
+					}
+				}
+				if err := a.AddKeyPair(perm, kp); err != nil {
+					t.Fatal(err)
+				}
+			}
+			before := encodeAccount(t, a)
+			ok, err := a.VerifyPassword([]byte("pw"))
+			if ok != tt.want || !errors.Is(err, tt.wantErr) {
+				t.Fatalf("VerifyPassword() = %v, %v, want %v, %v", ok, err, tt.want, tt.wantErr)
+			}
+			if after := encodeAccount(t, a); !reflect.DeepEqual(after, before) {
+				t.Fatal("VerifyPassword changed the account")
+			}
+		})
+	}
+}
+
+func TestStoreNotInitialized(t *testing.T) {
+	s := NewFileAccountStore(filepath.Join(t.TempDir(), "accounts"))
+	tests := []struct {
+		name string
+		run  func() error
+	}{
+		{"ListAccounts", func() error { _, err := s.ListAccounts(); return err }},
+		{"ListAccountsContext", func() error { _, err := s.ListAccountsContext(context.Background()); return err }},
+		{"ListAccountsSorted", func() error { _, err := s.ListAccountsSorted(ByCreatedAt); return err }},
+		{"LoadByAddress", func() error { _, err := s.LoadByAddress(AddressFromPublicKey(make([]byte, 32))); return err }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.run()
+			if !errors.Is(err, ErrStoreNotInitialized) || !errors.Is(err, fs.ErrNotExist) {
+				t.Fatalf("error = %v, want %v wrapping %v", err, ErrStoreNotInitialized, fs.ErrNotExist)
+			}
+		})
+	}
+
+	s.DirMode = 0750
+	for i := 0; i < 2; i++ {
+		if err := s.Init(); err != nil {
+			t.Fatalf("Init() #%v: %v", i+1, err)
+		}
+	}
+	info, err := os.Stat(s.AccountDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !info.IsDir() || info.Mode().Perm() != 0750 {
+		t.Fatalf("Init created %v", info.Mode())
+	}
+	if accs, err := s.ListAccounts(); err != nil || len(accs) != 0 {
+		t.Fatalf("ListAccounts() after Init = %v, %v", accs, err)
+	}
+
+	s = NewFileAccountStore(".")
+	s.FS = os.DirFS(t.TempDir())
+	if err := s.Init(); !errors.Is(err, ErrReadOnly) {
+		t.Fatalf("Init() on an fs.FS = %v, want %v", err, ErrReadOnly)
+	}
+}
+
+func TestKeyPairLabel(t *testing.T) {
+	tests := []struct {
+		name string
+		opts *EncryptOptions
+	}{
+		{"aes-ctr", &EncryptOptions{Scrypt: fastScrypt}},
+		{"aes-gcm", &EncryptOptions{Scrypt: fastScrypt, Cipher: CipherAESGCM}},
+		{"plain", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewFileAccountStore(t.TempDir())
+			a := NewAccountInfo()
+			a.Name = "alice"
+			if err := a.AddKeyPair(PermOwner, newTestKeyPair(t, KeyTypeEd25519), "YubiKey 2023"); err != nil {
+				t.Fatal(err)
+			}
+			if tt.opts != nil {
+				if err := a.EncryptWithOptions([]byte("pw"), *tt.opts); err != nil {
+					t.Fatal(err)
+				}
+			}
+			sealed := a.Keypairs[PermOwner].Clone()
+			if err := s.SaveAccount(a); err != nil {
+				t.Fatal(err)
+			}
+			got, err := s.LoadAccount("alice")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if label := got.Keypairs[PermOwner].Label; label != "YubiKey 2023" {
+				t.Fatalf("label after loading = %q", label)
+			}
+
+			// relabelling leaves the key material alone
+			got.Keypairs[PermOwner].Label = "YubiKey 2024"
+			if err := s.SaveAccount(got); err != nil {
+				t.Fatal(err)
+			}
+			if got, err = s.LoadAccount("alice"); err != nil {
+				t.Fatal(err)
+			}
+			kp := got.Keypairs[PermOwner]
+			if kp.EncryptedKey != sealed.EncryptedKey || kp.Mac != sealed.Mac || kp.Salt != sealed.Salt {
+				t.Fatal("relabelling changed the sealed key")
+			}
+			if tt.opts != nil {
+				if err := got.Decrypt([]byte("pw")); err != nil {
+					t.Fatalf("decrypting a relabelled keypair: %v", err)
+				}
+			}
+			if kp.Label != "YubiKey 2024" {
+				t.Fatalf("label after decrypting = %q", kp.Label)
+			}
+		})
+	}
+	if err := NewAccountInfo().AddKeyPair(PermOwner, newTestKeyPair(t, KeyTypeEd25519), "a", "b"); err == nil {
+		t.Fatal("AddKeyPair took two labels")
+	}
+}
+
+func TestOrderedKeypairs(t *testing.T) {
+	tests := []struct {
+		name  string
+		perms []string
+		want  []string
+	}{
+		{"empty", nil, []string{}},
+		{"one", []string{PermOwner}, []string{PermOwner}},
+		{"many", []string{PermOwner, "zeta", "custom", PermActive, "alpha"}, []string{PermActive, "alpha", "custom", PermOwner, "zeta"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := NewAccountInfo()
+			for _, perm := range tt.perms {
+				if err := a.AddKeyPair(perm, newTestKeyPair(t, KeyTypeEd25519)); err != nil {
+					t.Fatal(err)
+				}
+			}
+			for i := 0; i < 20; i++ {
+				kps := a.OrderedKeypairs()
+				got := make([]string, len(kps))
+				for j, kp := range kps {
+					got[j] = kp.Perm
+					if kp.KeyPair != a.Keypairs[kp.Perm] {
+						t.Fatalf("%v paired with another permission's keypair", kp.Perm)
+					}
+				}
+				if !reflect.DeepEqual(got, tt.want) {
+					t.Fatalf("call %v: OrderedKeypairs() = %v, want %v", i, got, tt.want)
+				}
+			}
+		})
+	}
+}
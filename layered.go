@@ -0,0 +1,132 @@
+package sdk
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// LayeredStore combines several AccountStores, e.g. a user's own keystore
+// directory over a shared read-only one. Layers are searched in order and
+// an account in an earlier layer hides one of the same name further down,
+// so the writable layer usually goes first.
+type LayeredStore struct {
+	layers []AccountStore
+}
+
+func NewLayeredStore(layers ...AccountStore) *LayeredStore {
+	return &LayeredStore{layers: layers}
+}
+
+// accountChecker is implemented by stores that can tell whether they hold
+// an account without loading it.
+type accountChecker interface {
+	HasAccount(name string) (bool, error)
+}
+
+// holds reports whether layer i holds name. known is false for layers
+// that cannot say.
+func (s *LayeredStore) holds(i int, name string) (known, has bool, err error) {
+	l := s.layers[i]
+	if ro, ok := l.(readOnlyStore); ok {
+		l = ro.AccountStore
+	}
+	c, ok := l.(accountChecker)
+	if !ok {
+		return false, false, nil
+	}
+	has, err = c.HasAccount(name)
+	return true, has, err
+}
+
+// find returns the first layer holding name, or -1.
+func (s *LayeredStore) find(name string) (int, error) {
+	for i, l := range s.layers {
+		known, has, err := s.holds(i, name)
+		if err != nil {
+			return -1, fmt.Errorf("layer %v: %v", i, err)
+		}
+		if has {
+			return i, nil
+		}
+		if !known {
+			if _, err := l.LoadAccount(name); err == nil {
+				return i, nil
+			}
+		}
+	}
+	return -1, nil
+}
+
+// LoadAccount loads name from the first layer holding it. A keystore that
+// fails to load there is an error, not a reason to look further down.
+func (s *LayeredStore) LoadAccount(name string) (*AccountInfo, error) {
+	i, err := s.find(name)
+	if err != nil {
+		return nil, err
+	}
+	if i < 0 {
+		return nil, fmt.Errorf("account %v not found in any of %v layers", name, len(s.layers))
+	}
+	return s.layers[i].LoadAccount(name)
+}
+
+// SaveAccount writes to the first layer that does not refuse with
+// ErrReadOnly. Saving an account that a read-only layer above it holds
+// fails, as the saved copy would stay hidden.
+func (s *LayeredStore) SaveAccount(a *AccountInfo) error {
+	holder, err := s.find(a.Name)
+	if err != nil {
+		return err
+	}
+	for i, l := range s.layers {
+		err := l.SaveAccount(a)
+		if errors.Is(err, ErrReadOnly) {
+			if i == holder {
+				return fmt.Errorf("account %v is held by read-only layer %v: %w", a.Name, i, err)
+			}
+			continue
+		}
+		return err
+	}
+	return fmt.Errorf("%w: no writable layer", ErrReadOnly)
+}
+
+// DeleteAccount deletes name from the layer it is loaded from. An account
+// of a read-only layer cannot be deleted; one hidden below is left alone
+// and becomes visible.
+func (s *LayeredStore) DeleteAccount(name string) error {
+	i, err := s.find(name)
+	if err != nil {
+		return err
+	}
+	if i < 0 {
+		return fmt.Errorf("account %v not found in any of %v layers", name, len(s.layers))
+	}
+	return s.layers[i].DeleteAccount(name)
+}
+
+// ListAccounts merges the accounts of all layers by name, an earlier layer
+// winning over later ones, sorted by name. Layers whose directory does not
+// exist yet are skipped.
+func (s *LayeredStore) ListAccounts() ([]*AccountInfo, error) {
+	seen := make(map[string]bool)
+	accs := make([]*AccountInfo, 0)
+	for i, l := range s.layers {
+		list, err := l.ListAccounts()
+		if errors.Is(err, ErrStoreNotInitialized) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("layer %v: %w", i, err)
+		}
+		for _, a := range list {
+			if !seen[a.Name] {
+				seen[a.Name] = true
+				accs = append(accs, a)
+			}
+		}
+	}
+	sort.Slice(accs, func(i, j int) bool { return accs[i].Name < accs[j].Name })
+	return accs, nil
+}
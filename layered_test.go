@@ -0,0 +1,109 @@
+package sdk
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// saveNote saves account name to s with note, which tells copies of the
+// same account apart.
+func saveNote(t *testing.T, s AccountStore, name, note string) {
+	t.Helper()
+	a := NewAccountInfo()
+	a.Name = name
+	a.Note = note
+	if err := a.AddKeyPair(PermOwner, newTestKeyPair(t, KeyTypeEd25519)); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SaveAccount(a); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLayeredStore(t *testing.T) {
+	base := NewFileAccountStore(t.TempDir())
+	saveNote(t, base, "alice", "base")
+	saveNote(t, base, "bob", "base")
+	overlay := NewMemoryAccountStore()
+	saveNote(t, overlay, "bob", "overlay")
+	s := NewLayeredStore(overlay, ReadOnlyStore(base))
+
+	lookups := []struct {
+		name string
+		want string
+	}{
+		{"alice", "base"},
+		{"bob", "overlay"},
+	}
+	for _, tt := range lookups {
+		a, err := s.LoadAccount(tt.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if a.Note != tt.want {
+			t.Fatalf("%v loaded from %v, want %v", tt.name, a.Note, tt.want)
+		}
+	}
+	if _, err := s.LoadAccount("carol"); err == nil {
+		t.Fatal("loaded an account no layer holds")
+	}
+	accs, err := s.ListAccounts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, a := range accs {
+		got = append(got, a.Name+"/"+a.Note)
+	}
+	if want := []string{"alice/base", "bob/overlay"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("ListAccounts() = %v, want %v", got, want)
+	}
+
+	// writes go to the overlay and hide the base copy
+	saveNote(t, s, "carol", "new")
+	saveNote(t, s, "alice", "changed")
+	for name, note := range map[string]string{"alice": "changed", "carol": "new"} {
+		if a, err := overlay.LoadAccount(name); err != nil || a.Note != note {
+			t.Fatalf("%v not written to the overlay: %v", name, err)
+		}
+	}
+	if a, err := base.LoadAccount("alice"); err != nil || a.Note != "base" {
+		t.Fatalf("saving changed the read-only layer: %v", err)
+	}
+
+	// deleting the overlay copy uncovers the base one
+	if err := s.DeleteAccount("bob"); err != nil {
+		t.Fatal(err)
+	}
+	if a, err := s.LoadAccount("bob"); err != nil || a.Note != "base" {
+		t.Fatalf("bob after deleting the overlay copy = %v, %v", a, err)
+	}
+	if err := s.DeleteAccount("bob"); !errors.Is(err, ErrReadOnly) {
+		t.Fatalf("deleting from the read-only layer = %v, want ErrReadOnly", err)
+	}
+}
+
+func TestLayeredStoreReadOnlyOnTop(t *testing.T) {
+	shared := NewFileAccountStore(t.TempDir())
+	saveNote(t, shared, "alice", "shared")
+	user := NewFileAccountStore(filepath.Join(t.TempDir(), "missing"))
+	s := NewLayeredStore(ReadOnlyStore(shared), user)
+
+	// a layer that is not initialized yet adds nothing
+	if accs, err := s.ListAccounts(); err != nil || len(accs) != 1 {
+		t.Fatalf("ListAccounts() = %v, %v, want alice only", accs, err)
+	}
+	a := NewAccountInfo()
+	a.Name = "alice"
+	if err := a.AddKeyPair(PermOwner, newTestKeyPair(t, KeyTypeEd25519)); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SaveAccount(a); !errors.Is(err, ErrReadOnly) {
+		t.Fatalf("saving an account the read-only top layer holds = %v, want ErrReadOnly", err)
+	}
+	if err := NewLayeredStore(ReadOnlyStore(shared)).SaveAccount(a); !errors.Is(err, ErrReadOnly) {
+		t.Fatalf("saving without a writable layer = %v, want ErrReadOnly", err)
+	}
+}
@@ -0,0 +1,56 @@
+package sdk
+
+import (
+	"fmt"
+	"io"
+)
+
+// MaxKeystoreSize bounds the keystores the loaders accept, in bytes, and
+// MaxKeyPairFieldSize the encoded length of each key, salt and MAC of a
+// keypair. Keys are tens of bytes, so both leave ample room while keeping
+// untrusted input from forcing large allocations.
+var (
+	MaxKeystoreSize     = 1 << 20
+	MaxKeyPairFieldSize = 4096
+)
+
+// readKeystore reads all of r, failing with ErrKeystoreTooLarge past
+// MaxKeystoreSize.
+func readKeystore(r io.Reader, source string) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, int64(MaxKeystoreSize)+1))
+	if err != nil {
+		return nil, err
+	}
+	if err := checkKeystoreSize(len(data), source); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func checkKeystoreSize(n int, source string) error {
+	if n > MaxKeystoreSize {
+		return fmt.Errorf("%w: keystore %v exceeds %v bytes", ErrKeystoreTooLarge, source, MaxKeystoreSize)
+	}
+	return nil
+}
+
+// checkSizes rejects oversized fields before they are decoded or fed to
+// any cipher.
+func (k *KeyPairInfo) checkSizes() error {
+	fields := map[string]int{
+		"raw_key":       len(k.RawKey.b),
+		"encrypted_key": len(k.EncryptedKey),
+		"salt":          len(k.Salt),
+		"mac":           len(k.Mac),
+		"public_key":    len(k.PubKey),
+		"label":         len(k.Label),
+		"wrapper":       len(k.Wrapper),
+		"wrapped_key":   len(k.WrappedKey),
+	}
+	for name, n := range fields {
+		if n > MaxKeyPairFieldSize {
+			return fmt.Errorf("%w: %v is %v bytes, limit is %v", ErrKeystoreTooLarge, name, n, MaxKeyPairFieldSize)
+		}
+	}
+	return nil
+}
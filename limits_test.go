@@ -0,0 +1,84 @@
+package sdk
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDecryptChecksSizesFirst(t *testing.T) {
+	huge := strings.Repeat("2", MaxKeyPairFieldSize+1)
+	tests := []struct {
+		field string
+		set   func(kp *KeyPairInfo)
+	}{
+		{"raw_key", func(kp *KeyPairInfo) { kp.RawKey = NewSecretKey(huge) }},
+		{"encrypted_key", func(kp *KeyPairInfo) { kp.EncryptedKey = huge }},
+		{"salt", func(kp *KeyPairInfo) { kp.Salt = huge }},
+		{"mac", func(kp *KeyPairInfo) { kp.Mac = huge }},
+		{"public_key", func(kp *KeyPairInfo) { kp.PubKey = huge }},
+		{"label", func(kp *KeyPairInfo) { kp.Label = huge }},
+		{"wrapped_key", func(kp *KeyPairInfo) { kp.WrappedKey = huge }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.field, func(t *testing.T) {
+			kp := newTestKeyPair(t, KeyTypeEd25519)
+			if err := kp.EncryptWithParams([]byte("pw"), fastScrypt); err != nil {
+				t.Fatal(err)
+			}
+			// decoding the salt would fail if sizes were not checked first
+			if tt.field != "salt" {
+				kp.Salt = "0OIl"
+			}
+			tt.set(kp)
+			err := kp.Decrypt([]byte("pw"))
+			if !errors.Is(err, ErrKeystoreTooLarge) || !strings.Contains(err.Error(), tt.field) {
+				t.Fatalf("Decrypt() = %v, want %v naming %v", err, ErrKeystoreTooLarge, tt.field)
+			}
+		})
+	}
+}
+
+func TestLoadAccountLimits(t *testing.T) {
+	a := NewAccountInfo()
+	a.Name = "alice"
+	if err := a.AddKeyPair(PermOwner, newTestKeyPair(t, KeyTypeEd25519)); err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if _, err := a.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	keystore := buf.String()
+	tests := []struct {
+		name  string
+		data  string
+		field int
+	}{
+		{"oversized keystore", keystore + strings.Repeat(" ", MaxKeystoreSize), MaxKeyPairFieldSize},
+		{"oversized label", strings.Replace(keystore, `"key_type"`, `"label": "`+strings.Repeat("x", MaxKeyPairFieldSize+1)+`", "key_type"`, 1), MaxKeyPairFieldSize},
+		{"lowered field limit", keystore, 8},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func(n int) { MaxKeyPairFieldSize = n }(MaxKeyPairFieldSize)
+			MaxKeyPairFieldSize = tt.field
+			if _, err := LoadAccount(strings.NewReader(tt.data)); !errors.Is(err, ErrKeystoreTooLarge) {
+				t.Fatalf("LoadAccount() = %v, want %v", err, ErrKeystoreTooLarge)
+			}
+			file := filepath.Join(t.TempDir(), "alice.json")
+			if err := os.WriteFile(file, []byte(tt.data), 0600); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := LoadAccountFrom(file); !errors.Is(err, ErrKeystoreTooLarge) {
+				t.Fatalf("LoadAccountFrom() = %v, want %v", err, ErrKeystoreTooLarge)
+			}
+		})
+	}
+	if _, err := LoadAccount(strings.NewReader(keystore)); err != nil {
+		t.Fatalf("keystore within the limits: %v", err)
+	}
+}
@@ -0,0 +1,152 @@
+package sdk
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"time"
+)
+
+// DefaultLockTimeout is how long writes wait for another process's lock.
+const DefaultLockTimeout = 5 * time.Second
+
+// lockFileName lives in the account directory. Being a dotfile, it is never
+// taken for an account.
+const lockFileName = ".lock"
+
+// LockInfo describes the process holding a store's lock file.
+type LockInfo struct {
+	PID  int       `json:"pid"`
+	Host string    `json:"host"`
+	Time time.Time `json:"time"`
+}
+
+// Stale reports whether the lock was left behind by a process on this host
+// that no longer runs. Locks of other hosts are never reported stale.
+func (l *LockInfo) Stale() bool {
+	host, _ := os.Hostname()
+	return l.Host == host && !processAlive(l.PID)
+}
+
+// TryLock takes the store's lock file without waiting, failing with
+// ErrLocked if another process holds it. Writes made while it is held
+// join that hold instead of waiting, so several of them run as one unit. Within a
+// process the lock is shared and counted; each TryLock needs an Unlock.
+func (s *FileAccountStore) TryLock() error {
+	return s.acquire(-1)
+}
+
+// Unlock releases a lock taken by TryLock, removing the lock file once no
+// holder in this process is left.
+func (s *FileAccountStore) Unlock() error {
+	s.procMu.Lock()
+	defer s.procMu.Unlock()
+	if s.procLocks == 0 {
+		return fmt.Errorf("account store %v is not locked", s.AccountDir)
+	}
+	s.procLocks--
+	if s.procLocks > 0 {
+		return nil
+	}
+	return os.Remove(s.AccountDir + "/" + lockFileName)
+}
+
+// LockHolder returns who holds the lock file, or nil if nobody does.
+func (s *FileAccountStore) LockHolder() (*LockInfo, error) {
+	data, err := os.ReadFile(s.AccountDir + "/" + lockFileName)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var info LockInfo
+	if err := json.Unmarshal(data, &info); err != nil {
+		return nil, fmt.Errorf("unreadable lock file in %v: %v", s.AccountDir, err)
+	}
+	return &info, nil
+}
+
+// BreakStaleLock removes a lock file whose holder is Stale. A lock that
+// may still be in use is left alone.
+func (s *FileAccountStore) BreakStaleLock() error {
+	info, err := s.LockHolder()
+	if err != nil || info == nil {
+		return err
+	}
+	if !info.Stale() {
+		return fmt.Errorf("%w: pid %v on %v may still be running", ErrLocked, info.PID, info.Host)
+	}
+	currentLogger().Warnf("removing stale lock of pid %v from %v in %v", info.PID, info.Time.Format(time.RFC3339), s.AccountDir)
+	return os.Remove(s.AccountDir + "/" + lockFileName)
+}
+
+// acquire takes the lock file, or joins this process's hold on it, waiting
+// up to timeout for another process to let go.
+func (s *FileAccountStore) acquire(timeout time.Duration) error {
+	if err := s.writable(); err != nil {
+		return err
+	}
+	s.procMu.Lock()
+	defer s.procMu.Unlock()
+	if s.procLocks > 0 {
+		s.procLocks++
+		return nil
+	}
+	if timeout == 0 {
+		timeout = DefaultLockTimeout
+	}
+	deadline := time.Now().Add(timeout)
+	for {
+		err := s.createLockFile()
+		if err == nil {
+			s.procLocks = 1
+			return nil
+		}
+		if !errors.Is(err, fs.ErrExist) {
+			return err
+		}
+		if time.Now().After(deadline) {
+			return s.lockedError()
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+func (s *FileAccountStore) createLockFile() error {
+	_, dirMode, err := s.modes()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(s.AccountDir, dirMode); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(s.AccountDir+"/"+lockFileName, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+	host, _ := os.Hostname()
+	err = json.NewEncoder(f).Encode(LockInfo{PID: os.Getpid(), Host: host, Time: time.Now().UTC()})
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(f.Name())
+	}
+	return err
+}
+
+func (s *FileAccountStore) lockedError() error {
+	info, err := s.LockHolder()
+	switch {
+	case err != nil:
+		return fmt.Errorf("%w: %v", ErrLocked, err)
+	case info == nil:
+		return ErrLocked
+	case info.Stale():
+		return fmt.Errorf("%w: pid %v is gone, BreakStaleLock removes its lock", ErrLocked, info.PID)
+	}
+	return fmt.Errorf("%w: held by pid %v on %v since %v", ErrLocked, info.PID, info.Host, info.Time.Format(time.RFC3339))
+}
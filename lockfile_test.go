@@ -0,0 +1,161 @@
+package sdk
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+)
+
+// errNotLocked stands for Unlock's error when no lock is held
+var errNotLocked = errors.New("not locked")
+
+func TestLockFile(t *testing.T) {
+	dir := t.TempDir()
+	first, second := NewFileAccountStore(dir), NewFileAccountStore(dir)
+	second.LockTimeout = 100 * time.Millisecond
+	a := NewAccountInfo()
+	a.Name = "alice"
+	if err := a.AddKeyPair(PermOwner, newTestKeyPair(t, KeyTypeEd25519)); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := first.TryLock(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	holder, err := second.LockHolder()
+	if err != nil || holder == nil || holder.PID != os.Getpid() || holder.Stale() {
+		t.Fatalf("LockHolder() = %+v, %v", holder, err)
+	}
+	steps := []struct {
+		name    string
+		run     func() error
+		wantErr error
+	}{
+		{"second TryLock", second.TryLock, ErrLocked},
+		{"second save times out", func() error { return second.SaveAccount(a) }, ErrLocked},
+		{"holder saves", func() error { return first.SaveAccount(a) }, nil},
+		{"one unlock of two", first.Unlock, nil},
+		{"still locked", second.TryLock, ErrLocked},
+		{"last unlock", first.Unlock, nil},
+		{"extra unlock", first.Unlock, errNotLocked},
+		{"second TryLock after release", second.TryLock, nil},
+		{"first TryLock", first.TryLock, ErrLocked},
+		{"second unlock", second.Unlock, nil},
+	}
+	for _, step := range steps {
+		start := time.Now()
+		err := step.run()
+		switch {
+		case step.wantErr == ErrLocked:
+			if !errors.Is(err, ErrLocked) || !strings.Contains(err.Error(), "held by pid") {
+				t.Fatalf("%v: error = %v, want %v naming the holder", step.name, err, ErrLocked)
+			}
+		case step.wantErr == errNotLocked:
+			if err == nil || !strings.Contains(err.Error(), "is not locked") {
+				t.Fatalf("%v: error = %v, want %v", step.name, err, step.wantErr)
+			}
+		case err != nil:
+			t.Fatalf("%v: %v", step.name, err)
+		}
+		if step.name == "second save times out" && time.Since(start) < second.LockTimeout {
+			t.Fatalf("gave up after %v, before the %v timeout", time.Since(start), second.LockTimeout)
+		}
+	}
+	if _, err := os.Stat(dir + "/" + lockFileName); !os.IsNotExist(err) {
+		t.Fatalf("lock file left behind: %v", err)
+	}
+}
+
+func TestLockFileWaits(t *testing.T) {
+	dir := t.TempDir()
+	first, second := NewFileAccountStore(dir), NewFileAccountStore(dir)
+	if err := first.TryLock(); err != nil {
+		t.Fatal(err)
+	}
+	a := NewAccountInfo()
+	a.Name = "alice"
+	if err := a.AddKeyPair(PermOwner, newTestKeyPair(t, KeyTypeEd25519)); err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan error)
+	go func() { done <- second.SaveAccount(a) }()
+	select {
+	case err := <-done:
+		t.Fatalf("SaveAccount() = %v while another store held the lock", err)
+	case <-time.After(200 * time.Millisecond):
+	}
+	if err := first.Unlock(); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if ok, _ := first.HasAccount("alice"); !ok {
+		t.Fatal("account not saved once the lock was free")
+	}
+}
+
+func TestBreakStaleLock(t *testing.T) {
+	// a process that has exited leaves a free pid behind
+	cmd := exec.Command(os.Args[0], "-test.run=^$")
+	if err := cmd.Run(); err != nil {
+		t.Fatal(err)
+	}
+	host, _ := os.Hostname()
+	tests := []struct {
+		name      string
+		holder    LockInfo
+		wantStale bool
+	}{
+		{"exited process", LockInfo{PID: cmd.Process.Pid, Host: host}, true},
+		{"running process", LockInfo{PID: os.Getpid(), Host: host}, false},
+		{"other host", LockInfo{PID: cmd.Process.Pid, Host: host + ".elsewhere"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := &captureLogger{}
+			SetLogger(l)
+			t.Cleanup(func() { SetLogger(nil) })
+			s := NewFileAccountStore(t.TempDir())
+			tt.holder.Time = time.Now().UTC()
+			data, err := json.Marshal(tt.holder)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(s.AccountDir+"/"+lockFileName, data, 0600); err != nil {
+				t.Fatal(err)
+			}
+			if stale := tt.holder.Stale(); stale != tt.wantStale {
+				t.Fatalf("Stale() = %v, want %v", stale, tt.wantStale)
+			}
+			err = s.TryLock()
+			if !errors.Is(err, ErrLocked) || strings.Contains(err.Error(), "BreakStaleLock") != tt.wantStale {
+				t.Fatalf("TryLock() = %v, want %v suggesting BreakStaleLock: %v", err, ErrLocked, tt.wantStale)
+			}
+			err = s.BreakStaleLock()
+			if !tt.wantStale {
+				if !errors.Is(err, ErrLocked) {
+					t.Fatalf("BreakStaleLock() = %v, want %v", err, ErrLocked)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if msgs := l.take(); len(msgs) != 1 || !strings.HasPrefix(msgs[0], "warn: removing stale lock") {
+				t.Fatalf("logged %q", msgs)
+			}
+			if err := s.TryLock(); err != nil {
+				t.Fatal(err)
+			}
+			if err := s.Unlock(); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
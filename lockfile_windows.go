@@ -0,0 +1,16 @@
+package sdk
+
+import (
+	"os"
+)
+
+// processAlive cannot tell a dead process from one we may not open, so
+// FindProcess failing is the only sign of a stale lock.
+func processAlive(pid int) bool {
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	p.Release()
+	return true
+}
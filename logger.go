@@ -0,0 +1,36 @@
+package sdk
+
+import "sync"
+
+// Logger receives the package's diagnostic messages. The default discards
+// them; install one with SetLogger.
+type Logger interface {
+	Infof(format string, args ...interface{})
+	Warnf(format string, args ...interface{})
+}
+
+type nopLogger struct{}
+
+func (nopLogger) Infof(format string, args ...interface{}) {}
+func (nopLogger) Warnf(format string, args ...interface{}) {}
+
+var (
+	loggerMu sync.RWMutex
+	logger   Logger = nopLogger{}
+)
+
+// SetLogger routes the package's messages to l. A nil l silences them again.
+func SetLogger(l Logger) {
+	if l == nil {
+		l = nopLogger{}
+	}
+	loggerMu.Lock()
+	logger = l
+	loggerMu.Unlock()
+}
+
+func currentLogger() Logger {
+	loggerMu.RLock()
+	defer loggerMu.RUnlock()
+	return logger
+}
@@ -0,0 +1,84 @@
+package sdk
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// captureLogger records every message it is given
+type captureLogger struct {
+	mu   sync.Mutex
+	msgs []string
+}
+
+func (l *captureLogger) Infof(format string, args ...interface{}) {
+	l.add("info: " + fmt.Sprintf(format, args...))
+}
+
+func (l *captureLogger) Warnf(format string, args ...interface{}) {
+	l.add("warn: " + fmt.Sprintf(format, args...))
+}
+
+func (l *captureLogger) add(msg string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.msgs = append(l.msgs, msg)
+}
+
+// take returns and forgets the messages logged so far.
+func (l *captureLogger) take() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	msgs := l.msgs
+	l.msgs = nil
+	return msgs
+}
+
+func TestLogger(t *testing.T) {
+	l := &captureLogger{}
+	SetLogger(l)
+	t.Cleanup(func() { SetLogger(nil) })
+
+	s := NewFileAccountStore(t.TempDir())
+	a := NewAccountInfo()
+	a.Name = "alice"
+	if err := a.AddKeyPair(PermOwner, newTestKeyPair(t, KeyTypeEd25519)); err != nil {
+		t.Fatal(err)
+	}
+	steps := []struct {
+		name string
+		run  func() error
+		want []string
+	}{
+		{"save", func() error { return s.SaveAccount(a) }, []string{"info: saving keyfile of account alice to " + s.AccountDir + "/alice.json"}},
+		{"overwrite", func() error { return s.SaveAccount(a) }, []string{"info: backing up " + s.AccountDir + "/alice.json to " + s.AccountDir + "/backup/alice.", "info: saving keyfile of account alice"}},
+		{"delete", func() error { return s.DeleteAccount("alice") }, []string{"info: file " + s.AccountDir + "/alice.json has been removed"}},
+	}
+	for _, step := range steps {
+		if err := step.run(); err != nil {
+			t.Fatalf("%v: %v", step.name, err)
+		}
+		got := l.take()
+		if len(got) != len(step.want) {
+			t.Fatalf("%v logged %q, want %q", step.name, got, step.want)
+		}
+		for i, msg := range got {
+			if !strings.HasPrefix(msg, step.want[i]) {
+				t.Fatalf("%v logged %q, want %q", step.name, msg, step.want[i])
+			}
+		}
+	}
+
+	SetLogger(nil)
+	if err := s.SaveAccount(a); err != nil {
+		t.Fatal(err)
+	}
+	if got := l.take(); len(got) != 0 {
+		t.Fatalf("removed logger still got %q", got)
+	}
+	if _, ok := currentLogger().(nopLogger); !ok {
+		t.Fatalf("SetLogger(nil) installed %T, want the silent default", currentLogger())
+	}
+}
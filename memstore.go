@@ -0,0 +1,65 @@
+package sdk
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// MemoryAccountStore is an AccountStore held entirely in memory, mostly
+// useful as a stand-in for FileAccountStore in tests. Nothing it holds is
+// persisted. Accounts are stored by pointer, so changes made to a saved
+// AccountInfo are visible to later loads.
+type MemoryAccountStore struct {
+	mu       sync.RWMutex
+	accounts map[string]*AccountInfo
+}
+
+func NewMemoryAccountStore() *MemoryAccountStore {
+	return &MemoryAccountStore{accounts: make(map[string]*AccountInfo)}
+}
+
+func (s *MemoryAccountStore) LoadAccount(name string) (*AccountInfo, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	a, ok := s.accounts[name]
+	if !ok {
+		return nil, fmt.Errorf("account %v not found", name)
+	}
+	return a, nil
+}
+
+func (s *MemoryAccountStore) SaveAccount(a *AccountInfo) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.accounts[a.Name] = a
+	return nil
+}
+
+func (s *MemoryAccountStore) DeleteAccount(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.accounts[name]; !ok {
+		return fmt.Errorf("account %v not found", name)
+	}
+	delete(s.accounts, name)
+	return nil
+}
+
+func (s *MemoryAccountStore) ListAccounts() ([]*AccountInfo, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	accs := make([]*AccountInfo, 0, len(s.accounts))
+	for _, a := range s.accounts {
+		accs = append(accs, a)
+	}
+	sort.Slice(accs, func(i, j int) bool { return accs[i].Name < accs[j].Name })
+	return accs, nil
+}
+
+func (s *MemoryAccountStore) HasAccount(name string) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	_, ok := s.accounts[name]
+	return ok, nil
+}
@@ -0,0 +1,63 @@
+package sdk
+
+import (
+	"testing"
+)
+
+var (
+	_ AccountStore = (*MemoryAccountStore)(nil)
+	_ AccountStore = (*FileAccountStore)(nil)
+)
+
+func TestMemoryAccountStore(t *testing.T) {
+	s := NewMemoryAccountStore()
+	account := func(name, note string) *AccountInfo {
+		a := NewAccountInfo()
+		a.Name, a.Note = name, note
+		return a
+	}
+	steps := []struct {
+		name string
+		run  func() error
+		want []string
+	}{
+		{"empty", func() error { return nil }, []string{}},
+		{"save", func() error { return s.SaveAccount(account("bob", "first")) }, []string{"bob"}},
+		{"save another", func() error { return s.SaveAccount(account("alice", "")) }, []string{"alice", "bob"}},
+		{"overwrite", func() error { return s.SaveAccount(account("bob", "second")) }, []string{"alice", "bob"}},
+		{"delete", func() error { return s.DeleteAccount("alice") }, []string{"bob"}},
+	}
+	for _, step := range steps {
+		if err := step.run(); err != nil {
+			t.Fatalf("%v: %v", step.name, err)
+		}
+		accs, err := s.ListAccounts()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(accs) != len(step.want) {
+			t.Fatalf("%v: listed %v accounts, want %v", step.name, len(accs), step.want)
+		}
+		for i, a := range accs {
+			if a.Name != step.want[i] {
+				t.Fatalf("%v: account %v is %v, want %v", step.name, i, a.Name, step.want[i])
+			}
+		}
+	}
+	a, err := s.LoadAccount("bob")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Note != "second" {
+		t.Fatalf("loaded note %q, want the overwritten one", a.Note)
+	}
+	if _, err := s.LoadAccount("alice"); err == nil {
+		t.Fatal("deleted account still loads")
+	}
+	if err := s.DeleteAccount("alice"); err == nil {
+		t.Fatal("deleting a missing account succeeded")
+	}
+	if ok, _ := s.HasAccount("bob"); !ok {
+		t.Fatal("HasAccount(bob) = false")
+	}
+}
@@ -0,0 +1,109 @@
+package sdk
+
+import (
+	"fmt"
+)
+
+// ConflictPolicy decides what Merge does with a permission both accounts
+// have.
+type ConflictPolicy int
+
+const (
+	// MergeError fails the merge, leaving the account unchanged.
+	MergeError ConflictPolicy = iota
+	// MergeSkip keeps the account's own keypair.
+	MergeSkip
+	// MergeOverwrite replaces it with the other account's.
+	MergeOverwrite
+)
+
+// Merge adds the keypairs and threshold permissions of other to the
+// account. A permission name both accounts use is a conflict for onConflict
+// whether it is a keypair or a threshold permission on either side. Both
+// accounts must be all plaintext or all encrypted, as a merged account in a
+// mixed state could not be decrypted with one password. The keypairs are
+// shared, not copied, so other should not be used afterwards. Nothing is
+// changed if the merge fails.
+func (a *AccountInfo) Merge(other *AccountInfo, onConflict ConflictPolicy) error {
+	if len(a.Keypairs) > 0 && len(other.Keypairs) > 0 {
+		ours, theirs := a.EncryptionState(), other.EncryptionState()
+		if ours == StateMixed || theirs == StateMixed || ours != theirs {
+			return fmt.Errorf("%w: cannot merge a %v account into a %v one", ErrMixedEncryption, theirs, ours)
+		}
+	}
+	for perm, kp := range other.Keypairs {
+		if kp.Cipher == CipherAESGCM && other.Name != a.Name {
+			return fmt.Errorf("keypair %v of %v is bound to its account by aes-gcm, decrypt it before merging", perm, other.Name)
+		}
+	}
+	// perms lists what other brings, keypairs and threshold permissions alike
+	perms := make(map[string]bool, len(other.Keypairs)+len(other.Thresholds))
+	for perm := range other.Keypairs {
+		perms[perm] = true
+	}
+	for perm := range other.Thresholds {
+		perms[perm] = true
+	}
+	var take, replaced []string
+	for perm := range perms {
+		if !a.HasPermission(perm) && a.Thresholds[perm] == nil {
+			take = append(take, perm)
+			continue
+		}
+		switch onConflict {
+		case MergeError:
+			return fmt.Errorf("%w: %v", ErrPermissionExists, perm)
+		case MergeSkip:
+		case MergeOverwrite:
+			take = append(take, perm)
+			replaced = append(replaced, perm)
+		default:
+			return fmt.Errorf("unknown conflict policy %v", onConflict)
+		}
+	}
+	merged := make(map[string]*KeyPairInfo, len(a.Keypairs)+len(other.Keypairs))
+	for perm, kp := range a.Keypairs {
+		merged[perm] = kp
+	}
+	var thresholds map[string]*MultiKeyPermission
+	if len(a.Thresholds)+len(other.Thresholds) > 0 {
+		thresholds = make(map[string]*MultiKeyPermission, len(a.Thresholds)+len(other.Thresholds))
+	}
+	for perm, m := range a.Thresholds {
+		thresholds[perm] = m
+	}
+	for _, perm := range replaced {
+		delete(merged, perm)
+		delete(thresholds, perm)
+	}
+	for _, perm := range take {
+		if kp, ok := other.Keypairs[perm]; ok {
+			merged[perm] = kp
+		}
+		if m, ok := other.Thresholds[perm]; ok {
+			thresholds[perm] = m
+		}
+	}
+	for perm := range thresholds {
+		if merged[perm] != nil {
+			return fmt.Errorf("%w: %v is both a keypair and a threshold permission", ErrPermissionExists, perm)
+		}
+	}
+	if err := (&AccountInfo{Keypairs: merged}).checkDuplicateIDs(); err != nil {
+		return err
+	}
+	for _, perm := range replaced {
+		delete(a.signers, perm)
+	}
+	for perm, s := range other.signers {
+		if merged[perm] == other.Keypairs[perm] {
+			if a.signers == nil {
+				a.signers = make(map[string]Signer)
+			}
+			a.signers[perm] = s
+		}
+	}
+	a.Keypairs = merged
+	a.Thresholds = thresholds
+	return nil
+}
@@ -0,0 +1,198 @@
+package sdk
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMerge(t *testing.T) {
+	type fixture struct {
+		a, other     *AccountInfo
+		ours, theirs *KeyPairInfo
+	}
+	setup := func(t *testing.T, theirPerm string) fixture {
+		f := fixture{a: NewAccountInfo(), other: NewAccountInfo()}
+		f.a.Name, f.other.Name = "alice", "bob"
+		f.ours, f.theirs = newTestKeyPair(t, KeyTypeEd25519), newTestKeyPair(t, KeyTypeEd25519)
+		if err := f.a.AddKeyPair(PermOwner, f.ours); err != nil {
+			t.Fatal(err)
+		}
+		if err := f.other.AddKeyPair(theirPerm, f.theirs); err != nil {
+			t.Fatal(err)
+		}
+		return f
+	}
+	tests := []struct {
+		name      string
+		theirPerm string
+		policy    ConflictPolicy
+		prepare   func(t *testing.T, f fixture)
+		wantErr   error
+		wantMsg   string
+		want      func(f fixture) map[string]*KeyPairInfo
+	}{
+		{"no conflict", PermActive, MergeError, nil, nil, "", func(f fixture) map[string]*KeyPairInfo {
+			return map[string]*KeyPairInfo{PermOwner: f.ours, PermActive: f.theirs}
+		}},
+		{"conflict error", PermOwner, MergeError, nil, ErrPermissionExists, "", nil},
+		{"conflict skip", PermOwner, MergeSkip, nil, nil, "", func(f fixture) map[string]*KeyPairInfo {
+			return map[string]*KeyPairInfo{PermOwner: f.ours}
+		}},
+		{"conflict overwrite", PermOwner, MergeOverwrite, nil, nil, "", func(f fixture) map[string]*KeyPairInfo {
+			return map[string]*KeyPairInfo{PermOwner: f.theirs}
+		}},
+		{"unknown policy", PermOwner, ConflictPolicy(42), nil, nil, "unknown conflict policy", nil},
+		{"encrypted into plaintext", PermActive, MergeError, func(t *testing.T, f fixture) {
+			if err := f.theirs.EncryptWithParams([]byte("pw"), fastScrypt); err != nil {
+				t.Fatal(err)
+			}
+		}, ErrMixedEncryption, "", nil},
+		{"plaintext into encrypted", PermActive, MergeError, func(t *testing.T, f fixture) {
+			if err := f.ours.EncryptWithParams([]byte("pw"), fastScrypt); err != nil {
+				t.Fatal(err)
+			}
+		}, ErrMixedEncryption, "", nil},
+		{"both encrypted", PermActive, MergeError, func(t *testing.T, f fixture) {
+			for _, kp := range []*KeyPairInfo{f.ours, f.theirs} {
+				if err := kp.EncryptWithParams([]byte("pw"), fastScrypt); err != nil {
+					t.Fatal(err)
+				}
+			}
+		}, nil, "", func(f fixture) map[string]*KeyPairInfo {
+			return map[string]*KeyPairInfo{PermOwner: f.ours, PermActive: f.theirs}
+		}},
+		{"bound by aes-gcm", PermActive, MergeError, func(t *testing.T, f fixture) {
+			for _, kp := range []*KeyPairInfo{f.ours, f.theirs} {
+				if err := kp.EncryptWithOptions([]byte("pw"), EncryptOptions{Scrypt: fastScrypt, Cipher: CipherAESGCM, AAD: []byte("aad")}); err != nil {
+					t.Fatal(err)
+				}
+			}
+		}, nil, "bound to its account", nil},
+		{"duplicate id", PermActive, MergeError, func(t *testing.T, f fixture) {
+			f.theirs.ID = f.ours.ID
+		}, ErrDuplicateID, "", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := setup(t, tt.theirPerm)
+			if tt.prepare != nil {
+				tt.prepare(t, f)
+			}
+			err := f.a.Merge(f.other, tt.policy)
+			if tt.wantErr != nil || tt.wantMsg != "" {
+				if err == nil || tt.wantErr != nil && !errors.Is(err, tt.wantErr) || !strings.Contains(err.Error(), tt.wantMsg) {
+					t.Fatalf("Merge() = %v, want %v containing %q", err, tt.wantErr, tt.wantMsg)
+				}
+				if len(f.a.Keypairs) != 1 || f.a.Keypairs[PermOwner] != f.ours {
+					t.Fatalf("failed merge changed the account: %v", f.a.Keypairs)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := tt.want(f)
+			if len(f.a.Keypairs) != len(want) {
+				t.Fatalf("keypairs = %v, want %v", f.a.Keypairs, want)
+			}
+			for perm, kp := range want {
+				if f.a.Keypairs[perm] != kp {
+					t.Fatalf("keypair %v is not the expected one", perm)
+				}
+			}
+		})
+	}
+}
+
+func TestMergeThresholds(t *testing.T) {
+	pubs := []string{newTestKeyPair(t, KeyTypeEd25519).PubKey, newTestKeyPair(t, KeyTypeEd25519).PubKey}
+	withThreshold := func(t *testing.T, a *AccountInfo, perm string) *MultiKeyPermission {
+		if err := a.AddThresholdPermission(perm, pubs, 1); err != nil {
+			t.Fatal(err)
+		}
+		return a.Thresholds[perm]
+	}
+	tests := []struct {
+		name string
+		// the permissions of other's keypair and threshold, "" for none
+		theirKeyPair, theirThreshold string
+		policy                       ConflictPolicy
+		wantErr                      error
+		wantKeypairs                 []string
+		wantThresholds               map[string]string
+	}{
+		{"new threshold", "", "cosign", MergeError, nil, []string{PermOwner}, map[string]string{"treasury": "ours", "cosign": "theirs"}},
+		{"keypair over threshold, error", "treasury", "", MergeError, ErrPermissionExists, nil, nil},
+		{"keypair over threshold, skip", "treasury", "", MergeSkip, nil, []string{PermOwner}, map[string]string{"treasury": "ours"}},
+		{"keypair over threshold, overwrite", "treasury", "", MergeOverwrite, nil, []string{PermOwner, "treasury"}, map[string]string{}},
+		{"threshold over keypair, error", "", PermOwner, MergeError, ErrPermissionExists, nil, nil},
+		{"threshold over keypair, skip", "", PermOwner, MergeSkip, nil, []string{PermOwner}, map[string]string{"treasury": "ours"}},
+		{"threshold over keypair, overwrite", PermActive, PermOwner, MergeOverwrite, nil, []string{PermActive}, map[string]string{"treasury": "ours", PermOwner: "theirs"}},
+		{"threshold over threshold, error", "", "treasury", MergeError, ErrPermissionExists, nil, nil},
+		{"threshold over threshold, overwrite", "", "treasury", MergeOverwrite, nil, []string{PermOwner}, map[string]string{"treasury": "theirs"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, other := NewAccountInfo(), NewAccountInfo()
+			a.Name, other.Name = "alice", "bob"
+			ours := newTestKeyPair(t, KeyTypeEd25519)
+			if err := a.AddKeyPair(PermOwner, ours); err != nil {
+				t.Fatal(err)
+			}
+			sides := map[*MultiKeyPermission]string{withThreshold(t, a, "treasury"): "ours"}
+			if tt.theirKeyPair != "" {
+				if err := other.AddKeyPair(tt.theirKeyPair, newTestKeyPair(t, KeyTypeEd25519)); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if tt.theirThreshold != "" {
+				sides[withThreshold(t, other, tt.theirThreshold)] = "theirs"
+			}
+
+			err := a.Merge(other, tt.policy)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Merge() = %v, want %v", err, tt.wantErr)
+				}
+				if len(a.Keypairs) != 1 || a.Keypairs[PermOwner] != ours || len(a.Thresholds) != 1 || sides[a.Thresholds["treasury"]] != "ours" {
+					t.Fatalf("failed merge changed the account: %v, %v", a.Keypairs, a.Thresholds)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := a.Permissions(); !reflect.DeepEqual(got, tt.wantKeypairs) {
+				t.Fatalf("keypairs = %v, want %v", got, tt.wantKeypairs)
+			}
+			got := make(map[string]string, len(a.Thresholds))
+			for perm, m := range a.Thresholds {
+				got[perm] = sides[m]
+			}
+			if !reflect.DeepEqual(got, tt.wantThresholds) {
+				t.Fatalf("thresholds = %v, want %v", got, tt.wantThresholds)
+			}
+
+			// the merged account is one the store loads again
+			s := NewFileAccountStore(t.TempDir())
+			if err := s.SaveAccount(a); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := s.LoadAccount("alice"); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+
+	// other clashing with itself is refused too
+	a, other := NewAccountInfo(), NewAccountInfo()
+	if err := other.AddKeyPair("cosign", newTestKeyPair(t, KeyTypeEd25519)); err != nil {
+		t.Fatal(err)
+	}
+	other.Thresholds = map[string]*MultiKeyPermission{"cosign": {PubKeys: pubs, Threshold: 1}}
+	if err := a.Merge(other, MergeOverwrite); !errors.Is(err, ErrPermissionExists) {
+		t.Fatalf("Merge() of an account using cosign twice = %v, want ErrPermissionExists", err)
+	}
+}
@@ -0,0 +1,27 @@
+package sdk
+
+import "fmt"
+
+// KeystoreVersion is the AccountInfo format written by this package. Files
+// without a version field are version 0.
+const KeystoreVersion = 1
+
+// migrations[v] upgrades an account from version v to v+1 in memory. The
+// upgraded form reaches disk on the next save.
+var migrations = map[int]func(a *AccountInfo) error{
+	// version 1 added the account checksum, which SaveTo always writes
+	0: func(a *AccountInfo) error { return nil },
+}
+
+func (a *AccountInfo) migrate() error {
+	if a.Version > KeystoreVersion || a.Version < 0 {
+		return fmt.Errorf("%w %v, newest supported is %v", ErrUnsupportedVersion, a.Version, KeystoreVersion)
+	}
+	for a.Version < KeystoreVersion {
+		if err := migrations[a.Version](a); err != nil {
+			return fmt.Errorf("migrating keystore from version %v: %w", a.Version, err)
+		}
+		a.Version++
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package sdk
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestMigrate(t *testing.T) {
+	tests := []struct {
+		name    string
+		version int
+		wantErr error
+	}{
+		{"unversioned", 0, nil},
+		{"current", KeystoreVersion, nil},
+		{"newer", KeystoreVersion + 1, ErrUnsupportedVersion},
+		{"negative", -1, ErrUnsupportedVersion},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := NewAccountInfo()
+			if err := a.AddKeyPair(PermOwner, newTestKeyPair(t, KeyTypeEd25519)); err != nil {
+				t.Fatal(err)
+			}
+			a.Version = tt.version
+			// version is not covered by the checksum
+			a.Checksum = a.computeChecksum()
+			data, err := json.Marshal(a)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := decodeAccount(bytes.NewReader(data), "test", false)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("decodeAccount() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Version != KeystoreVersion {
+				t.Fatalf("version = %v, want %v", got.Version, KeystoreVersion)
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package sdk
+
+import (
+	"fmt"
+	"github.com/quantosnetwork/dev-0.1.0/common"
+)
+
+// MigrateKeyType replaces the keypair of perm with a fresh one of newType
+// and returns its public key, for the caller to submit as an on-chain key
+// change. The old keypair is kept as perm+".previous" and its public key in
+// the perm+".previous_pubkey" tag, so it stays usable until the chain has
+// accepted the new key.
+//
+// An encrypted keypair is replaced by one encrypted with the same password
+// and options; password must unlock the old keypair. It is ignored for a
+// plaintext keypair.
+func (a *AccountInfo) MigrateKeyType(perm string, newType KeyType, password []byte) (string, error) {
+	old, ok := a.Keypairs[perm]
+	if !ok {
+		return "", fmt.Errorf("%w %v", ErrInvalidPermission, perm)
+	}
+	previous := perm + ".previous"
+	if a.HasPermission(previous) {
+		return "", fmt.Errorf("%w: %v, remove the earlier migration first", ErrPermissionExists, previous)
+	}
+	scheme, err := schemeFor(newType)
+	if err != nil {
+		return "", err
+	}
+	kept := old
+	if old.IsEncrypted() {
+		check := old.Clone()
+		if err := a.decryptKeyPair(perm, check, password); err != nil {
+			return "", err
+		}
+		// an aes-gcm keypair is bound to its permission and has to be
+		// sealed again under the one it moves to
+		if old.Cipher == CipherAESGCM {
+			opts := old.encryptOptions()
+			opts.AAD = keyPairAAD(a.Name, previous, old.KeyType)
+			if err := check.EncryptWithOptions(password, opts); err != nil {
+				return "", err
+			}
+			kept = check
+		} else {
+			check.Wipe()
+		}
+	}
+	kp := &KeyPairInfo{ID: newKeyPairID(), KeyType: newType}
+	priv, pub, err := scheme.newKey(kp.ID)
+	if err != nil {
+		return "", err
+	}
+	kp.RawKey = NewSecretKey(common.EncodeBase58(priv))
+	wipeBytes(priv)
+	kp.PubKey = common.EncodeBase58(pub)
+	if old.IsEncrypted() {
+		opts := old.encryptOptions()
+		if opts.Cipher == CipherAESGCM {
+			opts.AAD = keyPairAAD(a.Name, perm, newType)
+		}
+		if err := kp.EncryptWithOptions(password, opts); err != nil {
+			return "", err
+		}
+	}
+	a.Keypairs[previous] = kept
+	a.Keypairs[perm] = kp
+	delete(a.signers, perm)
+	a.SetTag(perm+".previous_pubkey", old.PubKey)
+	return kp.PubKey, nil
+}
@@ -0,0 +1,107 @@
+package sdk
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestMigrateKeyType(t *testing.T) {
+	tests := []struct {
+		name string
+		opts *EncryptOptions
+	}{
+		{"plaintext", nil},
+		{"aes-ctr", &EncryptOptions{Scrypt: fastScrypt}},
+		{"aes-gcm", &EncryptOptions{KeySize: 32, Scrypt: fastScrypt, Cipher: CipherAESGCM}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := NewAccountInfo()
+			a.Name = "alice"
+			old := newTestKeyPair(t, KeyTypeEd25519)
+			oldPub, oldRaw := old.PubKey, old.RawKey.Reveal()
+			if err := a.AddKeyPair(PermOwner, old); err != nil {
+				t.Fatal(err)
+			}
+			if tt.opts != nil {
+				if err := a.EncryptWithOptions([]byte("pw"), *tt.opts); err != nil {
+					t.Fatal(err)
+				}
+			}
+			pub, err := a.MigrateKeyType(PermOwner, KeyTypeP256, []byte("pw"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			kp := a.Keypairs[PermOwner]
+			if kp.KeyType != KeyTypeP256 || kp.PubKey != pub || pub == oldPub {
+				t.Fatalf("new keypair is a %v key %v, returned %v", kp.KeyType, kp.PubKey, pub)
+			}
+			if got, _ := a.GetTag(PermOwner + ".previous_pubkey"); got != oldPub {
+				t.Fatalf("previous_pubkey tag = %q, want %q", got, oldPub)
+			}
+			if tt.opts != nil {
+				if !reflect.DeepEqual(kp.encryptOptions(), a.Keypairs[PermOwner+".previous"].encryptOptions()) {
+					t.Fatal("new keypair is not encrypted like the old one")
+				}
+				if err := a.Decrypt([]byte("pw")); err != nil {
+					t.Fatal(err)
+				}
+			}
+			prev := a.Keypairs[PermOwner+".previous"]
+			if prev.PubKey != oldPub || prev.RawKey.Reveal() != oldRaw {
+				t.Fatal("old keypair was not kept")
+			}
+			if err := a.Keypairs[PermOwner].VerifyPublicKey(); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestMigrateKeyTypeRejects(t *testing.T) {
+	tests := []struct {
+		name     string
+		perm     string
+		newType  KeyType
+		password string
+		wantErr  error
+	}{
+		{"unknown permission", "missing", KeyTypeP256, "pw", ErrInvalidPermission},
+		{"unsupported type", PermOwner, "rsa", "pw", ErrUnsupportedKey},
+		{"wrong password", PermOwner, KeyTypeP256, "wrong", ErrWrongPassword},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := NewAccountInfo()
+			a.Name = "alice"
+			if err := a.AddKeyPair(PermOwner, newTestKeyPair(t, KeyTypeEd25519)); err != nil {
+				t.Fatal(err)
+			}
+			if err := a.EncryptWithOptions([]byte("pw"), EncryptOptions{Scrypt: fastScrypt}); err != nil {
+				t.Fatal(err)
+			}
+			before := a.Keypairs[PermOwner]
+			sealed := before.EncryptedKey
+			if _, err := a.MigrateKeyType(tt.perm, tt.newType, []byte(tt.password)); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("MigrateKeyType() = %v, want %v", err, tt.wantErr)
+			}
+			if len(a.Keypairs) != 1 || a.Keypairs[PermOwner] != before || before.EncryptedKey != sealed || len(a.Tags()) != 0 {
+				t.Fatal("failed migration changed the account")
+			}
+		})
+	}
+
+	// a second migration would overwrite the key kept by the first
+	a := NewAccountInfo()
+	a.Name = "alice"
+	if err := a.AddKeyPair(PermOwner, newTestKeyPair(t, KeyTypeEd25519)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := a.MigrateKeyType(PermOwner, KeyTypeP256, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := a.MigrateKeyType(PermOwner, KeyTypeEd25519, nil); !errors.Is(err, ErrPermissionExists) {
+		t.Fatalf("second MigrateKeyType() = %v, want %v", err, ErrPermissionExists)
+	}
+}
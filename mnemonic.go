@@ -0,0 +1,46 @@
+package sdk
+
+import (
+	"fmt"
+	"github.com/tyler-smith/go-bip39"
+	"strings"
+)
+
+// GenerateMnemonic returns a BIP39 phrase over bits of fresh entropy, one of
+// 128, 160, 192, 224 or 256.
+func GenerateMnemonic(bits int) (string, error) {
+	switch bits {
+	case 128, 160, 192, 224, 256:
+	default:
+		return "", fmt.Errorf("invalid mnemonic entropy size %v", bits)
+	}
+	entropy, err := bip39.NewEntropy(bits)
+	if err != nil {
+		return "", err
+	}
+	defer wipeBytes(entropy)
+	return bip39.NewMnemonic(entropy)
+}
+
+// NewKeyPairInfoFromMnemonic derives a keypair from a BIP39 phrase. The
+// phrase checksum is verified and the seed is the standard BIP39 PBKDF2
+// expansion of mnemonic and passphrase, so the same inputs always produce
+// the same keys.
+func NewKeyPairInfoFromMnemonic(mnemonic, passphrase, keyType string) (*KeyPairInfo, error) {
+	words := strings.Fields(mnemonic)
+	switch len(words) {
+	case 12, 15, 18, 21, 24:
+	default:
+		return nil, fmt.Errorf("invalid mnemonic: expected 12, 15, 18, 21 or 24 words, got %v", len(words))
+	}
+	kt, err := ParseKeyType(keyType)
+	if err != nil {
+		return nil, err
+	}
+	seed, err := bip39.NewSeedWithErrorChecking(strings.Join(words, " "), passphrase)
+	if err != nil {
+		return nil, fmt.Errorf("invalid mnemonic: %v", err)
+	}
+	defer wipeBytes(seed)
+	return keyPairFromSeed(seed, kt)
+}
@@ -0,0 +1,96 @@
+package sdk
+
+import (
+	"encoding/hex"
+	"github.com/quantosnetwork/dev-0.1.0/common"
+	"strings"
+	"testing"
+)
+
+// bip39Vector is the first English vector of the BIP39 reference
+// implementation, whose seed begins with the ed25519 key below
+const bip39Vector = "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+
+func TestNewKeyPairInfoFromMnemonicVector(t *testing.T) {
+	const (
+		wantKey = "c55257c360c07c72029aebc1b53c05ed0362ada38ead3e3e9efa3708e5349553"
+		wantPub = "51425909c1e61287d378cf7af24fed87fa767e19a3462f7a01c93f95d73c465b"
+	)
+	for _, mnemonic := range []string{bip39Vector, "  " + strings.ReplaceAll(bip39Vector, " ", "\n\t") + " "} {
+		kp, err := NewKeyPairInfoFromMnemonic(mnemonic, "TREZOR", "ed25519")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := hex.EncodeToString(kp.RawKey.decode()); got != wantKey {
+			t.Fatalf("raw key %v, want %v", got, wantKey)
+		}
+		if got := hex.EncodeToString(common.DecodeBase58(kp.PubKey)); got != wantPub {
+			t.Fatalf("public key %v, want %v", got, wantPub)
+		}
+		if kp.KeyType != KeyTypeEd25519 {
+			t.Fatalf("key type %v, want ed25519", kp.KeyType)
+		}
+	}
+	other, err := NewKeyPairInfoFromMnemonic(bip39Vector, "", "ed25519")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hex.EncodeToString(common.DecodeBase58(other.PubKey)) == wantPub {
+		t.Fatal("passphrase does not change the derived key")
+	}
+}
+
+func TestNewKeyPairInfoFromMnemonicRejects(t *testing.T) {
+	tests := []struct {
+		name     string
+		mnemonic string
+		keyType  string
+	}{
+		{"empty", "", "ed25519"},
+		{"eleven words", strings.Repeat("abandon ", 11), "ed25519"},
+		{"thirteen words", bip39Vector + " about", "ed25519"},
+		{"unknown key type", bip39Vector, "rsa"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewKeyPairInfoFromMnemonic(tt.mnemonic, "", tt.keyType); err == nil {
+				t.Fatal("NewKeyPairInfoFromMnemonic succeeded")
+			}
+		})
+	}
+}
+
+func TestMnemonicChecksum(t *testing.T) {
+	bad := strings.Repeat("abandon ", 12)
+	if _, err := NewKeyPairInfoFromMnemonic(bad, "", "ed25519"); err == nil {
+		t.Fatal("accepted a mnemonic with a bad checksum")
+	}
+}
+
+func TestGenerateMnemonic(t *testing.T) {
+	tests := []struct {
+		bits  int
+		words int
+	}{
+		{128, 12}, {160, 15}, {192, 18}, {224, 21}, {256, 24},
+		{0, 0}, {96, 0}, {129, 0}, {512, 0},
+	}
+	for _, tt := range tests {
+		m, err := GenerateMnemonic(tt.bits)
+		if tt.words == 0 {
+			if err == nil {
+				t.Fatalf("GenerateMnemonic(%v) succeeded", tt.bits)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n := len(strings.Fields(m)); n != tt.words {
+			t.Fatalf("GenerateMnemonic(%v) gave %v words, want %v", tt.bits, n, tt.words)
+		}
+		if _, err := NewKeyPairInfoFromMnemonic(m, "", "ed25519"); err != nil {
+			t.Fatalf("generated mnemonic does not load: %v", err)
+		}
+	}
+}
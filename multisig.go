@@ -0,0 +1,112 @@
+package sdk
+
+import (
+	"fmt"
+)
+
+// MultiKeyPermission is a permission satisfied by signatures from at least
+// Threshold of PubKeys. The SDK holds no private keys for it; each holder
+// signs with their own account or Signer.
+type MultiKeyPermission struct {
+	PubKeys   []string `json:"pubkeys"`
+	Threshold int      `json:"threshold"`
+}
+
+// AddThresholdPermission registers perm as satisfied by threshold of the
+// given base58 public keys. The name must not clash with a keypair.
+func (a *AccountInfo) AddThresholdPermission(perm string, pubKeys []string, threshold int) error {
+	if a.HasPermission(perm) || a.Thresholds[perm] != nil {
+		return fmt.Errorf("%w: %v", ErrPermissionExists, perm)
+	}
+	m := &MultiKeyPermission{PubKeys: append([]string(nil), pubKeys...), Threshold: threshold}
+	if err := m.Validate(); err != nil {
+		return err
+	}
+	if a.Thresholds == nil {
+		a.Thresholds = make(map[string]*MultiKeyPermission)
+	}
+	a.Thresholds[perm] = m
+	return nil
+}
+
+// Validate checks that the threshold can be met and that every key is a
+// distinct public key of a scheme VerifySignature knows.
+func (m *MultiKeyPermission) Validate() error {
+	if m.Threshold < 1 || m.Threshold > len(m.PubKeys) {
+		return fmt.Errorf("%w: threshold %v of %v keys", ErrInvalidPermission, m.Threshold, len(m.PubKeys))
+	}
+	seen := make(map[string]bool, len(m.PubKeys))
+	for _, pub := range m.PubKeys {
+		if seen[pub] {
+			return fmt.Errorf("%w: key %v listed twice", ErrInvalidPermission, pub)
+		}
+		seen[pub] = true
+		if _, err := VerifySignature(pub, nil, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Verify checks that sigs, keyed by public key, hold valid signatures of
+// message from at least Threshold of the permission's keys. Signatures from
+// other keys and invalid ones are not counted.
+func (m *MultiKeyPermission) Verify(message []byte, sigs map[string][]byte) error {
+	valid := 0
+	for _, pub := range m.PubKeys {
+		sig, ok := sigs[pub]
+		if !ok {
+			continue
+		}
+		if ok, err := VerifySignature(pub, message, sig); err == nil && ok {
+			valid++
+		}
+	}
+	if valid < m.Threshold {
+		return fmt.Errorf("%w: %v of %v required", ErrThresholdNotMet, valid, m.Threshold)
+	}
+	return nil
+}
+
+// CollectSignatures signs message with signers holding keys of the
+// threshold permission perm, stopping once the threshold is met. Signers
+// whose key is not part of perm are ignored. The result, keyed by public
+// key, is checked with Verify before it is returned.
+func (a *AccountInfo) CollectSignatures(perm string, message []byte, signers ...Signer) (map[string][]byte, error) {
+	m := a.Thresholds[perm]
+	if m == nil {
+		return nil, fmt.Errorf("%w %v", ErrInvalidPermission, perm)
+	}
+	member := make(map[string]bool, len(m.PubKeys))
+	for _, pub := range m.PubKeys {
+		member[pub] = true
+	}
+	sigs := make(map[string][]byte, m.Threshold)
+	for _, s := range signers {
+		if len(sigs) == m.Threshold {
+			break
+		}
+		pub := s.PublicKey()
+		if !member[pub] || sigs[pub] != nil {
+			continue
+		}
+		sig, err := s.Sign(message)
+		if err != nil {
+			return nil, fmt.Errorf("%v: %w", pub, err)
+		}
+		sigs[pub] = sig
+	}
+	if err := m.Verify(message, sigs); err != nil {
+		return nil, err
+	}
+	return sigs, nil
+}
+
+// VerifyThreshold checks sigs against the threshold permission perm.
+func (a *AccountInfo) VerifyThreshold(perm string, message []byte, sigs map[string][]byte) error {
+	m := a.Thresholds[perm]
+	if m == nil {
+		return fmt.Errorf("%w %v", ErrInvalidPermission, perm)
+	}
+	return m.Verify(message, sigs)
+}
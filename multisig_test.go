@@ -0,0 +1,124 @@
+package sdk
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestThresholdPermission(t *testing.T) {
+	keys := []*KeyPairInfo{
+		newTestKeyPair(t, KeyTypeEd25519),
+		newTestKeyPair(t, KeyTypeP256),
+		newTestKeyPair(t, KeyTypeEd25519),
+	}
+	pubs := make([]string, len(keys))
+	for i, kp := range keys {
+		pubs[i] = kp.PubKey
+	}
+	a := NewAccountInfo()
+	if err := a.AddThresholdPermission("treasury", pubs, 2); err != nil {
+		t.Fatal(err)
+	}
+	message := []byte("transfer 10")
+	tests := []struct {
+		name    string
+		signers []Signer
+		wantErr error
+	}{
+		{"none", nil, ErrThresholdNotMet},
+		{"one", []Signer{keys[0]}, ErrThresholdNotMet},
+		{"one twice", []Signer{keys[0], keys[0]}, ErrThresholdNotMet},
+		{"outsider", []Signer{keys[0], newTestKeyPair(t, KeyTypeEd25519)}, ErrThresholdNotMet},
+		{"threshold", []Signer{keys[0], keys[1]}, nil},
+		{"all", []Signer{keys[2], keys[1], keys[0]}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sigs, err := a.CollectSignatures("treasury", message, tt.signers...)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("CollectSignatures() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if len(sigs) != 2 {
+				t.Fatalf("collected %v signatures, want the threshold of 2", len(sigs))
+			}
+			if err := a.VerifyThreshold("treasury", message, sigs); err != nil {
+				t.Fatal(err)
+			}
+			if err := a.VerifyThreshold("treasury", []byte("transfer 1000"), sigs); !errors.Is(err, ErrThresholdNotMet) {
+				t.Fatalf("signatures verified for another message: %v", err)
+			}
+		})
+	}
+}
+
+func TestAddThresholdPermissionRejects(t *testing.T) {
+	pub := newTestKeyPair(t, KeyTypeEd25519).PubKey
+	tests := []struct {
+		name      string
+		pubKeys   []string
+		threshold int
+	}{
+		{"zero threshold", []string{pub}, 0},
+		{"threshold above keys", []string{pub}, 2},
+		{"duplicate key", []string{pub, pub}, 2},
+		{"not a public key", []string{pub, "x"}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := NewAccountInfo()
+			if err := a.AddThresholdPermission("multi", tt.pubKeys, tt.threshold); err == nil {
+				t.Fatal("AddThresholdPermission() = nil, want error")
+			}
+		})
+	}
+}
+
+func TestPermissionNameClash(t *testing.T) {
+	pub := newTestKeyPair(t, KeyTypeEd25519).PubKey
+	tests := []struct {
+		name string
+		add  func(a *AccountInfo) error
+	}{
+		{"keypair over threshold", func(a *AccountInfo) error {
+			if err := a.AddThresholdPermission("shared", []string{pub}, 1); err != nil {
+				return err
+			}
+			return a.AddKeyPair("shared", newTestKeyPair(t, KeyTypeEd25519))
+		}},
+		{"threshold over keypair", func(a *AccountInfo) error {
+			if err := a.AddKeyPair("shared", newTestKeyPair(t, KeyTypeEd25519)); err != nil {
+				return err
+			}
+			return a.AddThresholdPermission("shared", []string{pub}, 1)
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.add(NewAccountInfo()); !errors.Is(err, ErrPermissionExists) {
+				t.Fatalf("error = %v, want ErrPermissionExists", err)
+			}
+		})
+	}
+}
+
+func TestLoadRejectsPermissionClash(t *testing.T) {
+	a := NewAccountInfo()
+	a.Name = "clash"
+	if err := a.AddKeyPair("shared", newTestKeyPair(t, KeyTypeEd25519)); err != nil {
+		t.Fatal(err)
+	}
+	a.Thresholds = map[string]*MultiKeyPermission{
+		"shared": {PubKeys: []string{newTestKeyPair(t, KeyTypeEd25519).PubKey}, Threshold: 1},
+	}
+	var buf bytes.Buffer
+	if _, err := a.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := decodeAccount(&buf, "clash", false); !errors.Is(err, ErrPermissionExists) {
+		t.Fatalf("decodeAccount() error = %v, want ErrPermissionExists", err)
+	}
+}
@@ -0,0 +1,87 @@
+package sdk
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"unicode"
+	"unicode/utf8"
+)
+
+// PasswordPolicy describes the minimum a keystore password must meet. The
+// Require fields each demand at least one character of that class.
+type PasswordPolicy struct {
+	MinLength     int
+	RequireUpper  bool
+	RequireLower  bool
+	RequireDigit  bool
+	RequireSymbol bool
+}
+
+var DefaultPasswordPolicy = PasswordPolicy{MinLength: 8}
+
+func ValidatePassword(password []byte, policy PasswordPolicy) error {
+	if n := utf8.RuneCount(password); n < policy.MinLength {
+		return fmt.Errorf("%w: must be at least %v characters, got %v", ErrWeakPassword, policy.MinLength, n)
+	}
+	var upper, lower, digit, symbol bool
+	for _, r := range string(password) {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r):
+			symbol = true
+		}
+	}
+	switch {
+	case policy.RequireUpper && !upper:
+		return fmt.Errorf("%w: needs an upper case letter", ErrWeakPassword)
+	case policy.RequireLower && !lower:
+		return fmt.Errorf("%w: needs a lower case letter", ErrWeakPassword)
+	case policy.RequireDigit && !digit:
+		return fmt.Errorf("%w: needs a digit", ErrWeakPassword)
+	case policy.RequireSymbol && !symbol:
+		return fmt.Errorf("%w: needs a symbol", ErrWeakPassword)
+	}
+	return nil
+}
+
+// PasswordFromEnv reads a password from the environment variable name,
+// for automation that cannot prompt.
+func PasswordFromEnv(name string) ([]byte, error) {
+	v, ok := os.LookupEnv(name)
+	if !ok {
+		return nil, fmt.Errorf("password variable %v is not set", name)
+	}
+	if v == "" {
+		return nil, fmt.Errorf("password variable %v is empty", name)
+	}
+	return []byte(v), nil
+}
+
+// PasswordFromFile reads a password from the file at path, dropping one
+// trailing newline as editors and echo add. It warns when other users can
+// read the file.
+func PasswordFromFile(path string) ([]byte, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if info.Mode().Perm()&0004 != 0 {
+		currentLogger().Warnf("password file %v is readable by all users", path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	password := bytes.TrimSuffix(bytes.TrimSuffix(data, []byte("\n")), []byte("\r"))
+	if len(password) == 0 {
+		wipeBytes(data)
+		return nil, fmt.Errorf("password file %v is empty", path)
+	}
+	return password, nil
+}
@@ -0,0 +1,141 @@
+package sdk
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValidatePassword(t *testing.T) {
+	strict := PasswordPolicy{MinLength: 8, RequireUpper: true, RequireLower: true, RequireDigit: true, RequireSymbol: true}
+	tests := []struct {
+		name     string
+		password string
+		policy   PasswordPolicy
+		ok       bool
+	}{
+		{"empty policy", "", PasswordPolicy{}, true},
+		{"default", "eightchr", DefaultPasswordPolicy, true},
+		{"too short", "short", DefaultPasswordPolicy, false},
+		{"length counts runes", "пароль12", DefaultPasswordPolicy, true},
+		{"multibyte too short", "пароль", DefaultPasswordPolicy, false},
+		{"strict", "Passw0rd!", strict, true},
+		{"no upper", "passw0rd!", strict, false},
+		{"no lower", "PASSW0RD!", strict, false},
+		{"no digit", "Password!", strict, false},
+		{"no symbol", "Passw0rdd", strict, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidatePassword([]byte(tt.password), tt.policy)
+			if tt.ok && err != nil {
+				t.Fatalf("ValidatePassword() = %v, want nil", err)
+			}
+			if !tt.ok && !errors.Is(err, ErrWeakPassword) {
+				t.Fatalf("ValidatePassword() = %v, want ErrWeakPassword", err)
+			}
+		})
+	}
+}
+
+func TestEncryptWithPolicy(t *testing.T) {
+	kp := newTestKeyPair(t, KeyTypeEd25519)
+	policy := DefaultPasswordPolicy
+	err := kp.EncryptWithOptions([]byte("short"), EncryptOptions{Scrypt: fastScrypt, Policy: &policy})
+	if !errors.Is(err, ErrWeakPassword) {
+		t.Fatalf("EncryptWithOptions() = %v, want ErrWeakPassword", err)
+	}
+	if kp.IsEncrypted() {
+		t.Fatal("weak password encrypted the keypair")
+	}
+	if err := kp.EncryptWithOptions([]byte("long enough"), EncryptOptions{Scrypt: fastScrypt, Policy: &policy}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPasswordFromEnv(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		set     bool
+		want    string
+		wantErr string
+	}{
+		{"set", "hunter2\n", true, "hunter2\n", ""},
+		{"empty", "", true, "", "is empty"},
+		{"unset", "", false, "", "is not set"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			const name = "QUANTOS_SDK_TEST_PASSWORD"
+			t.Setenv(name, tt.value)
+			if !tt.set {
+				os.Unsetenv(name)
+			}
+			got, err := PasswordFromEnv(name)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) || !strings.Contains(err.Error(), name) {
+					t.Fatalf("PasswordFromEnv() = %q, %v, want an error that %v %v", got, err, name, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || string(got) != tt.want {
+				t.Fatalf("PasswordFromEnv() = %q, %v, want %q", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestPasswordFromFile(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		mode     os.FileMode
+		want     string
+		wantErr  string
+		wantWarn bool
+	}{
+		{"plain", "hunter2", 0600, "hunter2", "", false},
+		{"trailing newline", "hunter2\n", 0600, "hunter2", "", false},
+		{"windows newline", "hunter2\r\n", 0600, "hunter2", "", false},
+		{"only one newline dropped", "hunter2\n\n", 0600, "hunter2\n", "", false},
+		{"world-readable", "hunter2\n", 0644, "hunter2", "", true},
+		{"empty", "", 0600, "", "is empty", false},
+		{"just a newline", "\n", 0600, "", "is empty", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := &captureLogger{}
+			SetLogger(l)
+			t.Cleanup(func() { SetLogger(nil) })
+			path := filepath.Join(t.TempDir(), "password")
+			if err := os.WriteFile(path, []byte(tt.data), tt.mode); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.Chmod(path, tt.mode); err != nil {
+				t.Fatal(err)
+			}
+			got, err := PasswordFromFile(path)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("PasswordFromFile() = %q, %v, want an error that it %v", got, err, tt.wantErr)
+				}
+			} else if err != nil || string(got) != tt.want {
+				t.Fatalf("PasswordFromFile() = %q, %v, want %q", got, err, tt.want)
+			}
+			warned := false
+			for _, msg := range l.take() {
+				warned = warned || strings.HasPrefix(msg, "warn: ") && strings.Contains(msg, "readable by all users")
+			}
+			if warned != tt.wantWarn {
+				t.Fatalf("warned about permissions: %v, want %v", warned, tt.wantWarn)
+			}
+		})
+	}
+	if _, err := PasswordFromFile(filepath.Join(t.TempDir(), "missing")); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("PasswordFromFile() on a missing file = %v, want %v", err, fs.ErrNotExist)
+	}
+}
@@ -0,0 +1,47 @@
+package sdk
+
+import (
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+)
+
+const (
+	PEMTypeEncryptedKeyPair = "QUANTOS ENCRYPTED KEYPAIR"
+	PEMTypeKeyPair          = "QUANTOS KEYPAIR"
+)
+
+// MarshalPEM wraps the keypair's JSON form in a PEM block. Encrypted
+// keypairs use PEMTypeEncryptedKeyPair, plaintext ones PEMTypeKeyPair.
+func (k *KeyPairInfo) MarshalPEM() ([]byte, error) {
+	data, err := json.Marshal(k)
+	if err != nil {
+		return nil, err
+	}
+	blockType := PEMTypeKeyPair
+	if k.IsEncrypted() {
+		blockType = PEMTypeEncryptedKeyPair
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: data}), nil
+}
+
+// UnmarshalPEM reads a keypair written by MarshalPEM and validates it as
+// keystores are on load.
+func UnmarshalPEM(data []byte) (*KeyPairInfo, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+	if block.Type != PEMTypeEncryptedKeyPair && block.Type != PEMTypeKeyPair {
+		return nil, fmt.Errorf("unexpected PEM block type %q", block.Type)
+	}
+	k := &KeyPairInfo{}
+	if err := json.Unmarshal(block.Bytes, k); err != nil {
+		return nil, fmt.Errorf("invalid keypair in PEM block, %w", err)
+	}
+	if err := k.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid keypair in PEM block, %w", err)
+	}
+	return k, nil
+}
@@ -0,0 +1,77 @@
+package sdk
+
+import (
+	"fmt"
+	"time"
+)
+
+// SetValidity limits AccountInfo.Sign to keypair use between notBefore and
+// notAfter. A zero time leaves that side open. The window is plaintext in
+// the keystore like Metadata, so it guards against mistakes rather than
+// against whoever can edit the file.
+func (k *KeyPairInfo) SetValidity(notBefore, notAfter time.Time) error {
+	if !notBefore.IsZero() && !notAfter.IsZero() && notAfter.Before(notBefore) {
+		return fmt.Errorf("%w: not_after %v is before not_before %v", ErrInvalidKeyPair,
+			notAfter.Format(time.RFC3339), notBefore.Format(time.RFC3339))
+	}
+	k.NotBefore, k.NotAfter = formatPolicyTime(notBefore), formatPolicyTime(notAfter)
+	return nil
+}
+
+// SetMaxUses caps the signatures AccountInfo.Sign makes with the keypair,
+// counting those already made. Zero removes the cap.
+func (k *KeyPairInfo) SetMaxUses(n int) error {
+	if n < 0 {
+		return fmt.Errorf("%w: negative max_uses %v", ErrInvalidKeyPair, n)
+	}
+	k.MaxUses = n
+	return nil
+}
+
+func formatPolicyTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// checkPolicy reports whether the keypair may sign at now.
+func (k *KeyPairInfo) checkPolicy(now time.Time) error {
+	notBefore, notAfter, err := k.validity()
+	if err != nil {
+		return err
+	}
+	switch {
+	case !notBefore.IsZero() && now.Before(notBefore):
+		return fmt.Errorf("%w until %v", ErrKeyNotYetValid, k.NotBefore)
+	case !notAfter.IsZero() && now.After(notAfter):
+		return fmt.Errorf("%w since %v", ErrKeyExpired, k.NotAfter)
+	case k.MaxUses > 0 && k.Uses >= k.MaxUses:
+		return fmt.Errorf("%w of %v signatures", ErrUsageLimitReached, k.MaxUses)
+	}
+	return nil
+}
+
+func (k *KeyPairInfo) validity() (notBefore, notAfter time.Time, err error) {
+	if k.NotBefore != "" {
+		if notBefore, err = time.Parse(time.RFC3339, k.NotBefore); err != nil {
+			return notBefore, notAfter, fmt.Errorf("%w: not_before: %v", ErrInvalidKeyPair, err)
+		}
+	}
+	if k.NotAfter != "" {
+		if notAfter, err = time.Parse(time.RFC3339, k.NotAfter); err != nil {
+			return notBefore, notAfter, fmt.Errorf("%w: not_after: %v", ErrInvalidKeyPair, err)
+		}
+	}
+	return notBefore, notAfter, nil
+}
+
+func (k *KeyPairInfo) validatePolicy() error {
+	if _, _, err := k.validity(); err != nil {
+		return err
+	}
+	if k.MaxUses < 0 || k.Uses < 0 {
+		return fmt.Errorf("%w: negative max_uses or uses", ErrInvalidKeyPair)
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package sdk
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSignPolicy(t *testing.T) {
+	now := time.Now()
+	hour := time.Hour
+	tests := []struct {
+		name      string
+		notBefore time.Time
+		notAfter  time.Time
+		wantErr   error
+	}{
+		{"open", time.Time{}, time.Time{}, nil},
+		{"inside window", now.Add(-hour), now.Add(hour), nil},
+		{"open start", time.Time{}, now.Add(hour), nil},
+		{"expired", now.Add(-2 * hour), now.Add(-hour), ErrKeyExpired},
+		{"not yet valid", now.Add(hour), time.Time{}, ErrKeyNotYetValid},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := NewAccountInfo()
+			kp := newTestKeyPair(t, KeyTypeEd25519)
+			if err := kp.SetValidity(tt.notBefore, tt.notAfter); err != nil {
+				t.Fatal(err)
+			}
+			if err := a.AddKeyPair(PermOwner, kp); err != nil {
+				t.Fatal(err)
+			}
+			_, err := a.Sign(PermOwner, []byte("msg"))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Sign() = %v, want %v", err, tt.wantErr)
+			}
+			wantUses := 0
+			if tt.wantErr == nil {
+				wantUses = 1
+			}
+			if kp.Uses != wantUses {
+				t.Fatalf("uses = %v, want %v", kp.Uses, wantUses)
+			}
+		})
+	}
+}
+
+func TestSignUsageLimit(t *testing.T) {
+	s := NewFileAccountStore(t.TempDir())
+	a := NewAccountInfo()
+	a.Name = "alice"
+	kp := newTestKeyPair(t, KeyTypeEd25519)
+	if err := kp.SetMaxUses(3); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.AddKeyPair(PermOwner, kp); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := a.Sign(PermOwner, []byte("msg")); err != nil {
+			t.Fatalf("signature %v: %v", i+1, err)
+		}
+	}
+	// the count persists, so a reload cannot reset it
+	if err := s.SaveAccount(a); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := s.LoadAccount("alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := loaded.Keypairs[PermOwner].Uses; got != 2 {
+		t.Fatalf("loaded uses = %v, want 2", got)
+	}
+	if _, err := loaded.Sign(PermOwner, []byte("msg")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loaded.Sign(PermOwner, []byte("msg")); !errors.Is(err, ErrUsageLimitReached) {
+		t.Fatalf("fourth Sign() = %v, want %v", err, ErrUsageLimitReached)
+	}
+	if got := loaded.Keypairs[PermOwner].Uses; got != 3 {
+		t.Fatalf("uses = %v, want 3", got)
+	}
+	// lifting the cap allows signing again
+	if err := loaded.Keypairs[PermOwner].SetMaxUses(0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loaded.Sign(PermOwner, []byte("msg")); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPolicyRejects(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name string
+		run  func(kp *KeyPairInfo) error
+	}{
+		{"reversed window", func(kp *KeyPairInfo) error { return kp.SetValidity(now, now.Add(-time.Second)) }},
+		{"negative max uses", func(kp *KeyPairInfo) error { return kp.SetMaxUses(-1) }},
+		{"unparsable not_after", func(kp *KeyPairInfo) error {
+			kp.NotAfter = "tomorrow"
+			return kp.Validate()
+		}},
+		{"unparsable not_before on sign", func(kp *KeyPairInfo) error {
+			kp.NotBefore = "2020-01-01"
+			a := NewAccountInfo()
+			if err := a.AddKeyPair(PermOwner, kp); err != nil {
+				return err
+			}
+			_, err := a.Sign(PermOwner, []byte("msg"))
+			return err
+		}},
+		{"negative uses", func(kp *KeyPairInfo) error {
+			kp.Uses = -1
+			return kp.Validate()
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.run(newTestKeyPair(t, KeyTypeEd25519)); !errors.Is(err, ErrInvalidKeyPair) {
+				t.Fatalf("error = %v, want %v", err, ErrInvalidKeyPair)
+			}
+		})
+	}
+}
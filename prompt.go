@@ -0,0 +1,41 @@
+package sdk
+
+import (
+	"bytes"
+)
+
+// PasswordPrompter asks the user for a password. TerminalPrompter reads one
+// from the terminal; tests and GUIs can supply their own.
+type PasswordPrompter interface {
+	PromptPassword(prompt string) ([]byte, error)
+}
+
+// PromptNewPassword asks for a password twice, as when choosing one, and
+// fails with ErrPasswordMismatch unless both entries are the same.
+func PromptNewPassword(p PasswordPrompter, prompt string) ([]byte, error) {
+	first, err := p.PromptPassword(prompt)
+	if err != nil {
+		return nil, err
+	}
+	second, err := p.PromptPassword("Repeat " + prompt)
+	if err != nil {
+		wipeBytes(first)
+		return nil, err
+	}
+	defer wipeBytes(second)
+	if !bytes.Equal(first, second) {
+		wipeBytes(first)
+		return nil, ErrPasswordMismatch
+	}
+	return first, nil
+}
+
+// UnlockInteractive prompts for the account's password and decrypts it.
+func UnlockInteractive(a *AccountInfo, p PasswordPrompter) error {
+	password, err := p.PromptPassword("password for " + a.Name + ": ")
+	if err != nil {
+		return err
+	}
+	defer wipeBytes(password)
+	return a.Decrypt(password)
+}
@@ -0,0 +1,106 @@
+package sdk
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"reflect"
+	"testing"
+)
+
+var errNoAnswers = errors.New("no more answers")
+
+// scriptedPrompter answers prompts from a fixed list, failing once it runs
+// out.
+type scriptedPrompter struct {
+	answers []string
+	prompts []string
+}
+
+func (p *scriptedPrompter) PromptPassword(prompt string) ([]byte, error) {
+	p.prompts = append(p.prompts, prompt)
+	if len(p.answers) == 0 {
+		return nil, errNoAnswers
+	}
+	answer := p.answers[0]
+	p.answers = p.answers[1:]
+	return []byte(answer), nil
+}
+
+func TestPromptNewPassword(t *testing.T) {
+	tests := []struct {
+		name    string
+		answers []string
+		want    string
+		wantErr error
+	}{
+		{"match", []string{"hunter2", "hunter2"}, "hunter2", nil},
+		{"mismatch", []string{"hunter2", "hunter3"}, "", ErrPasswordMismatch},
+		{"no repeat", []string{"hunter2"}, "", errNoAnswers},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &scriptedPrompter{answers: tt.answers}
+			got, err := PromptNewPassword(p, "new password: ")
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("PromptNewPassword() = %q, %v, want %v", got, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || string(got) != tt.want {
+				t.Fatalf("PromptNewPassword() = %q, %v, want %q", got, err, tt.want)
+			}
+			if want := []string{"new password: ", "Repeat new password: "}; !reflect.DeepEqual(p.prompts, want) {
+				t.Fatalf("prompts = %q, want %q", p.prompts, want)
+			}
+		})
+	}
+}
+
+func TestUnlockInteractive(t *testing.T) {
+	tests := []struct {
+		name    string
+		answers []string
+		wantErr error
+	}{
+		{"right password", []string{"pw"}, nil},
+		{"wrong password", []string{"wrong"}, ErrWrongPassword},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestStore(t, "alice")
+			a, err := s.LoadAccount("alice")
+			if err != nil {
+				t.Fatal(err)
+			}
+			p := &scriptedPrompter{answers: tt.answers}
+			err = UnlockInteractive(a, p)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("UnlockInteractive() = %v, want %v", err, tt.wantErr)
+			}
+			if want := []string{"password for alice: "}; !reflect.DeepEqual(p.prompts, want) {
+				t.Fatalf("prompts = %q, want %q", p.prompts, want)
+			}
+			if locked := a.Keypairs[PermOwner].IsEncrypted(); locked != (tt.wantErr != nil) {
+				t.Fatalf("encrypted after unlock = %v", locked)
+			}
+		})
+	}
+}
+
+func TestTerminalPrompterNotTerminal(t *testing.T) {
+	f, err := os.CreateTemp(t.TempDir(), "stdin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var out bytes.Buffer
+	p := &TerminalPrompter{In: f, Out: &out}
+	if _, err := p.PromptPassword("password: "); err == nil {
+		t.Fatal("prompted on a regular file")
+	}
+	if out.Len() != 0 {
+		t.Fatalf("printed %q before failing", out.String())
+	}
+}
@@ -0,0 +1,83 @@
+package sdk
+
+// AccountView is an account with all secret material left out: no raw
+// keys, ciphertexts, salts or MACs. It is what to marshal into logs and API
+// responses; json.Marshal of an AccountInfo writes the keystore form,
+// plaintext keys included.
+type AccountView struct {
+	Name       string                         `json:"name"`
+	Address    string                         `json:"address,omitempty"`
+	Version    int                            `json:"version,omitempty"`
+	Keypairs   map[string]KeyPairView         `json:"keypairs"`
+	Thresholds map[string]*MultiKeyPermission `json:"thresholds,omitempty"`
+	Metadata   map[string]string              `json:"metadata,omitempty"`
+	Note       string                         `json:"notes,omitempty"`
+	CreatedAt  string                         `json:"created_at,omitempty"`
+	UpdatedAt  string                         `json:"updated_at,omitempty"`
+}
+
+// KeyPairView is the public part of a keypair, with how it is encrypted
+// in place of the encrypted key.
+type KeyPairView struct {
+	ID         string  `json:"kp_id"`
+	KeyType    KeyType `json:"key_type"`
+	PubKey     string  `json:"public_key"`
+	Label      string  `json:"label,omitempty"`
+	Path       string  `json:"path,omitempty"`
+	Encryption EncInfo `json:"encryption"`
+	WatchOnly  bool    `json:"watch_only,omitempty"`
+	NotBefore  string  `json:"not_before,omitempty"`
+	NotAfter   string  `json:"not_after,omitempty"`
+	MaxUses    int     `json:"max_uses,omitempty"`
+	Uses       int     `json:"uses,omitempty"`
+}
+
+// PublicView returns the keypair's KeyPairView. Encryption metadata that
+// does not parse is left out rather than failing the view.
+func (k *KeyPairInfo) PublicView() KeyPairView {
+	info, _ := k.EncryptionInfo()
+	return KeyPairView{
+		ID:         k.ID,
+		KeyType:    k.KeyType,
+		PubKey:     k.PubKey,
+		Label:      k.Label,
+		Path:       k.Path,
+		Encryption: info,
+		WatchOnly:  k.IsWatchOnly(),
+		NotBefore:  k.NotBefore,
+		NotAfter:   k.NotAfter,
+		MaxUses:    k.MaxUses,
+		Uses:       k.Uses,
+	}
+}
+
+// PublicView returns the account's AccountView, safe to marshal whether
+// the account is encrypted or not. Metadata and thresholds are copied.
+func (a *AccountInfo) PublicView() AccountView {
+	v := AccountView{
+		Name:      a.Name,
+		Version:   a.Version,
+		Keypairs:  make(map[string]KeyPairView, len(a.Keypairs)),
+		Note:      a.Note,
+		CreatedAt: a.CreatedAt,
+		UpdatedAt: a.UpdatedAt,
+	}
+	if a.Metadata != nil {
+		v.Metadata = a.Tags()
+	}
+	if a.Thresholds != nil {
+		v.Thresholds = make(map[string]*MultiKeyPermission, len(a.Thresholds))
+		for perm, m := range a.Thresholds {
+			if m != nil {
+				v.Thresholds[perm] = &MultiKeyPermission{PubKeys: append([]string(nil), m.PubKeys...), Threshold: m.Threshold}
+			}
+		}
+	}
+	v.Address, _ = a.Address()
+	for perm, kp := range a.Keypairs {
+		if kp != nil {
+			v.Keypairs[perm] = kp.PublicView()
+		}
+	}
+	return v
+}
@@ -0,0 +1,80 @@
+package sdk
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestPublicView(t *testing.T) {
+	tests := []struct {
+		name    string
+		encrypt bool
+	}{
+		{"decrypted", false},
+		{"encrypted", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := NewAccountInfo()
+			a.Name = "alice"
+			a.SetTag("tier", "hot")
+			a.SetNotes("laptop")
+			for _, perm := range []string{PermOwner, PermActive} {
+				kp := newTestKeyPair(t, KeyTypeEd25519)
+				kp.Label = perm + " key"
+				if err := a.AddKeyPair(perm, kp); err != nil {
+					t.Fatal(err)
+				}
+			}
+			// secrets is what must not show, whichever form the keys are in
+			var secrets []string
+			for _, kp := range a.Keypairs {
+				secrets = append(secrets, kp.RawKey.Reveal())
+			}
+			if tt.encrypt {
+				if err := a.EncryptWithOptions([]byte("pw"), EncryptOptions{Scrypt: fastScrypt}); err != nil {
+					t.Fatal(err)
+				}
+				for _, kp := range a.Keypairs {
+					secrets = append(secrets, kp.EncryptedKey, kp.Salt, kp.Mac)
+				}
+			} else if full, err := json.Marshal(a); err != nil || !strings.Contains(string(full), "raw_key") {
+				t.Fatalf("keystore form lacks the raw key: %s, %v", full, err)
+			}
+
+			v := a.PublicView()
+			data, err := json.Marshal(v)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, field := range []string{`"raw_key"`, `"encrypted_key"`, `"salt"`, `"mac"`} {
+				if strings.Contains(string(data), field) {
+					t.Fatalf("view has %v: %s", field, data)
+				}
+			}
+			for _, secret := range secrets {
+				if strings.Contains(string(data), secret) {
+					t.Fatalf("view shows secret %v: %s", secret, data)
+				}
+			}
+			addr, _ := a.Address()
+			if v.Name != "alice" || v.Address != addr || v.Note != "laptop" || v.Metadata["tier"] != "hot" {
+				t.Fatalf("view = %+v", v)
+			}
+			for perm, kp := range a.Keypairs {
+				kv := v.Keypairs[perm]
+				if kv.PubKey != kp.PubKey || kv.ID != kp.ID || kv.Label != perm+" key" {
+					t.Fatalf("%v: view = %+v", perm, kv)
+				}
+				if encrypted := kv.Encryption.Cipher != ""; encrypted != tt.encrypt {
+					t.Fatalf("%v: encryption = %+v", perm, kv.Encryption)
+				}
+			}
+			v.Metadata["tier"] = "cold"
+			if tier, _ := a.GetTag("tier"); tier != "hot" {
+				t.Fatal("view shares metadata with the account")
+			}
+		})
+	}
+}
@@ -0,0 +1,20 @@
+package sdk
+
+// readOnlyStore passes loads and lists through to the wrapped store.
+type readOnlyStore struct {
+	AccountStore
+}
+
+// ReadOnlyStore wraps s so that SaveAccount and DeleteAccount fail with
+// ErrReadOnly, for components that must never change keystores.
+func ReadOnlyStore(s AccountStore) AccountStore {
+	return readOnlyStore{s}
+}
+
+func (readOnlyStore) SaveAccount(a *AccountInfo) error {
+	return ErrReadOnly
+}
+
+func (readOnlyStore) DeleteAccount(name string) error {
+	return ErrReadOnly
+}
@@ -0,0 +1,53 @@
+package sdk
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestReadOnlyStore(t *testing.T) {
+	stores := []struct {
+		name  string
+		store func(t *testing.T) AccountStore
+	}{
+		{"file", func(t *testing.T) AccountStore { return newTestStore(t) }},
+		{"memory", func(t *testing.T) AccountStore { return NewMemoryAccountStore() }},
+	}
+	for _, tt := range stores {
+		t.Run(tt.name, func(t *testing.T) {
+			s := tt.store(t)
+			a := NewAccountInfo()
+			a.Name = "alice"
+			if err := a.AddKeyPair(PermOwner, newTestKeyPair(t, KeyTypeEd25519)); err != nil {
+				t.Fatal(err)
+			}
+			if err := s.SaveAccount(a); err != nil {
+				t.Fatal(err)
+			}
+			ro := ReadOnlyStore(s)
+			got, err := ro.LoadAccount("alice")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Keypairs[PermOwner].PubKey != a.Keypairs[PermOwner].PubKey {
+				t.Fatal("loaded a different account")
+			}
+			if accs, err := ro.ListAccounts(); err != nil || len(accs) != 1 || accs[0].Name != "alice" {
+				t.Fatalf("ListAccounts() = %v, %v", accs, err)
+			}
+
+			b := NewAccountInfo()
+			b.Name = "bob"
+			if err := ro.SaveAccount(b); !errors.Is(err, ErrReadOnly) {
+				t.Fatalf("SaveAccount() = %v, want %v", err, ErrReadOnly)
+			}
+			if err := ro.DeleteAccount("alice"); !errors.Is(err, ErrReadOnly) {
+				t.Fatalf("DeleteAccount() = %v, want %v", err, ErrReadOnly)
+			}
+			accs, err := s.ListAccounts()
+			if err != nil || len(accs) != 1 || accs[0].Name != "alice" {
+				t.Fatalf("wrapped store changed: %v, %v", accs, err)
+			}
+		})
+	}
+}
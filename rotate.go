@@ -0,0 +1,54 @@
+package sdk
+
+// RotateReport is the outcome of RotateKDF. Skipped accounts have no
+// encrypted keypairs.
+type RotateReport struct {
+	Rotated []string
+	Skipped []string
+	Failed  AccountErrors
+}
+
+// RotateKDF re-encrypts every account in the store with scrypt and
+// newParams, keeping each keypair's password, key size and cipher, and
+// saves it with the usual backup. passwordFor supplies each account's
+// password. A failing account is recorded in the report and the rest carry
+// on; the returned error is then the report's Failed.
+func (s *FileAccountStore) RotateKDF(passwordFor func(name string) ([]byte, error), newParams ScryptParams) (RotateReport, error) {
+	report := RotateReport{Rotated: []string{}, Skipped: []string{}, Failed: AccountErrors{}}
+	if err := newParams.validate(); err != nil {
+		return report, err
+	}
+	accs, err := s.ListAccounts()
+	if err != nil {
+		return report, err
+	}
+	for _, a := range accs {
+		if a.EncryptionState() == StateAllPlaintext {
+			report.Skipped = append(report.Skipped, a.Name)
+			continue
+		}
+		if err := s.rotateAccount(a, passwordFor, newParams); err != nil {
+			report.Failed[a.Name] = err
+			continue
+		}
+		report.Rotated = append(report.Rotated, a.Name)
+	}
+	if len(report.Failed) > 0 {
+		return report, report.Failed
+	}
+	return report, nil
+}
+
+func (s *FileAccountStore) rotateAccount(a *AccountInfo, passwordFor func(name string) ([]byte, error), params ScryptParams) error {
+	password, err := passwordFor(a.Name)
+	if err != nil {
+		return err
+	}
+	err = a.rekey(password, password, func(kp *KeyPairInfo) EncryptOptions {
+		return EncryptOptions{KeySize: kp.KeySize, KDF: KDFScrypt, Scrypt: params, Cipher: kp.Cipher}
+	})
+	if err != nil {
+		return err
+	}
+	return s.SaveAccount(a)
+}
@@ -0,0 +1,129 @@
+package sdk
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestRotateKDF(t *testing.T) {
+	s := NewFileAccountStore(t.TempDir())
+	accounts := []struct {
+		name string
+		opts *EncryptOptions
+	}{
+		{"ctr", &EncryptOptions{KeySize: 32, Scrypt: fastScrypt}},
+		{"gcm", &EncryptOptions{Scrypt: fastScrypt, Cipher: CipherAESGCM}},
+		{"plain", nil},
+	}
+	for _, acc := range accounts {
+		a := NewAccountInfo()
+		a.Name = acc.name
+		if err := a.AddKeyPair(PermOwner, newTestKeyPair(t, KeyTypeEd25519)); err != nil {
+			t.Fatal(err)
+		}
+		if acc.opts != nil {
+			if err := a.EncryptWithOptions([]byte("pw-"+acc.name), *acc.opts); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := s.SaveAccount(a); err != nil {
+			t.Fatal(err)
+		}
+	}
+	newParams := ScryptParams{N: 2 * minScryptN, R: 4, P: 2}
+	report, err := s.RotateKDF(func(name string) ([]byte, error) {
+		return []byte("pw-" + name), nil
+	}, newParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(report.Rotated)
+	if !reflect.DeepEqual(report.Rotated, []string{"ctr", "gcm"}) || !reflect.DeepEqual(report.Skipped, []string{"plain"}) {
+		t.Fatalf("report = %+v", report)
+	}
+	for _, acc := range accounts[:2] {
+		t.Run(acc.name, func(t *testing.T) {
+			a, err := s.LoadAccount(acc.name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			kp := a.Keypairs[PermOwner]
+			if *kp.ScryptParams != newParams {
+				t.Fatalf("scrypt params = %+v, want %+v", *kp.ScryptParams, newParams)
+			}
+			if kp.Cipher != acc.opts.Cipher {
+				t.Fatalf("cipher = %q, want %q", kp.Cipher, acc.opts.Cipher)
+			}
+			if want := acc.opts.KeySize; want != 0 && kp.KeySize != want {
+				t.Fatalf("key size = %v, want %v", kp.KeySize, want)
+			}
+			if kp.Cipher == CipherAESGCM {
+				// the AAD still binds the keypair to its account
+				c := kp.Clone()
+				if err := c.DecryptWithAAD([]byte("pw-"+acc.name), keyPairAAD("other", PermOwner, kp.KeyType)); !errors.Is(err, ErrWrongPassword) {
+					t.Fatalf("decrypting with another account's AAD = %v, want ErrWrongPassword", err)
+				}
+			}
+			if err := a.Decrypt([]byte("pw-" + acc.name)); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestRotateKDFFailures(t *testing.T) {
+	errNoPassword := errors.New("no password")
+	tests := []struct {
+		name    string
+		pw      func(name string) ([]byte, error)
+		wantErr error
+	}{
+		{"wrong password", func(name string) ([]byte, error) {
+			if name == "bob" {
+				return []byte("wrong"), nil
+			}
+			return []byte("pw"), nil
+		}, ErrWrongPassword},
+		{"callback fails", func(name string) ([]byte, error) {
+			if name == "bob" {
+				return nil, errNoPassword
+			}
+			return []byte("pw"), nil
+		}, errNoPassword},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestStore(t, "alice", "bob", "carol")
+			before, err := s.LoadAccount("bob")
+			if err != nil {
+				t.Fatal(err)
+			}
+			newParams := ScryptParams{N: 2 * minScryptN, R: 4, P: 2}
+			report, err := s.RotateKDF(tt.pw, newParams)
+			if !errors.Is(err, tt.wantErr) || !errors.Is(report.Failed["bob"], tt.wantErr) || len(report.Failed) != 1 {
+				t.Fatalf("RotateKDF() = %+v, %v, want bob failed with %v", report, err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(report.Rotated, []string{"alice", "carol"}) {
+				t.Fatalf("rotated %v, want the other accounts", report.Rotated)
+			}
+			for name, want := range map[string]ScryptParams{"alice": newParams, "bob": *before.Keypairs[PermOwner].ScryptParams, "carol": newParams} {
+				a, err := s.LoadAccount(name)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got := *a.Keypairs[PermOwner].ScryptParams; got != want {
+					t.Fatalf("%v: scrypt params = %+v, want %+v", name, got, want)
+				}
+				if err := a.Decrypt([]byte("pw")); err != nil {
+					t.Fatalf("%v: %v", name, err)
+				}
+			}
+		})
+	}
+	s := newTestStore(t)
+	if _, err := s.RotateKDF(nil, ScryptParams{N: 3}); err == nil {
+		t.Fatal("RotateKDF accepted invalid parameters")
+	}
+}
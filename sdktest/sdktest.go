@@ -0,0 +1,61 @@
+// Package sdktest builds reproducible keystore fixtures for tests of code
+// using the SDK. Never use its accounts for real funds: their keys follow
+// from a small seed.
+package sdktest
+
+import (
+	"github.com/google/uuid"
+	"github.com/quantosnetwork/dev-0.1.0/common"
+	"github.com/quantosnetwork/quantos-sdk"
+	"math/rand"
+)
+
+// FastScrypt is the cheapest scrypt setting the SDK accepts. MustEncrypt
+// uses it so tests do not spend seconds deriving keys.
+var FastScrypt = sdk.ScryptParams{N: 1 << 10, R: 8, P: 1}
+
+// GenerateTestAccount returns a plaintext account called name with ed25519
+// owner and active keypairs. The same name and seed always give the same
+// IDs and keys, so the keystore WriteTo produces is identical too.
+func GenerateTestAccount(name string, seed int64) *sdk.AccountInfo {
+	r := rand.New(rand.NewSource(seed))
+	a := sdk.NewAccountInfo()
+	a.Name = name
+	for _, perm := range []string{sdk.PermOwner, sdk.PermActive} {
+		raw := make([]byte, 32)
+		r.Read(raw)
+		kp, err := sdk.ImportPrivateKey(common.EncodeBase58(raw), string(sdk.KeyTypeEd25519))
+		if err != nil {
+			panic(err)
+		}
+		id, err := uuid.NewRandomFromReader(r)
+		if err != nil {
+			panic(err)
+		}
+		kp.ID = id.String()
+		if err := a.AddKeyPair(perm, kp); err != nil {
+			panic(err)
+		}
+	}
+	return a
+}
+
+// MustEncrypt encrypts every keypair of a with pw using FastScrypt and
+// returns a. It panics on failure. Salts are random, so the encrypted
+// keystore differs between runs even though the keys do not.
+func MustEncrypt(a *sdk.AccountInfo, pw string) *sdk.AccountInfo {
+	for _, perm := range a.Permissions() {
+		if err := a.Keypairs[perm].EncryptWithParams([]byte(pw), FastScrypt); err != nil {
+			panic(err)
+		}
+	}
+	return a
+}
+
+// MustDecrypt is the counterpart of MustEncrypt.
+func MustDecrypt(a *sdk.AccountInfo, pw string) *sdk.AccountInfo {
+	if err := a.Decrypt([]byte(pw)); err != nil {
+		panic(err)
+	}
+	return a
+}
@@ -0,0 +1,72 @@
+package sdktest
+
+import (
+	"bytes"
+	"github.com/quantosnetwork/quantos-sdk"
+	"testing"
+)
+
+func keystore(t *testing.T, a *sdk.AccountInfo) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if _, err := a.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestGenerateTestAccount(t *testing.T) {
+	base := keystore(t, GenerateTestAccount("alice", 1))
+	tests := []struct {
+		name string
+		seed int64
+		same bool
+	}{
+		{"alice", 1, true},
+		{"alice", 2, false},
+		{"bob", 1, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := GenerateTestAccount(tt.name, tt.seed)
+			for perm, kp := range a.Keypairs {
+				if err := kp.Validate(); err != nil {
+					t.Fatalf("%v: %v", perm, err)
+				}
+			}
+			if got := keystore(t, a); bytes.Equal(got, base) != tt.same {
+				t.Fatalf("keystore of %v/%v equal to alice/1: %v, want %v", tt.name, tt.seed, !tt.same, tt.same)
+			}
+		})
+	}
+	a, b := GenerateTestAccount("alice", 1), GenerateTestAccount("alice", 2)
+	for _, perm := range []string{sdk.PermOwner, sdk.PermActive} {
+		if a.Keypairs[perm].ID == b.Keypairs[perm].ID || a.Keypairs[perm].PubKey == b.Keypairs[perm].PubKey {
+			t.Fatalf("%v keypair does not depend on the seed", perm)
+		}
+	}
+}
+
+func TestMustEncryptDecrypt(t *testing.T) {
+	plain := keystore(t, GenerateTestAccount("alice", 7))
+	a := MustEncrypt(GenerateTestAccount("alice", 7), "pw")
+	if a.EncryptionState() != sdk.StateAllEncrypted {
+		t.Fatalf("state after MustEncrypt = %v", a.EncryptionState())
+	}
+	for _, kp := range a.Keypairs {
+		if *kp.ScryptParams != FastScrypt {
+			t.Fatalf("scrypt params = %+v, want %+v", *kp.ScryptParams, FastScrypt)
+		}
+	}
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Fatal("MustDecrypt with the wrong password did not panic")
+			}
+		}()
+		MustDecrypt(a, "wrong")
+	}()
+	if got := keystore(t, MustDecrypt(a, "pw")); !bytes.Equal(got, plain) {
+		t.Fatalf("decrypted keystore differs from the original:\n%s\n%s", got, plain)
+	}
+}
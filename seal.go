@@ -0,0 +1,173 @@
+package sdk
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/elliptic"
+	"encoding/json"
+	"fmt"
+	"github.com/quantosnetwork/dev-0.1.0/common"
+	"golang.org/x/crypto/hkdf"
+	"golang.org/x/crypto/sha3"
+	"io"
+	"lukechampine.com/frand"
+)
+
+// sealedAccount is the envelope written by Seal. The account is encrypted
+// under a random content key, which is in turn encrypted under a key agreed
+// between an ephemeral P-256 key and the recipient's.
+type sealedAccount struct {
+	Version    int    `json:"version"`
+	Recipient  string `json:"recipient"`
+	Ephemeral  string `json:"ephemeral"`
+	WrappedKey string `json:"wrapped_key"`
+	Ciphertext string `json:"ciphertext"`
+}
+
+// Seal encrypts the account for the holder of recipientPub, a base58
+// P-256 public key, so a keystore can be handed over without sharing its
+// password. Keypairs are sealed as they are, encrypted or not.
+func (a *AccountInfo) Seal(recipientPub string) ([]byte, error) {
+	curve := elliptic.P256()
+	rx, ry := elliptic.UnmarshalCompressed(curve, common.DecodeBase58(recipientPub))
+	if rx == nil {
+		return nil, fmt.Errorf("%w: recipient must be a p256 public key", ErrUnsupportedKey)
+	}
+	ephPriv, ephPub, err := p256Scheme{}.newKey("")
+	if err != nil {
+		return nil, err
+	}
+	defer wipeBytes(ephPriv)
+	sx, _ := curve.ScalarMult(rx, ry, ephPriv)
+	kek, err := sealKEK(sx.FillBytes(make([]byte, 32)), ephPub, common.DecodeBase58(recipientPub))
+	if err != nil {
+		return nil, err
+	}
+	defer wipeBytes(kek)
+
+	plain := *a
+	plain.stamp()
+	data, err := json.Marshal(&plain)
+	if err != nil {
+		return nil, err
+	}
+	defer wipeBytes(data)
+	contentKey := make([]byte, 32)
+	frand.Read(contentKey)
+	defer wipeBytes(contentKey)
+	ciphertext, err := gcmSeal(contentKey, data)
+	if err != nil {
+		return nil, err
+	}
+	wrapped, err := gcmSeal(kek, contentKey)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(sealedAccount{
+		Version:    1,
+		Recipient:  recipientPub,
+		Ephemeral:  common.EncodeBase58(ephPub),
+		WrappedKey: common.EncodeBase58(wrapped),
+		Ciphertext: common.EncodeBase58(ciphertext),
+	})
+}
+
+// Open reverses Seal with the recipient's keypair, which must be a
+// decrypted P-256 keypair. The core/account keys have no key agreement, so
+// they cannot be used here.
+func Open(data []byte, recipient *KeyPairInfo) (*AccountInfo, error) {
+	var env sealedAccount
+	if err := json.Unmarshal(data, &env); err != nil {
+		return nil, fmt.Errorf("invalid sealed account, %v", err)
+	}
+	if env.Version != 1 {
+		return nil, fmt.Errorf("unsupported sealed account version %v", env.Version)
+	}
+	if env.Recipient != recipient.PubKey {
+		return nil, fmt.Errorf("account was sealed for %v, not %v", env.Recipient, recipient.PubKey)
+	}
+	if recipient.KeyType != KeyTypeP256 {
+		return nil, fmt.Errorf("%w %v: key agreement needs p256", ErrUnsupportedKey, recipient.KeyType)
+	}
+	if recipient.IsWatchOnly() {
+		return nil, ErrWatchOnly
+	}
+	if recipient.IsEncrypted() {
+		return nil, ErrStillEncrypted
+	}
+	curve := elliptic.P256()
+	ephPub := common.DecodeBase58(env.Ephemeral)
+	ex, ey := elliptic.UnmarshalCompressed(curve, ephPub)
+	if ex == nil {
+		return nil, fmt.Errorf("invalid sealed account ephemeral key")
+	}
+	priv := recipient.RawKey.decode()
+	defer wipeBytes(priv)
+	if _, err := (p256Scheme{}).key(priv); err != nil {
+		return nil, err
+	}
+	sx, _ := curve.ScalarMult(ex, ey, priv)
+	kek, err := sealKEK(sx.FillBytes(make([]byte, 32)), ephPub, common.DecodeBase58(env.Recipient))
+	if err != nil {
+		return nil, err
+	}
+	defer wipeBytes(kek)
+	contentKey, err := gcmOpen(kek, common.DecodeBase58(env.WrappedKey))
+	if err != nil {
+		return nil, err
+	}
+	defer wipeBytes(contentKey)
+	plain, err := gcmOpen(contentKey, common.DecodeBase58(env.Ciphertext))
+	if err != nil {
+		return nil, err
+	}
+	defer wipeBytes(plain)
+	return LoadAccount(bytes.NewReader(plain))
+}
+
+// sealKEK derives the key encrypting the content key from the shared
+// secret, bound to both public keys.
+func sealKEK(shared, ephPub, recipientPub []byte) ([]byte, error) {
+	defer wipeBytes(shared)
+	salt := append(append([]byte{}, ephPub...), recipientPub...)
+	kek := make([]byte, 32)
+	if _, err := io.ReadFull(hkdf.New(sha3.New256, shared, salt, []byte("quantos sealed account")), kek); err != nil {
+		return nil, err
+	}
+	return kek, nil
+}
+
+// gcmSeal encrypts with AES-GCM, prefixing the random nonce.
+func gcmSeal(key, plaintext []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	frand.Read(nonce)
+	return gcm.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+func gcmOpen(key, sealed []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return nil, fmt.Errorf("sealed data too short")
+	}
+	plain, err := gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], nil)
+	if err != nil {
+		return nil, fmt.Errorf("sealed data failed authentication")
+	}
+	return plain, nil
+}
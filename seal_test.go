@@ -0,0 +1,86 @@
+package sdk
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestSealOpen(t *testing.T) {
+	recipient := newTestKeyPair(t, KeyTypeP256)
+	a := NewAccountInfo()
+	a.Name = "treasury"
+	if err := a.AddKeyPair(PermOwner, newTestKeyPair(t, KeyTypeEd25519)); err != nil {
+		t.Fatal(err)
+	}
+	encrypted := newTestKeyPair(t, KeyTypeEd25519)
+	if err := encrypted.EncryptWithParams([]byte("pw"), fastScrypt); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.AddKeyPair(PermActive, encrypted); err != nil {
+		t.Fatal(err)
+	}
+	data, err := a.Seal(recipient.PubKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(data, []byte(a.Keypairs[PermOwner].RawKey.Reveal())) {
+		t.Fatal("sealed envelope contains the raw key")
+	}
+	got, err := Open(data, recipient)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a.stamp()
+	if !reflect.DeepEqual(got, a) {
+		t.Fatalf("Open() = %+v, want %+v", got, a)
+	}
+	if err := got.Keypairs[PermActive].Decrypt([]byte("pw")); err != nil {
+		t.Fatalf("sealed encrypted keypair does not decrypt: %v", err)
+	}
+
+	other := newTestKeyPair(t, KeyTypeP256)
+	lockedRecipient := *recipient
+	if err := lockedRecipient.EncryptWithParams([]byte("pw"), fastScrypt); err != nil {
+		t.Fatal(err)
+	}
+	impostor := *other
+	impostor.PubKey = recipient.PubKey
+	var env map[string]interface{}
+	if err := json.Unmarshal(data, &env); err != nil {
+		t.Fatal(err)
+	}
+	env["ciphertext"] = env["wrapped_key"]
+	swapped, err := json.Marshal(env)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name      string
+		data      []byte
+		recipient *KeyPairInfo
+		wantErr   error
+	}{
+		{"other recipient", data, other, nil},
+		{"wrong private key", data, &impostor, nil},
+		{"encrypted recipient", data, &lockedRecipient, ErrStillEncrypted},
+		{"tampered envelope", swapped, recipient, nil},
+		{"not json", []byte("sealed"), recipient, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Open(tt.data, tt.recipient)
+			if err == nil {
+				t.Fatal("Open succeeded")
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Open() = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+	if _, err := a.Seal(newTestKeyPair(t, KeyTypeEd25519).PubKey); !errors.Is(err, ErrUnsupportedKey) {
+		t.Fatalf("Seal() to an ed25519 key = %v, want %v", err, ErrUnsupportedKey)
+	}
+}
@@ -0,0 +1,75 @@
+package sdk
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/quantosnetwork/dev-0.1.0/common"
+	"io"
+)
+
+const redacted = "[REDACTED]"
+
+// SecretKey holds base58 private key material. However it is printed or
+// marshaled on its own it comes out as [REDACTED]; Reveal is the only way
+// to read it back. KeyPairInfo still writes the real value to keystores.
+type SecretKey struct {
+	b []byte
+}
+
+func NewSecretKey(v string) SecretKey {
+	return SecretKey{b: []byte(v)}
+}
+
+func (s SecretKey) Reveal() string {
+	return string(s.b)
+}
+
+func (s SecretKey) IsEmpty() bool {
+	return len(s.b) == 0
+}
+
+func (s SecretKey) String() string {
+	return redacted
+}
+
+func (s SecretKey) GoString() string {
+	return redacted
+}
+
+func (s SecretKey) Format(f fmt.State, verb rune) {
+	io.WriteString(f, redacted)
+}
+
+func (s SecretKey) MarshalJSON() ([]byte, error) {
+	return json.Marshal(redacted)
+}
+
+func (s *SecretKey) UnmarshalJSON(data []byte) error {
+	var v string
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	s.b = []byte(v)
+	return nil
+}
+
+// decode returns the raw key bytes, which the caller should wipe.
+func (s SecretKey) decode() []byte {
+	return common.DecodeBase58(string(s.b))
+}
+
+// wipe zeroes the key in place and empties it.
+func (s *SecretKey) wipe() {
+	wipeBytes(s.b)
+	s.b = nil
+}
+
+// MarshalJSON writes the keypair with its real raw key, as keystore files
+// need it.
+func (k KeyPairInfo) MarshalJSON() ([]byte, error) {
+	type plain KeyPairInfo
+	return json.Marshal(struct {
+		plain
+		RawKey string `json:"raw_key,omitempty"`
+	}{plain(k), k.RawKey.Reveal()})
+}
@@ -0,0 +1,55 @@
+package sdk
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestSecretKeyRedacted(t *testing.T) {
+	kp := newTestKeyPair(t, KeyTypeEd25519)
+	secret := kp.RawKey.Reveal()
+	a := NewAccountInfo()
+	a.Name = "alice"
+	if err := a.AddKeyPair(PermOwner, kp); err != nil {
+		t.Fatal(err)
+	}
+	printed := map[string]string{
+		"%v kp":    fmt.Sprintf("%v", kp),
+		"%+v kp":   fmt.Sprintf("%+v", kp),
+		"%#v kp":   fmt.Sprintf("%#v", kp),
+		"%+v *kp":  fmt.Sprintf("%+v", *kp),
+		"%s key":   fmt.Sprintf("%s", kp.RawKey),
+		"%q key":   fmt.Sprintf("%q", kp.RawKey),
+		"%x key":   fmt.Sprintf("%x", kp.RawKey),
+		"%+v acct": fmt.Sprintf("%+v", a),
+		"String":   kp.RawKey.String(),
+		"GoString": kp.RawKey.GoString(),
+	}
+	keyJSON, err := json.Marshal(kp.RawKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	printed["json key"] = string(keyJSON)
+	for name, s := range printed {
+		if strings.Contains(s, secret) {
+			t.Fatalf("%v leaks the raw key: %v", name, s)
+		}
+	}
+	if !strings.Contains(printed["%+v *kp"], redacted) {
+		t.Fatalf("%%+v does not redact the raw key: %v", printed["%+v *kp"])
+	}
+
+	data, err := json.Marshal(kp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got KeyPairInfo
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.RawKey.Reveal() != secret {
+		t.Fatal("keystore JSON does not keep the raw key")
+	}
+}
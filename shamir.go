@@ -0,0 +1,188 @@
+package sdk
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"github.com/quantosnetwork/dev-0.1.0/common"
+)
+
+// Share is one part of a keypair's raw key split with SplitShares. Each
+// share carries what CombineShares needs besides the other shares, so it
+// can be written to its own file. Fewer than Threshold shares reveal
+// nothing about the key, but a share is still best kept as private as the
+// keystore itself.
+type Share struct {
+	KeyPairID string  `json:"kp_id"`
+	KeyType   KeyType `json:"key_type"`
+	PubKey    string  `json:"public_key"`
+	Threshold int     `json:"threshold"`
+	Index     int     `json:"index"`
+	Value     string  `json:"value"`
+}
+
+// SplitShares splits the decrypted raw key into n shares with Shamir's
+// secret sharing over GF(256), any threshold of which rebuild the keypair.
+// 2 <= threshold <= n <= 255. The keypair itself is left as it is.
+func (k *KeyPairInfo) SplitShares(n, threshold int) ([]Share, error) {
+	if threshold < 2 || threshold > n || n > 255 {
+		return nil, fmt.Errorf("invalid share split %v of %v, want 2 <= threshold <= n <= 255", threshold, n)
+	}
+	if k.IsWatchOnly() {
+		return nil, ErrWatchOnly
+	}
+	if k.IsEncrypted() {
+		return nil, ErrStillEncrypted
+	}
+	secret := k.RawKey.decode()
+	defer wipeBytes(secret)
+	if len(secret) == 0 {
+		return nil, fmt.Errorf("%w: raw key is not valid base58", ErrInvalidKeyPair)
+	}
+	// coeffs[i] holds the random coefficients of the polynomial for
+	// secret byte i, its constant term being the byte itself
+	coeffs := make([][]byte, len(secret))
+	for i := range secret {
+		coeffs[i] = make([]byte, threshold)
+		coeffs[i][0] = secret[i]
+		if _, err := rand.Read(coeffs[i][1:]); err != nil {
+			return nil, err
+		}
+	}
+	shares := make([]Share, n)
+	for s := range shares {
+		x := byte(s + 1)
+		y := make([]byte, len(secret))
+		for i, c := range coeffs {
+			y[i] = gfEval(c, x)
+		}
+		shares[s] = Share{
+			KeyPairID: k.ID,
+			KeyType:   k.KeyType,
+			PubKey:    k.PubKey,
+			Threshold: threshold,
+			Index:     int(x),
+			Value:     common.EncodeBase58(y),
+		}
+		wipeBytes(y)
+	}
+	for _, c := range coeffs {
+		wipeBytes(c)
+	}
+	return shares, nil
+}
+
+// CombineShares rebuilds the plaintext keypair from at least Threshold
+// shares of one SplitShares call. The rebuilt key is checked against the
+// public key the shares carry, so mixed up or damaged shares fail with
+// ErrPublicKeyMismatch rather than producing a wrong key.
+func CombineShares(shares []Share) (*KeyPairInfo, error) {
+	if len(shares) == 0 {
+		return nil, ErrNotEnoughShares
+	}
+	first := shares[0]
+	if first.Threshold < 2 || first.Threshold > 255 {
+		return nil, fmt.Errorf("invalid share threshold %v", first.Threshold)
+	}
+	if len(shares) < first.Threshold {
+		return nil, fmt.Errorf("%w: have %v, need %v", ErrNotEnoughShares, len(shares), first.Threshold)
+	}
+	shares = shares[:first.Threshold]
+	xs := make([]byte, len(shares))
+	ys := make([][]byte, len(shares))
+	defer func() {
+		for _, y := range ys {
+			wipeBytes(y)
+		}
+	}()
+	seen := make(map[int]bool, len(shares))
+	for i, sh := range shares {
+		if sh.KeyPairID != first.KeyPairID || sh.KeyType != first.KeyType || sh.PubKey != first.PubKey || sh.Threshold != first.Threshold {
+			return nil, fmt.Errorf("share %v belongs to another keypair or split", sh.Index)
+		}
+		if sh.Index < 1 || sh.Index > 255 || seen[sh.Index] {
+			return nil, fmt.Errorf("invalid or repeated share index %v", sh.Index)
+		}
+		seen[sh.Index] = true
+		y, err := decodeSealed("share value", sh.Value)
+		if err != nil {
+			return nil, err
+		}
+		if i > 0 && len(y) != len(ys[0]) {
+			return nil, fmt.Errorf("share %v has a different length", sh.Index)
+		}
+		xs[i], ys[i] = byte(sh.Index), y
+	}
+	secret := make([]byte, len(ys[0]))
+	defer wipeBytes(secret)
+	for b := range secret {
+		secret[b] = gfInterpolateZero(xs, ys, b)
+	}
+	kp := &KeyPairInfo{
+		ID:      first.KeyPairID,
+		KeyType: first.KeyType,
+		PubKey:  first.PubKey,
+		RawKey:  NewSecretKey(common.EncodeBase58(secret)),
+	}
+	if err := kp.verifyPublicKey(secret); err != nil && !errors.Is(err, ErrUnsupportedKey) {
+		kp.Wipe()
+		return nil, err
+	}
+	if err := kp.Validate(); err != nil {
+		kp.Wipe()
+		return nil, err
+	}
+	return kp, nil
+}
+
+// gfEval evaluates the polynomial with coefficients c, lowest first, at x.
+func gfEval(c []byte, x byte) byte {
+	var y byte
+	for i := len(c) - 1; i >= 0; i-- {
+		y = gfMul(y, x) ^ c[i]
+	}
+	return y
+}
+
+// gfInterpolateZero is the Lagrange interpolation at 0 of byte b of the
+// points (xs[i], ys[i][b]).
+func gfInterpolateZero(xs []byte, ys [][]byte, b int) byte {
+	var result byte
+	for i := range xs {
+		num, den := byte(1), byte(1)
+		for j := range xs {
+			if i != j {
+				num = gfMul(num, xs[j])
+				den = gfMul(den, xs[i]^xs[j])
+			}
+		}
+		result ^= gfMul(ys[i][b], gfMul(num, gfInv(den)))
+	}
+	return result
+}
+
+// gfMul multiplies in GF(256) with the AES polynomial x^8+x^4+x^3+x+1.
+func gfMul(a, b byte) byte {
+	var p byte
+	for b > 0 {
+		if b&1 != 0 {
+			p ^= a
+		}
+		carry := a & 0x80
+		a <<= 1
+		if carry != 0 {
+			a ^= 0x1b
+		}
+		b >>= 1
+	}
+	return p
+}
+
+// gfInv is a^254, the inverse of a non-zero a.
+func gfInv(a byte) byte {
+	r := byte(1)
+	for i := 0; i < 254; i++ {
+		r = gfMul(r, a)
+	}
+	return r
+}
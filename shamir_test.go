@@ -0,0 +1,183 @@
+package sdk
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/quantosnetwork/dev-0.1.0/common"
+	"testing"
+)
+
+// subsets returns every k-element subset of shares.
+func subsets(shares []Share, k int) [][]Share {
+	if k == 0 {
+		return [][]Share{nil}
+	}
+	var out [][]Share
+	for i := 0; i <= len(shares)-k; i++ {
+		for _, rest := range subsets(shares[i+1:], k-1) {
+			out = append(out, append([]Share{shares[i]}, rest...))
+		}
+	}
+	return out
+}
+
+func TestSplitShares(t *testing.T) {
+	tests := []struct {
+		keyType      KeyType
+		n, threshold int
+	}{
+		{KeyTypeEd25519, 2, 2},
+		{KeyTypeEd25519, 3, 2},
+		{KeyTypeEd25519, 5, 3},
+		{KeyTypeP256, 4, 4},
+		{KeyTypeEd25519, 255, 255},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%v/%v-of-%v", tt.keyType, tt.threshold, tt.n), func(t *testing.T) {
+			kp := newTestKeyPair(t, tt.keyType)
+			shares, err := kp.SplitShares(tt.n, tt.threshold)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(shares) != tt.n {
+				t.Fatalf("%v shares, want %v", len(shares), tt.n)
+			}
+			// each share stands on its own once serialized
+			for i, sh := range shares {
+				data, err := json.Marshal(sh)
+				if err != nil {
+					t.Fatal(err)
+				}
+				shares[i] = Share{}
+				if err := json.Unmarshal(data, &shares[i]); err != nil {
+					t.Fatal(err)
+				}
+			}
+			sets := [][]Share{shares, shares[tt.n-tt.threshold:]}
+			if tt.n <= 5 {
+				sets = subsets(shares, tt.threshold)
+			}
+			for _, set := range sets {
+				got, err := CombineShares(set)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got.RawKey.Reveal() != kp.RawKey.Reveal() || got.ID != kp.ID || got.PubKey != kp.PubKey || got.KeyType != kp.KeyType {
+					t.Fatalf("shares %v rebuilt another keypair", set)
+				}
+			}
+
+			short := shares[:tt.threshold-1]
+			if _, err := CombineShares(short); !errors.Is(err, ErrNotEnoughShares) {
+				t.Fatalf("CombineShares() of %v shares = %v, want ErrNotEnoughShares", len(short), err)
+			}
+			// padding too few shares with a made up one rebuilds some other
+			// key, which the public key check catches
+			forged := shares[tt.threshold-1]
+			forged.Value = common.EncodeBase58(make([]byte, len(kp.RawKey.decode())))
+			if _, err := CombineShares(append(short[:len(short):len(short)], forged)); !errors.Is(err, ErrPublicKeyMismatch) {
+				t.Fatalf("CombineShares() with a forged share = %v, want ErrPublicKeyMismatch", err)
+			}
+		})
+	}
+}
+
+func TestSplitSharesRejects(t *testing.T) {
+	encrypted := newTestKeyPair(t, KeyTypeEd25519)
+	if err := encrypted.EncryptWithParams([]byte("pw"), fastScrypt); err != nil {
+		t.Fatal(err)
+	}
+	watchOnly, err := NewWatchOnlyKeyPair(newTestKeyPair(t, KeyTypeEd25519).PubKey, "ed25519")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name         string
+		kp           *KeyPairInfo
+		n, threshold int
+		wantErr      error
+	}{
+		{"threshold 1", newTestKeyPair(t, KeyTypeEd25519), 3, 1, nil},
+		{"threshold above n", newTestKeyPair(t, KeyTypeEd25519), 2, 3, nil},
+		{"too many shares", newTestKeyPair(t, KeyTypeEd25519), 256, 2, nil},
+		{"encrypted", encrypted, 3, 2, ErrStillEncrypted},
+		{"watch-only", watchOnly, 3, 2, ErrWatchOnly},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shares, err := tt.kp.SplitShares(tt.n, tt.threshold)
+			if err == nil || tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Fatalf("SplitShares() = %v, %v, want %v", shares, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCombineSharesRejects(t *testing.T) {
+	kp := newTestKeyPair(t, KeyTypeEd25519)
+	shares, err := kp.SplitShares(3, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	others, err := newTestKeyPair(t, KeyTypeEd25519).SplitShares(3, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name    string
+		shares  func() []Share
+		wantErr error
+	}{
+		{"none", func() []Share { return nil }, ErrNotEnoughShares},
+		{"other keypair", func() []Share { return []Share{shares[0], others[1]} }, nil},
+		{"repeated index", func() []Share { return []Share{shares[0], shares[0]} }, nil},
+		{"index 0", func() []Share {
+			sh := shares[1]
+			sh.Index = 0
+			return []Share{shares[0], sh}
+		}, nil},
+		{"bad threshold", func() []Share {
+			sh := shares[0]
+			sh.Threshold = 1
+			return []Share{sh}
+		}, nil},
+		{"short value", func() []Share {
+			sh := shares[1]
+			sh.Value = common.EncodeBase58([]byte{1, 2, 3})
+			return []Share{shares[0], sh}
+		}, nil},
+		{"corrupt value", func() []Share {
+			sh := shares[1]
+			sh.Value = "0OIl"
+			return []Share{shares[0], sh}
+		}, ErrCorruptKeystore},
+		{"damaged value", func() []Share {
+			sh := shares[1]
+			y := common.DecodeBase58(sh.Value)
+			y[0] ^= 1
+			sh.Value = common.EncodeBase58(y)
+			return []Share{shares[0], sh}
+		}, ErrPublicKeyMismatch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := CombineShares(tt.shares())
+			if err == nil || tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Fatalf("CombineShares() = %v, %v, want %v", got, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestGF256(t *testing.T) {
+	// the multiplication example of FIPS 197, section 4.2
+	if got := gfMul(0x57, 0x83); got != 0xc1 {
+		t.Fatalf("gfMul(0x57, 0x83) = %#x, want 0xc1", got)
+	}
+	for a := 1; a < 256; a++ {
+		if got := gfMul(byte(a), gfInv(byte(a))); got != 1 {
+			t.Fatalf("%#x * gfInv(%#x) = %#x, want 1", a, a, got)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package sdk
+
+import (
+	"lukechampine.com/frand"
+	"os"
+)
+
+// SecureDeleteAccount overwrites the account's keystore with random bytes,
+// syncs it and only then removes it.
+//
+// This only helps on file systems that overwrite in place. Copy-on-write
+// file systems (btrfs, ZFS, APFS), journals, snapshots and SSD wear
+// levelling can all keep the old blocks around, so it is no substitute for
+// full disk encryption.
+func (s *FileAccountStore) SecureDeleteAccount(name string) error {
+	err := s.secureDelete(name, false)
+	s.audit(AuditDelete, name, err)
+	return err
+}
+
+// SecureDeleteAccountAndBackups is SecureDeleteAccount that also shreds
+// every backup of the account.
+func (s *FileAccountStore) SecureDeleteAccountAndBackups(name string) error {
+	err := s.secureDelete(name, true)
+	s.audit(AuditDelete, name, err)
+	return err
+}
+
+func (s *FileAccountStore) secureDelete(name string, backups bool) error {
+	if err := s.writable(); err != nil {
+		return err
+	}
+	unlock, err := s.lockAccount(name)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	f := s.AccountDir + "/" + s.keystoreFile(name)
+	if err := shredFile(f); err != nil {
+		return err
+	}
+	currentLogger().Infof("file %v has been shredded", f)
+	if !backups {
+		return nil
+	}
+	list, err := s.ListBackups(name)
+	if err != nil {
+		return err
+	}
+	for _, b := range list {
+		if err := shredFile(b.Path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// shredFile overwrites fileName with random data before removing it.
+func shredFile(fileName string) error {
+	fi, err := os.Stat(fileName)
+	if err != nil {
+		return err
+	}
+	// keystores are read-only
+	if err := os.Chmod(fileName, 0600); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(fileName, os.O_WRONLY, 0)
+	if err != nil {
+		return err
+	}
+	buf := make([]byte, 4096)
+	for left := fi.Size(); left > 0 && err == nil; {
+		n := int64(len(buf))
+		if left < n {
+			n = left
+		}
+		frand.Read(buf[:n])
+		_, err = f.Write(buf[:n])
+		left -= n
+	}
+	if err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Remove(fileName)
+}
@@ -0,0 +1,65 @@
+package sdk
+
+import (
+	"bytes"
+	"errors"
+	"io/fs"
+	"os"
+	"testing"
+)
+
+func TestSecureDeleteAccount(t *testing.T) {
+	tests := []struct {
+		name        string
+		del         func(s *FileAccountStore, name string) error
+		wantBackups int
+	}{
+		{"keystore only", (*FileAccountStore).SecureDeleteAccount, 1},
+		{"with backups", (*FileAccountStore).SecureDeleteAccountAndBackups, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewFileAccountStore(t.TempDir())
+			writeTestAccounts(t, s, 2)
+			a, err := s.LoadAccount("acc000")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := s.SaveAccount(a); err != nil {
+				t.Fatal(err)
+			}
+			before, err := os.ReadFile(s.path("acc000.json"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			// a second link keeps the inode around to inspect what was left
+			link := t.TempDir() + "/link"
+			if err := os.Link(s.path("acc000.json"), link); err != nil {
+				t.Skipf("hard links unsupported: %v", err)
+			}
+			if err := tt.del(s, "acc000"); err != nil {
+				t.Fatal(err)
+			}
+			if ok, err := s.HasAccount("acc000"); ok || err != nil {
+				t.Fatalf("HasAccount() after delete = %v, %v", ok, err)
+			}
+			after, err := os.ReadFile(link)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(after) != len(before) || bytes.Equal(after, before) || bytes.Contains(after, []byte(a.Keypairs[PermOwner].RawKey.Reveal())) {
+				t.Fatal("keystore contents were not overwritten")
+			}
+			backups, err := s.ListBackups("acc000")
+			if err != nil || len(backups) != tt.wantBackups {
+				t.Fatalf("ListBackups() = %v, %v, want %v", backups, err, tt.wantBackups)
+			}
+			if ok, _ := s.HasAccount("acc001"); !ok {
+				t.Fatal("unrelated account was deleted")
+			}
+			if err := tt.del(s, "acc000"); !errors.Is(err, fs.ErrNotExist) {
+				t.Fatalf("deleting again = %v, want %v", err, fs.ErrNotExist)
+			}
+		})
+	}
+}
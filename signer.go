@@ -0,0 +1,113 @@
+package sdk
+
+import (
+	"fmt"
+)
+
+// Signer produces signatures for a single public key. A decrypted
+// *KeyPairInfo is one; hardware wallets and other external signers that
+// never hand out their private key can implement it too.
+type Signer interface {
+	// PublicKey returns the base58 public key signatures verify against.
+	PublicKey() string
+	Sign(message []byte) ([]byte, error)
+}
+
+func (k *KeyPairInfo) PublicKey() string {
+	return k.PubKey
+}
+
+// Sign signs message with the raw key, failing with ErrStillEncrypted if
+// the keypair has not been decrypted and ErrWatchOnly if it has no private
+// key at all.
+func (k *KeyPairInfo) Sign(message []byte) ([]byte, error) {
+	if k.IsWatchOnly() {
+		return nil, ErrWatchOnly
+	}
+	if k.IsEncrypted() {
+		return nil, ErrStillEncrypted
+	}
+	raw := k.RawKey.decode()
+	defer wipeBytes(raw)
+	return signMessage(k.KeyType, raw, message)
+}
+
+// PasswordFunc is asked for a password each time one is needed.
+type PasswordFunc func() ([]byte, error)
+
+// keyPairSigner decrypts a copy of an encrypted keypair for every signature
+// and wipes it straight after.
+type keyPairSigner struct {
+	kp       *KeyPairInfo
+	password PasswordFunc
+	aad      []byte
+}
+
+// NewKeyPairSigner returns a Signer over kp that, while kp is encrypted,
+// obtains the password from password and decrypts on demand. kp itself is
+// never decrypted. An aes-gcm keypair fails to sign with ErrAADRequired;
+// AccountInfo.KeyPairSigner handles those.
+func NewKeyPairSigner(kp *KeyPairInfo, password PasswordFunc) Signer {
+	return &keyPairSigner{kp: kp, password: password}
+}
+
+// KeyPairSigner is NewKeyPairSigner for the keypair of perm, decrypting it
+// with the associated data it was sealed over.
+func (a *AccountInfo) KeyPairSigner(perm string, password PasswordFunc) (Signer, error) {
+	kp, ok := a.Keypairs[perm]
+	if !ok {
+		return nil, fmt.Errorf("%w %v", ErrInvalidPermission, perm)
+	}
+	return &keyPairSigner{kp: kp, password: password, aad: a.keyPairAAD(perm, kp)}, nil
+}
+
+func (s *keyPairSigner) PublicKey() string {
+	return s.kp.PubKey
+}
+
+func (s *keyPairSigner) Sign(message []byte) ([]byte, error) {
+	if !s.kp.IsEncrypted() {
+		return s.kp.Sign(message)
+	}
+	password, err := s.password()
+	if err != nil {
+		return nil, err
+	}
+	plain := *s.kp
+	if err := plain.DecryptWithAAD(password, s.aad); err != nil {
+		return nil, err
+	}
+	defer plain.Wipe()
+	return plain.Sign(message)
+}
+
+// RegisterSigner adds an externally held key under perm. The keystore only
+// records its public key; the signer has to be registered again each time
+// the account is loaded.
+func (a *AccountInfo) RegisterSigner(perm string, keyType KeyType, s Signer) error {
+	kt, err := ParseKeyType(string(keyType))
+	if err != nil {
+		return err
+	}
+	if err := a.AddKeyPair(perm, &KeyPairInfo{ID: newKeyPairID(), KeyType: kt, PubKey: s.PublicKey()}); err != nil {
+		return err
+	}
+	if a.signers == nil {
+		a.signers = make(map[string]Signer)
+	}
+	a.signers[perm] = s
+	return nil
+}
+
+// Signer returns what AccountInfo.Sign uses for perm: a registered signer
+// or else the keypair itself.
+func (a *AccountInfo) Signer(perm string) (Signer, error) {
+	if s, ok := a.signers[perm]; ok {
+		return s, nil
+	}
+	kp, ok := a.Keypairs[perm]
+	if !ok {
+		return nil, fmt.Errorf("%w %v", ErrInvalidPermission, perm)
+	}
+	return kp, nil
+}
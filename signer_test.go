@@ -0,0 +1,112 @@
+package sdk
+
+import (
+	"errors"
+	"testing"
+)
+
+// mockSigner stands in for a hardware wallet, holding a key the account
+// never sees.
+type mockSigner struct {
+	kp    *KeyPairInfo
+	calls int
+}
+
+func (m *mockSigner) PublicKey() string {
+	return m.kp.PubKey
+}
+
+func (m *mockSigner) Sign(message []byte) ([]byte, error) {
+	m.calls++
+	return m.kp.Sign(message)
+}
+
+func TestRegisterSigner(t *testing.T) {
+	device := &mockSigner{kp: newTestKeyPair(t, KeyTypeEd25519)}
+	a := NewAccountInfo()
+	a.Name = "alice"
+	if err := a.AddKeyPair(PermActive, newTestKeyPair(t, KeyTypeEd25519)); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.RegisterSigner(PermOwner, KeyTypeEd25519, device); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.RegisterSigner(PermOwner, KeyTypeEd25519, device); !errors.Is(err, ErrPermissionExists) {
+		t.Fatalf("registering twice = %v, want %v", err, ErrPermissionExists)
+	}
+	if err := a.RegisterSigner("other", "rsa", device); !errors.Is(err, ErrUnsupportedKey) {
+		t.Fatalf("registering an unknown key type = %v, want %v", err, ErrUnsupportedKey)
+	}
+	if kp := a.Keypairs[PermOwner]; !kp.IsWatchOnly() || kp.PubKey != device.PublicKey() {
+		t.Fatalf("keystore entry for the signer is %+v, want only its public key", kp)
+	}
+
+	sig, err := a.Sign(PermOwner, []byte("msg"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if device.calls != 1 {
+		t.Fatalf("device signed %v times, want 1", device.calls)
+	}
+	if ok, err := VerifySignature(device.PublicKey(), []byte("msg"), sig); !ok || err != nil {
+		t.Fatalf("device signature does not verify: %v", err)
+	}
+
+	// encrypting the account leaves the device-backed keypair alone
+	if err := a.EncryptWithOptions([]byte("pw"), EncryptOptions{Scrypt: fastScrypt}); err != nil {
+		t.Fatal(err)
+	}
+	if a.Keypairs[PermOwner].IsEncrypted() || !a.Keypairs[PermActive].IsEncrypted() {
+		t.Fatal("Encrypt touched the wrong keypairs")
+	}
+	if _, err := a.Sign(PermOwner, []byte("msg")); err != nil {
+		t.Fatalf("device signing after Encrypt: %v", err)
+	}
+	if _, err := a.Sign(PermActive, []byte("msg")); !errors.Is(err, ErrStillEncrypted) {
+		t.Fatalf("Sign() with an encrypted keypair = %v, want %v", err, ErrStillEncrypted)
+	}
+
+	// on a fresh load only the public key is left
+	clone := a.Clone()
+	clone.signers = nil
+	if _, err := clone.Sign(PermOwner, []byte("msg")); !errors.Is(err, ErrWatchOnly) {
+		t.Fatalf("Sign() without the registered signer = %v, want %v", err, ErrWatchOnly)
+	}
+}
+
+func TestKeyPairSigner(t *testing.T) {
+	kp := newTestKeyPair(t, KeyTypeP256)
+	if err := kp.EncryptWithParams([]byte("pw"), fastScrypt); err != nil {
+		t.Fatal(err)
+	}
+	errNoPassword := errors.New("no password")
+	tests := []struct {
+		name     string
+		password PasswordFunc
+		wantErr  error
+	}{
+		{"right password", func() ([]byte, error) { return []byte("pw"), nil }, nil},
+		{"wrong password", func() ([]byte, error) { return []byte("nope"), nil }, ErrWrongPassword},
+		{"prompt fails", func() ([]byte, error) { return nil, errNoPassword }, errNoPassword},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewKeyPairSigner(kp, tt.password)
+			if s.PublicKey() != kp.PubKey {
+				t.Fatalf("PublicKey() = %v, want %v", s.PublicKey(), kp.PubKey)
+			}
+			sig, err := s.Sign([]byte("msg"))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Sign() = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil {
+				if ok, err := VerifySignature(kp.PubKey, []byte("msg"), sig); !ok || err != nil {
+					t.Fatalf("signature does not verify: %v", err)
+				}
+			}
+			if !kp.IsEncrypted() || !kp.RawKey.IsEmpty() {
+				t.Fatal("signing decrypted the keypair itself")
+			}
+		})
+	}
+}
@@ -0,0 +1,209 @@
+package sdk
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/subtle"
+	"encoding/binary"
+	"fmt"
+	"golang.org/x/crypto/sha3"
+	"hash"
+	"io"
+	"lukechampine.com/frand"
+)
+
+// streamMagic starts every EncryptStream output.
+var streamMagic = []byte("QKS1")
+
+// StreamChunkSize is how much plaintext EncryptStream seals per chunk, and
+// so roughly the memory either direction needs.
+const StreamChunkSize = 64 << 10
+
+// finalChunk flags the length of the last chunk, so that a stream cut at a
+// chunk boundary is still detected.
+const finalChunk = 1 << 31
+
+// EncryptStream encrypts all of src to dst with AES-256-CTR under a key
+// derived from password with scrypt at DefaultScryptParams. The output is
+// a header followed by chunks of at most StreamChunkSize bytes, each
+// authenticated by a SHA3 MAC over everything before it, so reordered,
+// altered or truncated chunks fail DecryptStream.
+//
+// Layout: "QKS1", scrypt N, R and P as big endian uint32, the 48 byte salt
+// and IV, a 32 byte password check, then for each chunk a big endian
+// uint32 length (high bit set on the last one), the ciphertext and its
+// 32 byte MAC.
+func EncryptStream(dst io.Writer, src io.Reader, password []byte) error {
+	params := DefaultScryptParams
+	hdr := streamHeader(params)
+	salt := make([]byte, saltSize)
+	frand.Read(salt)
+	hdr = append(hdr, salt...)
+	s, err := newStreamCipher(password, params, salt, hdr)
+	if err != nil {
+		return err
+	}
+	defer s.wipe()
+	if _, err := dst.Write(append(hdr, s.check()...)); err != nil {
+		return err
+	}
+	cur := make([]byte, StreamChunkSize)
+	defer wipeBytes(cur)
+	next := make([]byte, StreamChunkSize)
+	defer wipeBytes(next)
+	// read a chunk ahead to know which one is the last
+	n, eof, err := fillChunk(src, cur)
+	if err != nil {
+		return err
+	}
+	for {
+		m := 0
+		if !eof {
+			if m, eof, err = fillChunk(src, next); err != nil {
+				return err
+			}
+		}
+		last := eof && m == 0
+		if err := s.writeChunk(dst, cur[:n], last); err != nil {
+			return err
+		}
+		if last {
+			return nil
+		}
+		cur, next, n = next, cur, m
+	}
+}
+
+// fillChunk reads into buf until it is full or src ends.
+func fillChunk(src io.Reader, buf []byte) (int, bool, error) {
+	n, err := io.ReadFull(src, buf)
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return n, true, nil
+	}
+	return n, false, err
+}
+
+// DecryptStream reverses EncryptStream. Each chunk is authenticated before
+// its plaintext is written, but a stream failing part way has already
+// written the chunks before the failure, so dst should be discarded on
+// error. A wrong password fails with ErrWrongPassword before anything is
+// written; damage fails with ErrCorruptKeystore.
+func DecryptStream(dst io.Writer, src io.Reader, password []byte) error {
+	hdr := make([]byte, len(streamMagic)+12+saltSize)
+	if _, err := io.ReadFull(src, hdr); err != nil {
+		return fmt.Errorf("%w: stream header: %v", ErrCorruptKeystore, err)
+	}
+	if !bytes.Equal(hdr[:len(streamMagic)], streamMagic) {
+		return fmt.Errorf("%w: not an encrypted stream", ErrCorruptKeystore)
+	}
+	p := hdr[len(streamMagic):]
+	params := ScryptParams{
+		N: int(binary.BigEndian.Uint32(p[0:4])),
+		R: int(binary.BigEndian.Uint32(p[4:8])),
+		P: int(binary.BigEndian.Uint32(p[8:12])),
+	}
+	salt := p[12:]
+	s, err := newStreamCipher(password, params, salt, hdr)
+	if err != nil {
+		return err
+	}
+	defer s.wipe()
+	check := make([]byte, macSize)
+	if _, err := io.ReadFull(src, check); err != nil {
+		return fmt.Errorf("%w: stream header: %v", ErrCorruptKeystore, err)
+	}
+	if subtle.ConstantTimeCompare(check, s.check()) != 1 {
+		return ErrWrongPassword
+	}
+	buf := make([]byte, StreamChunkSize+macSize)
+	defer wipeBytes(buf)
+	var length [4]byte
+	for i := 0; ; i++ {
+		if _, err := io.ReadFull(src, length[:]); err != nil {
+			return fmt.Errorf("%w: stream truncated before chunk %v", ErrCorruptKeystore, i)
+		}
+		last := binary.BigEndian.Uint32(length[:])&finalChunk != 0
+		n := int(binary.BigEndian.Uint32(length[:]) &^ finalChunk)
+		if n > StreamChunkSize {
+			return fmt.Errorf("%w: chunk %v is %v bytes", ErrCorruptKeystore, i, n)
+		}
+		chunk := buf[:n+macSize]
+		if _, err := io.ReadFull(src, chunk); err != nil {
+			return fmt.Errorf("%w: chunk %v truncated", ErrCorruptKeystore, i)
+		}
+		s.mac.Write(length[:])
+		s.mac.Write(chunk[:n])
+		if subtle.ConstantTimeCompare(s.mac.Sum(nil), chunk[n:]) != 1 {
+			return fmt.Errorf("%w: chunk %v failed authentication", ErrCorruptKeystore, i)
+		}
+		s.stream.XORKeyStream(chunk[:n], chunk[:n])
+		if _, err := dst.Write(chunk[:n]); err != nil {
+			return err
+		}
+		if last {
+			break
+		}
+	}
+	var extra [1]byte
+	if n, _ := src.Read(extra[:]); n > 0 {
+		return fmt.Errorf("%w: data after the last chunk", ErrCorruptKeystore)
+	}
+	return nil
+}
+
+func streamHeader(params ScryptParams) []byte {
+	hdr := make([]byte, len(streamMagic)+12)
+	copy(hdr, streamMagic)
+	for i, v := range []int{params.N, params.R, params.P} {
+		binary.BigEndian.PutUint32(hdr[len(streamMagic)+4*i:], uint32(v))
+	}
+	return hdr
+}
+
+// streamCipher is the CTR stream and running MAC of one encrypted stream.
+type streamCipher struct {
+	key    []byte
+	stream cipher.Stream
+	mac    hash.Hash
+}
+
+func newStreamCipher(password []byte, params ScryptParams, salt, hdr []byte) (*streamCipher, error) {
+	kdf := KeyPairInfo{KDF: KDFScrypt, ScryptParams: &params}
+	key, err := kdf.deriveKey(password, salt[0:32], 64)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key[0:32])
+	if err != nil {
+		wipeBytes(key)
+		return nil, err
+	}
+	mac := sha3.New256()
+	mac.Write(key[32:64])
+	mac.Write(hdr)
+	return &streamCipher{key: key, stream: cipher.NewCTR(block, salt[32:48]), mac: mac}, nil
+}
+
+// check is the MAC of the header alone, telling a wrong password from
+// damaged chunks.
+func (s *streamCipher) check() []byte {
+	return s.mac.Sum(nil)
+}
+
+func (s *streamCipher) writeChunk(dst io.Writer, plain []byte, last bool) error {
+	n := uint32(len(plain))
+	if last {
+		n |= finalChunk
+	}
+	out := make([]byte, 4+len(plain), 4+len(plain)+macSize)
+	binary.BigEndian.PutUint32(out, n)
+	s.stream.XORKeyStream(out[4:], plain)
+	s.mac.Write(out)
+	_, err := dst.Write(s.mac.Sum(out))
+	return err
+}
+
+func (s *streamCipher) wipe() {
+	wipeBytes(s.key)
+}
@@ -0,0 +1,111 @@
+package sdk
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// maxWriter fails the test if any single write exceeds max bytes.
+type maxWriter struct {
+	t   *testing.T
+	buf bytes.Buffer
+	max int
+}
+
+func (w *maxWriter) Write(p []byte) (int, error) {
+	if len(p) > w.max {
+		w.t.Fatalf("wrote %v bytes at once, want at most %v", len(p), w.max)
+	}
+	return w.buf.Write(p)
+}
+
+// streamData returns n bytes that differ from chunk to chunk.
+func streamData(n int) []byte {
+	data := make([]byte, n)
+	for i := range data {
+		data[i] = byte(i*7 + i/StreamChunkSize)
+	}
+	return data
+}
+
+func TestEncryptStream(t *testing.T) {
+	for _, size := range []int{0, 1, StreamChunkSize - 1, StreamChunkSize, StreamChunkSize + 1, 3<<20 + 7} {
+		t.Run(fmt.Sprint(size), func(t *testing.T) {
+			data := streamData(size)
+			enc := &maxWriter{t: t, max: 4 + StreamChunkSize + macSize}
+			if err := EncryptStream(enc, bytes.NewReader(data), []byte("pw")); err != nil {
+				t.Fatal(err)
+			}
+			if size > 64 && bytes.Contains(enc.buf.Bytes(), data[:64]) {
+				t.Fatal("stream holds the plaintext")
+			}
+			dec := &maxWriter{t: t, max: StreamChunkSize}
+			if err := DecryptStream(dec, bytes.NewReader(enc.buf.Bytes()), []byte("pw")); err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(dec.buf.Bytes(), data) {
+				t.Fatal("decrypted stream differs")
+			}
+		})
+	}
+}
+
+func TestDecryptStreamCorrupt(t *testing.T) {
+	var enc bytes.Buffer
+	if err := EncryptStream(&enc, bytes.NewReader(streamData(3*StreamChunkSize+100)), []byte("pw")); err != nil {
+		t.Fatal(err)
+	}
+	stream := enc.Bytes()
+	const first = len("QKS1") + 12 + saltSize + macSize
+	const chunk = 4 + StreamChunkSize + macSize
+	tests := []struct {
+		name     string
+		password string
+		damage   func(b []byte) []byte
+		wantErr  error
+	}{
+		{"wrong password", "wrong", func(b []byte) []byte { return b }, ErrWrongPassword},
+		{"not a stream", "pw", func(b []byte) []byte { return append([]byte("QKS0"), b[4:]...) }, ErrCorruptKeystore},
+		{"short header", "pw", func(b []byte) []byte { return b[:first-1] }, ErrCorruptKeystore},
+		{"altered kdf params", "pw", func(b []byte) []byte {
+			b[7] ^= 1
+			return b
+		}, nil},
+		{"flipped ciphertext bit", "pw", func(b []byte) []byte {
+			b[first+chunk+10] ^= 1
+			return b
+		}, ErrCorruptKeystore},
+		{"flipped mac bit", "pw", func(b []byte) []byte {
+			b[first+2*chunk-1] ^= 1
+			return b
+		}, ErrCorruptKeystore},
+		{"oversized chunk length", "pw", func(b []byte) []byte {
+			b[first] = 0x7f
+			return b
+		}, ErrCorruptKeystore},
+		{"swapped chunks", "pw", func(b []byte) []byte {
+			out := append([]byte(nil), b[:first]...)
+			out = append(out, b[first+chunk:first+2*chunk]...)
+			out = append(out, b[first:first+chunk]...)
+			return append(out, b[first+2*chunk:]...)
+		}, ErrCorruptKeystore},
+		{"last chunk dropped", "pw", func(b []byte) []byte { return b[:first+3*chunk] }, ErrCorruptKeystore},
+		{"cut mid chunk", "pw", func(b []byte) []byte { return b[:first+chunk+100] }, ErrCorruptKeystore},
+		{"data after the end", "pw", func(b []byte) []byte { return append(b, 0) }, ErrCorruptKeystore},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := tt.damage(append([]byte(nil), stream...))
+			var dec bytes.Buffer
+			err := DecryptStream(&dec, bytes.NewReader(b), []byte(tt.password))
+			if err == nil || tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Fatalf("DecryptStream() = %v, want %v", err, tt.wantErr)
+			}
+			if errors.Is(err, ErrWrongPassword) && dec.Len() != 0 {
+				t.Fatalf("wrote %v bytes before rejecting the password", dec.Len())
+			}
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package sdk
+
+// AccountSummary is what account lists show: public keys and their labels,
+// encryption state and timestamps, but no key material, salt or MAC.
+type AccountSummary struct {
+	Name       string            `json:"name"`
+	PubKeys    map[string]string `json:"pubkeys"`
+	Labels     map[string]string `json:"labels,omitempty"`
+	Encryption string            `json:"encryption"`
+	CreatedAt  string            `json:"created_at,omitempty"`
+	UpdatedAt  string            `json:"updated_at,omitempty"`
+}
+
+// Summary returns the account's AccountSummary.
+func (a *AccountInfo) Summary() AccountSummary {
+	sum := AccountSummary{
+		Name:       a.Name,
+		PubKeys:    make(map[string]string, len(a.Keypairs)),
+		Encryption: a.EncryptionState().String(),
+		CreatedAt:  a.CreatedAt,
+		UpdatedAt:  a.UpdatedAt,
+	}
+	for perm, kp := range a.Keypairs {
+		sum.PubKeys[perm] = kp.PubKey
+		if kp.Label != "" {
+			if sum.Labels == nil {
+				sum.Labels = make(map[string]string)
+			}
+			sum.Labels[perm] = kp.Label
+		}
+	}
+	return sum
+}
+
+// ListAccountSummaries is ListAccounts reduced to summaries, sorted by
+// name. Plaintext raw keys read along the way are wiped.
+func (s *FileAccountStore) ListAccountSummaries() ([]AccountSummary, error) {
+	accs, err := s.ListAccounts()
+	if err != nil {
+		return nil, err
+	}
+	sums := make([]AccountSummary, len(accs))
+	for i, a := range accs {
+		sums[i] = a.Summary()
+		a.Wipe()
+	}
+	return sums, nil
+}
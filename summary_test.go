@@ -0,0 +1,70 @@
+package sdk
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestListAccountSummaries(t *testing.T) {
+	s := NewFileAccountStore(t.TempDir())
+	pubs := map[string]string{}
+	for _, acc := range []struct {
+		name    string
+		encrypt bool
+	}{
+		{"bob", false},
+		{"alice", true},
+	} {
+		a := NewAccountInfo()
+		a.Name = acc.name
+		kp := newTestKeyPair(t, KeyTypeEd25519)
+		kp.Label = "hot"
+		pubs[acc.name] = kp.PubKey
+		if err := a.AddKeyPair(PermOwner, kp); err != nil {
+			t.Fatal(err)
+		}
+		if acc.encrypt {
+			if err := a.EncryptWithOptions([]byte("pw"), EncryptOptions{Scrypt: fastScrypt}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := s.SaveAccount(a); err != nil {
+			t.Fatal(err)
+		}
+	}
+	sums, err := s.ListAccountSummaries()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name       string
+		encryption string
+	}{
+		{"alice", "encrypted"},
+		{"bob", "plaintext"},
+	}
+	if len(sums) != len(tests) {
+		t.Fatalf("%v summaries, want %v", len(sums), len(tests))
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sum := sums[i]
+			if sum.Name != tt.name || sum.Encryption != tt.encryption || sum.PubKeys[PermOwner] != pubs[tt.name] || sum.Labels[PermOwner] != "hot" {
+				t.Fatalf("summary = %+v", sum)
+			}
+			if sum.CreatedAt == "" || sum.UpdatedAt == "" {
+				t.Fatalf("summary has no timestamps: %+v", sum)
+			}
+			data, err := json.Marshal(sum)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, field := range []string{"raw_key", "encrypted_key", "salt", "mac", "scrypt_params"} {
+				if strings.Contains(string(data), field) {
+					t.Fatalf("summary JSON %s contains %v", data, field)
+				}
+			}
+		})
+	}
+}
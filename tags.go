@@ -0,0 +1,52 @@
+package sdk
+
+// SetTag sets the metadata tag key to value.
+func (a *AccountInfo) SetTag(key, value string) {
+	if a.Metadata == nil {
+		a.Metadata = make(map[string]string)
+	}
+	a.Metadata[key] = value
+}
+
+// GetTag returns the value of tag key and whether it is set.
+func (a *AccountInfo) GetTag(key string) (string, bool) {
+	v, ok := a.Metadata[key]
+	return v, ok
+}
+
+func (a *AccountInfo) DeleteTag(key string) {
+	delete(a.Metadata, key)
+}
+
+// Tags returns a copy of the account's metadata.
+func (a *AccountInfo) Tags() map[string]string {
+	tags := make(map[string]string, len(a.Metadata))
+	for k, v := range a.Metadata {
+		tags[k] = v
+	}
+	return tags
+}
+
+func (a *AccountInfo) SetNotes(notes string) {
+	a.Note = notes
+}
+
+func (a *AccountInfo) Notes() string {
+	return a.Note
+}
+
+// ListAccountsByTag is ListAccounts narrowed to accounts whose tag key is
+// set to value.
+func (s *FileAccountStore) ListAccountsByTag(key, value string) ([]*AccountInfo, error) {
+	accs, err := s.ListAccounts()
+	if err != nil {
+		return nil, err
+	}
+	matched := make([]*AccountInfo, 0)
+	for _, a := range accs {
+		if v, ok := a.GetTag(key); ok && v == value {
+			matched = append(matched, a)
+		}
+	}
+	return matched, nil
+}
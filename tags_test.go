@@ -0,0 +1,150 @@
+package sdk
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestTags(t *testing.T) {
+	a := NewAccountInfo()
+	if _, ok := a.GetTag("tier"); ok {
+		t.Fatal("new account has a tier tag")
+	}
+	a.SetTag("tier", "cold")
+	a.SetTag("team", "treasury")
+	a.SetTag("tier", "hot")
+	a.SetTag("empty", "")
+	tests := []struct {
+		key    string
+		want   string
+		wantOK bool
+	}{
+		{"tier", "hot", true},
+		{"team", "treasury", true},
+		{"empty", "", true},
+		{"missing", "", false},
+	}
+	for _, tt := range tests {
+		if v, ok := a.GetTag(tt.key); v != tt.want || ok != tt.wantOK {
+			t.Fatalf("GetTag(%q) = %q, %v, want %q, %v", tt.key, v, ok, tt.want, tt.wantOK)
+		}
+	}
+	tags := a.Tags()
+	tags["tier"] = "changed"
+	if v, _ := a.GetTag("tier"); v != "hot" {
+		t.Fatal("Tags() does not return a copy")
+	}
+	a.DeleteTag("empty")
+	if got := fmt.Sprint(a.Tags()); got != "map[team:treasury tier:hot]" {
+		t.Fatalf("Tags() = %v", got)
+	}
+}
+
+func TestListAccountsByTag(t *testing.T) {
+	s := NewFileAccountStore(t.TempDir())
+	tiers := map[string]string{"alice": "cold", "bob": "hot", "carol": "cold", "dave": ""}
+	for name, tier := range tiers {
+		a := NewAccountInfo()
+		a.Name = name
+		if err := a.AddKeyPair(PermOwner, newTestKeyPair(t, KeyTypeEd25519)); err != nil {
+			t.Fatal(err)
+		}
+		if tier != "" {
+			a.SetTag("tier", tier)
+		}
+		// tags are plaintext and survive encryption
+		if err := a.EncryptWithOptions([]byte("pw"), EncryptOptions{Scrypt: fastScrypt}); err != nil {
+			t.Fatal(err)
+		}
+		if err := s.SaveAccount(a); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tests := []struct {
+		key, value string
+		want       string
+	}{
+		{"tier", "cold", "[alice carol]"},
+		{"tier", "hot", "[bob]"},
+		{"tier", "warm", "[]"},
+		{"team", "", "[]"},
+	}
+	for _, tt := range tests {
+		accs, err := s.ListAccountsByTag(tt.key, tt.value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		names := make([]string, len(accs))
+		for i, a := range accs {
+			names[i] = a.Name
+		}
+		if got := fmt.Sprint(names); got != tt.want {
+			t.Fatalf("ListAccountsByTag(%q, %q) = %v, want %v", tt.key, tt.value, got, tt.want)
+		}
+	}
+	a, err := s.DecryptAccount("alice", []byte("pw"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := a.GetTag("tier"); v != "cold" {
+		t.Fatalf("tag after decrypting = %q, want cold", v)
+	}
+}
+
+func TestNotes(t *testing.T) {
+	tests := []struct {
+		name  string
+		notes string
+		opts  EncryptOptions
+	}{
+		{"empty", "", EncryptOptions{Scrypt: fastScrypt}},
+		{"plain", "hardware backup in safe #3", EncryptOptions{Scrypt: fastScrypt}},
+		{"aes-gcm", "hardware backup in safe #3", EncryptOptions{Scrypt: fastScrypt, Cipher: CipherAESGCM}},
+		{"multiline json", "{\"raw_key\": \"x\"}\n\tsecond line ✓", EncryptOptions{Scrypt: fastScrypt}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewFileAccountStore(t.TempDir())
+			a := NewAccountInfo()
+			a.Name = "alice"
+			kp := newTestKeyPair(t, KeyTypeEd25519)
+			raw := kp.RawKey.Reveal()
+			if err := a.AddKeyPair(PermOwner, kp); err != nil {
+				t.Fatal(err)
+			}
+			a.SetNotes(tt.notes)
+			if err := a.EncryptWithOptions([]byte("pw"), tt.opts); err != nil {
+				t.Fatal(err)
+			}
+			if err := s.SaveAccount(a); err != nil {
+				t.Fatal(err)
+			}
+			got, err := s.LoadAccount("alice")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Notes() != tt.notes {
+				t.Fatalf("loaded notes = %q, want %q", got.Notes(), tt.notes)
+			}
+			// notes are not part of what the password protects
+			got.SetNotes("changed after encryption")
+			if err := got.Decrypt([]byte("pw")); err != nil {
+				t.Fatal(err)
+			}
+			if got.Keypairs[PermOwner].RawKey.Reveal() != raw {
+				t.Fatal("notes leaked into the key")
+			}
+			got.SetNotes(tt.notes)
+			if err := s.SaveAccount(got); err != nil {
+				t.Fatal(err)
+			}
+			again, err := s.LoadAccount("alice")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if again.Notes() != tt.notes {
+				t.Fatalf("notes after decrypting and saving = %q, want %q", again.Notes(), tt.notes)
+			}
+		})
+	}
+}
@@ -0,0 +1,31 @@
+package sdk
+
+import (
+	"fmt"
+	"golang.org/x/term"
+	"io"
+	"os"
+)
+
+// TerminalPrompter reads passwords from a terminal without echoing them.
+// The prompt goes to Out so it does not mix with a program's output.
+type TerminalPrompter struct {
+	In  *os.File
+	Out io.Writer
+}
+
+// NewTerminalPrompter prompts on stderr and reads from stdin.
+func NewTerminalPrompter() *TerminalPrompter {
+	return &TerminalPrompter{In: os.Stdin, Out: os.Stderr}
+}
+
+func (t *TerminalPrompter) PromptPassword(prompt string) ([]byte, error) {
+	fd := int(t.In.Fd())
+	if !term.IsTerminal(fd) {
+		return nil, fmt.Errorf("cannot prompt for a password: %v is not a terminal", t.In.Name())
+	}
+	fmt.Fprint(t.Out, prompt)
+	password, err := term.ReadPassword(fd)
+	fmt.Fprintln(t.Out)
+	return password, err
+}
@@ -0,0 +1,102 @@
+package sdk
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ThrottledAccount guards an account against password guessing, e.g. in a
+// server that unlocks keystores on request. After Threshold wrong passwords
+// in a row further attempts are refused with ErrThrottled for BaseDelay,
+// doubling with each wrong password after that up to MaxDelay. A correct
+// password resets the count. The state is kept in memory only.
+type ThrottledAccount struct {
+	Threshold int
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+
+	account *AccountInfo
+
+	mu          sync.Mutex
+	failures    int
+	lockedUntil time.Time
+}
+
+// NewThrottledAccount allows three wrong passwords, then backs off from one
+// second to at most five minutes.
+func NewThrottledAccount(a *AccountInfo) *ThrottledAccount {
+	return &ThrottledAccount{
+		Threshold: 3,
+		BaseDelay: time.Second,
+		MaxDelay:  5 * time.Minute,
+		account:   a,
+	}
+}
+
+// Decrypt decrypts the account unless it is locked out.
+func (t *ThrottledAccount) Decrypt(password []byte) error {
+	return t.attempt(func() error { return t.account.Decrypt(password) })
+}
+
+// VerifyPassword checks password as AccountInfo.VerifyPassword does,
+// counting a false result as a failure.
+func (t *ThrottledAccount) VerifyPassword(password []byte) (bool, error) {
+	var ok bool
+	err := t.attempt(func() error {
+		var err error
+		ok, err = t.account.VerifyPassword(password)
+		if err == nil && !ok {
+			err = ErrWrongPassword
+		}
+		return err
+	})
+	if errors.Is(err, ErrWrongPassword) {
+		return false, nil
+	}
+	return ok, err
+}
+
+// RetryAfter is how long attempts are refused for, zero when they are not.
+func (t *ThrottledAccount) RetryAfter() time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if d := time.Until(t.lockedUntil); d > 0 {
+		return d
+	}
+	return 0
+}
+
+// attempt runs try while holding the lock, so guesses are made one at a
+// time and none slips past a lockout.
+func (t *ThrottledAccount) attempt(try func() error) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if d := time.Until(t.lockedUntil); d > 0 {
+		return fmt.Errorf("%w in %v", ErrThrottled, d.Round(time.Millisecond))
+	}
+	err := try()
+	switch {
+	case err == nil:
+		t.failures = 0
+		t.lockedUntil = time.Time{}
+	case errors.Is(err, ErrWrongPassword):
+		t.failures++
+		if t.failures >= t.Threshold {
+			t.lockedUntil = time.Now().Add(t.delay())
+		}
+	}
+	return err
+}
+
+func (t *ThrottledAccount) delay() time.Duration {
+	d := t.BaseDelay
+	for i := t.Threshold; i < t.failures && d < t.MaxDelay; i++ {
+		d *= 2
+	}
+	if t.MaxDelay > 0 && d > t.MaxDelay {
+		d = t.MaxDelay
+	}
+	return d
+}
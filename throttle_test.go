@@ -0,0 +1,69 @@
+package sdk
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestThrottledAccount(t *testing.T) {
+	a, err := newTestStore(t, "alice").LoadAccount("alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ta := NewThrottledAccount(a)
+	ta.BaseDelay, ta.MaxDelay = 50*time.Millisecond, time.Second
+	for i := 0; i < ta.Threshold; i++ {
+		if ta.RetryAfter() != 0 {
+			t.Fatalf("locked out after %v wrong passwords", i)
+		}
+		if ok, err := ta.VerifyPassword([]byte("wrong")); ok || err != nil {
+			t.Fatalf("VerifyPassword(wrong) = %v, %v", ok, err)
+		}
+	}
+	if d := ta.RetryAfter(); d <= 0 || d > ta.BaseDelay {
+		t.Fatalf("RetryAfter() = %v after %v wrong passwords, want up to %v", d, ta.Threshold, ta.BaseDelay)
+	}
+	// even the right password is refused while locked out
+	if ok, err := ta.VerifyPassword([]byte("pw")); ok || !errors.Is(err, ErrThrottled) {
+		t.Fatalf("VerifyPassword() while locked = %v, %v, want %v", ok, err, ErrThrottled)
+	}
+	if err := ta.Decrypt([]byte("pw")); !errors.Is(err, ErrThrottled) {
+		t.Fatalf("Decrypt() while locked = %v, want %v", err, ErrThrottled)
+	}
+	time.Sleep(ta.RetryAfter())
+	// one more wrong password doubles the delay
+	if err := ta.Decrypt([]byte("wrong")); !errors.Is(err, ErrWrongPassword) {
+		t.Fatalf("Decrypt(wrong) = %v, want %v", err, ErrWrongPassword)
+	}
+	if d := ta.RetryAfter(); d <= ta.BaseDelay || d > 2*ta.BaseDelay {
+		t.Fatalf("RetryAfter() = %v, want up to %v", d, 2*ta.BaseDelay)
+	}
+	time.Sleep(ta.RetryAfter())
+	if err := ta.Decrypt([]byte("pw")); err != nil {
+		t.Fatal(err)
+	}
+	if a.IsEncrypted() || ta.RetryAfter() != 0 || ta.failures != 0 {
+		t.Fatalf("successful unlock left encrypted %v, retry after %v, %v failures", a.IsEncrypted(), ta.RetryAfter(), ta.failures)
+	}
+}
+
+func TestThrottleDelay(t *testing.T) {
+	ta := &ThrottledAccount{Threshold: 3, BaseDelay: time.Second, MaxDelay: 5 * time.Second}
+	tests := []struct {
+		failures int
+		want     time.Duration
+	}{
+		{3, time.Second},
+		{4, 2 * time.Second},
+		{5, 4 * time.Second},
+		{6, 5 * time.Second},
+		{100, 5 * time.Second},
+	}
+	for _, tt := range tests {
+		ta.failures = tt.failures
+		if got := ta.delay(); got != tt.want {
+			t.Fatalf("delay() after %v failures = %v, want %v", tt.failures, got, tt.want)
+		}
+	}
+}
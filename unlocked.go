@@ -0,0 +1,91 @@
+package sdk
+
+import (
+	"sync"
+	"time"
+)
+
+// UnlockedAccount keeps a decrypted copy of an account for a limited time,
+// so interactive tools need not ask for the password on every signature.
+// The wrapped account itself is never decrypted; when the TTL runs out or
+// Lock is called the copy's raw keys are wiped.
+type UnlockedAccount struct {
+	account *AccountInfo
+
+	mu    sync.Mutex
+	plain *AccountInfo
+	timer *time.Timer
+}
+
+func NewUnlockedAccount(a *AccountInfo) *UnlockedAccount {
+	return &UnlockedAccount{account: a}
+}
+
+// Unlock decrypts the account with password and keeps it unlocked for ttl.
+// Unlocking again replaces the previous copy and restarts the timer.
+func (u *UnlockedAccount) Unlock(password []byte, ttl time.Duration) error {
+	plain := u.account.Clone()
+	for perm, c := range plain.Keypairs {
+		if !c.IsEncrypted() {
+			continue
+		}
+		if err := plain.decryptKeyPair(perm, c, password); err != nil {
+			plain.Wipe()
+			return err
+		}
+	}
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.lock()
+	u.plain = plain
+	u.timer = time.AfterFunc(ttl, func() {
+		u.mu.Lock()
+		defer u.mu.Unlock()
+		// a timer that fired while Unlock was replacing the copy must not
+		// lock the new one
+		if u.plain == plain {
+			u.lock()
+		}
+	})
+	return nil
+}
+
+// Lock wipes the decrypted copy straight away.
+func (u *UnlockedAccount) Lock() {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.lock()
+}
+
+func (u *UnlockedAccount) lock() {
+	if u.timer != nil {
+		u.timer.Stop()
+		u.timer = nil
+	}
+	if u.plain != nil {
+		u.plain.Wipe()
+		u.plain = nil
+	}
+}
+
+func (u *UnlockedAccount) IsLocked() bool {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return u.plain == nil
+}
+
+// Sign signs with the unlocked copy, failing with ErrStillEncrypted once
+// the account has been locked again. Usage counts are carried over to the
+// wrapped account, so saving it records them.
+func (u *UnlockedAccount) Sign(perm string, message []byte) ([]byte, error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if u.plain == nil {
+		return nil, ErrStillEncrypted
+	}
+	sig, err := u.plain.Sign(perm, message)
+	if err == nil && u.account.Keypairs[perm] != nil && u.plain.Keypairs[perm] != nil {
+		u.account.Keypairs[perm].Uses = u.plain.Keypairs[perm].Uses
+	}
+	return sig, err
+}
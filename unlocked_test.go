@@ -0,0 +1,69 @@
+package sdk
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestUnlockedAccount(t *testing.T) {
+	a := NewAccountInfo()
+	a.Name = "alice"
+	if err := a.AddKeyPair(PermOwner, newTestKeyPair(t, KeyTypeEd25519)); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.EncryptWithOptions([]byte("pw"), EncryptOptions{Scrypt: fastScrypt}); err != nil {
+		t.Fatal(err)
+	}
+	u := NewUnlockedAccount(a)
+	if !u.IsLocked() {
+		t.Fatal("new UnlockedAccount is unlocked")
+	}
+	if err := u.Unlock([]byte("wrong"), time.Hour); !errors.Is(err, ErrWrongPassword) {
+		t.Fatalf("Unlock() with a wrong password = %v, want %v", err, ErrWrongPassword)
+	}
+
+	tests := []struct {
+		name string
+		ttl  time.Duration
+		lock func(u *UnlockedAccount)
+	}{
+		{"expiry", 200 * time.Millisecond, func(*UnlockedAccount) {}},
+		{"Lock", time.Hour, (*UnlockedAccount).Lock},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := u.Unlock([]byte("pw"), tt.ttl); err != nil {
+				t.Fatal(err)
+			}
+			u.mu.Lock()
+			plain := u.plain.Keypairs[PermOwner]
+			u.mu.Unlock()
+			sig, err := u.Sign(PermOwner, []byte("msg"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ok, err := VerifySignature(a.Keypairs[PermOwner].PubKey, []byte("msg"), sig); !ok || err != nil {
+				t.Fatalf("signature does not verify: %v", err)
+			}
+			if !a.Keypairs[PermOwner].IsEncrypted() {
+				t.Fatal("Unlock decrypted the wrapped account")
+			}
+			tt.lock(u)
+			for deadline := time.Now().Add(5 * time.Second); !u.IsLocked(); time.Sleep(10 * time.Millisecond) {
+				if time.Now().After(deadline) {
+					t.Fatal("account is still unlocked")
+				}
+			}
+			if !plain.RawKey.IsEmpty() {
+				t.Fatal("raw key of the unlocked copy was not wiped")
+			}
+			if _, err := u.Sign(PermOwner, []byte("msg")); !errors.Is(err, ErrStillEncrypted) {
+				t.Fatalf("Sign() after locking = %v, want %v", err, ErrStillEncrypted)
+			}
+		})
+	}
+	if a.Keypairs[PermOwner].Uses != 2 {
+		t.Fatalf("wrapped account counts %v uses, want 2", a.Keypairs[PermOwner].Uses)
+	}
+}
@@ -0,0 +1,127 @@
+package sdk
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ProblemKind categorises an AccountProblem.
+type ProblemKind string
+
+const (
+	ProblemUnparseable       ProblemKind = "unparseable"
+	ProblemChecksum          ProblemKind = "checksum"
+	ProblemInvalidKeyPair    ProblemKind = "invalid_keypair"
+	ProblemMissingMAC        ProblemKind = "missing_mac"
+	ProblemUnknownKeyType    ProblemKind = "unknown_key_type"
+	ProblemPublicKeyMismatch ProblemKind = "public_key_mismatch"
+	ProblemDuplicateID       ProblemKind = "duplicate_id"
+)
+
+// AccountProblem is one issue found by FileAccountStore.Verify. Permission
+// is empty for problems with the file as a whole.
+type AccountProblem struct {
+	File       string
+	Account    string
+	Permission string
+	Kind       ProblemKind
+	Detail     string
+}
+
+func (p AccountProblem) String() string {
+	where := p.File
+	if p.Permission != "" {
+		where += " " + p.Permission
+	}
+	return fmt.Sprintf("%v: %v: %v", where, p.Kind, p.Detail)
+}
+
+// Verify scans every keystore in the account directory and reports what is
+// wrong with each, without stopping at the first bad file. Only a failure to
+// read the directory itself is returned as an error. Public keys of
+// encrypted keypairs cannot be checked without the password and are skipped.
+func (s *FileAccountStore) Verify() ([]AccountProblem, error) {
+	files, err := s.readDir("")
+	if err != nil {
+		return nil, err
+	}
+	problems := make([]AccountProblem, 0)
+	seenIDs := map[string]string{} // keypair id -> file it was first seen in
+	for _, f := range files {
+		if f.IsDir() || s.keystoreExt(f.Name()) == "" {
+			continue
+		}
+		fileName := s.path(f.Name())
+		report := func(account, perm string, kind ProblemKind, detail string) {
+			problems = append(problems, AccountProblem{File: fileName, Account: account, Permission: perm, Kind: kind, Detail: detail})
+		}
+		data, err := s.readFile(f.Name())
+		if err == nil {
+			data, err = gunzipKeystore(data, fileName)
+		}
+		if err != nil {
+			report("", "", ProblemUnparseable, err.Error())
+			continue
+		}
+		a := NewAccountInfo()
+		if err := s.unmarshal(data, a); err != nil {
+			report("", "", ProblemUnparseable, err.Error())
+			continue
+		}
+		hasNil := false
+		for _, perm := range a.Permissions() {
+			kp := a.Keypairs[perm]
+			if kp == nil {
+				report(a.Name, perm, ProblemInvalidKeyPair, "null keypair")
+				hasNil = true
+				continue
+			}
+			if kp.ID != "" {
+				if first, ok := seenIDs[kp.ID]; ok {
+					report(a.Name, perm, ProblemDuplicateID, fmt.Sprintf("keypair id %v also used in %v", kp.ID, first))
+				} else {
+					seenIDs[kp.ID] = fileName
+				}
+			}
+			if _, err := ParseKeyType(string(kp.KeyType)); err != nil {
+				report(a.Name, perm, ProblemUnknownKeyType, err.Error())
+				continue
+			}
+			if kp.EncryptedKey != "" && kp.Mac == "" {
+				report(a.Name, perm, ProblemMissingMAC, "encrypted key has no mac")
+				continue
+			}
+			if err := kp.Validate(); err != nil {
+				report(a.Name, perm, ProblemInvalidKeyPair, err.Error())
+				continue
+			}
+			if kp.IsEncrypted() || kp.IsWatchOnly() {
+				continue
+			}
+			if err := kp.VerifyPublicKey(); err != nil && !errors.Is(err, ErrUnsupportedKey) {
+				report(a.Name, perm, ProblemPublicKeyMismatch, err.Error())
+			}
+		}
+		if !hasNil && a.Checksum != "" && a.Checksum != a.computeChecksum() {
+			report(a.Name, "", ProblemChecksum, "checksum does not match keypairs")
+		}
+	}
+	return problems, nil
+}
+
+// FindByID locates the keypair with the given ID, returning the account and
+// permission holding it.
+func (s *FileAccountStore) FindByID(id string) (*AccountInfo, string, error) {
+	accs, err := s.ListAccounts()
+	if err != nil {
+		return nil, "", err
+	}
+	for _, a := range accs {
+		for _, perm := range a.Permissions() {
+			if a.Keypairs[perm].ID == id {
+				return a, perm, nil
+			}
+		}
+	}
+	return nil, "", fmt.Errorf("no keypair with id %v", id)
+}
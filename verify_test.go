@@ -0,0 +1,120 @@
+package sdk
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestVerify(t *testing.T) {
+	s := NewFileAccountStore(t.TempDir())
+	if err := s.Init(); err != nil {
+		t.Fatal(err)
+	}
+	write := func(name string, change func(kp *KeyPairInfo)) *KeyPairInfo {
+		a := NewAccountInfo()
+		a.Name = name
+		kp := newTestKeyPair(t, KeyTypeEd25519)
+		if err := a.AddKeyPair(PermOwner, kp); err != nil {
+			t.Fatal(err)
+		}
+		change(kp)
+		a.Checksum = a.computeChecksum()
+		data, err := json.Marshal(a)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(s.path(name+".json"), data, 0600); err != nil {
+			t.Fatal(err)
+		}
+		return kp
+	}
+	encrypt := func(kp *KeyPairInfo) {
+		if err := kp.EncryptWithParams([]byte("pw"), fastScrypt); err != nil {
+			t.Fatal(err)
+		}
+	}
+	otherPub := newTestKeyPair(t, KeyTypeEd25519).PubKey
+
+	write("a-healthy", func(*KeyPairInfo) {})
+	first := write("b-first", encrypt)
+	write("c-duplicate", func(kp *KeyPairInfo) { kp.ID = first.ID })
+	write("d-missing-mac", func(kp *KeyPairInfo) { encrypt(kp); kp.Mac = "" })
+	write("e-unknown-type", func(kp *KeyPairInfo) { kp.KeyType = "rsa" })
+	write("f-mismatch", func(kp *KeyPairInfo) { kp.PubKey = otherPub })
+	write("g-invalid", func(kp *KeyPairInfo) { encrypt(kp); kp.Salt = "" })
+	write("h-checksum", func(*KeyPairInfo) {})
+	doc, err := os.ReadFile(s.path("h-checksum.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc = []byte(strings.Replace(string(doc), `"name":"h-checksum"`, `"name":"h-renamed"`, 1))
+	for name, data := range map[string][]byte{"h-checksum.json": doc, "i-broken.json": []byte("{"), "README.md": []byte("hi")} {
+		if err := os.WriteFile(s.path(name), data, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	problems, err := s.Verify()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[string][]string{}
+	for _, p := range problems {
+		file := strings.TrimSuffix(p.File[strings.LastIndex(p.File, "/")+1:], ".json")
+		got[file] = append(got[file], fmt.Sprintf("%v %v", p.Permission, p.Kind))
+	}
+	want := map[string][]string{
+		"c-duplicate":    {"owner duplicate_id"},
+		"d-missing-mac":  {"owner missing_mac"},
+		"e-unknown-type": {"owner unknown_key_type"},
+		"f-mismatch":     {"owner public_key_mismatch"},
+		"g-invalid":      {"owner invalid_keypair"},
+		"h-checksum":     {" checksum"},
+		"i-broken":       {" unparseable"},
+	}
+	for file := range got {
+		sort.Strings(got[file])
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Fatalf("Verify() found %v, want %v", got, want)
+	}
+
+	if _, err := NewFileAccountStore(s.path("missing")).Verify(); err == nil {
+		t.Fatal("Verify of a missing directory succeeded")
+	}
+}
+
+func TestFindByID(t *testing.T) {
+	s := NewFileAccountStore(t.TempDir())
+	ids := map[string]string{} // id -> account/perm
+	for _, name := range []string{"alice", "bob"} {
+		a := NewAccountInfo()
+		a.Name = name
+		for _, perm := range []string{PermOwner, PermActive} {
+			kp := newTestKeyPair(t, KeyTypeEd25519)
+			if err := a.AddKeyPair(perm, kp); err != nil {
+				t.Fatal(err)
+			}
+			ids[kp.ID] = name + "/" + perm
+		}
+		if err := s.SaveAccount(a); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for id, want := range ids {
+		a, perm, err := s.FindByID(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := a.Name + "/" + perm; got != want {
+			t.Fatalf("FindByID(%v) = %v, want %v", id, got, want)
+		}
+	}
+	if _, _, err := s.FindByID(newKeyPairID()); err == nil {
+		t.Fatal("FindByID found an unknown id")
+	}
+}
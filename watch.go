@@ -0,0 +1,127 @@
+package sdk
+
+import (
+	"context"
+	"fmt"
+	"github.com/fsnotify/fsnotify"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	AccountCreated  = "created"
+	AccountModified = "modified"
+	AccountDeleted  = "deleted"
+)
+
+// AccountEvent reports that the keystore of account Name was created,
+// modified or deleted.
+type AccountEvent struct {
+	Name string
+	Op   string
+}
+
+// watchDebounce is how long Watch waits for an account's file to settle.
+// A single SaveAccount moves the old file away and renames a new one in,
+// which should be reported once.
+const watchDebounce = 100 * time.Millisecond
+
+// Watch reports changes to the keystores in the account directory, whoever
+// makes them. Changes to an account within watchDebounce of each other are
+// coalesced into one event describing where the account ended up. The
+// backup directory is not watched. The channel is closed once ctx is done.
+func (s *FileAccountStore) Watch(ctx context.Context) (<-chan AccountEvent, error) {
+	if s.FS != nil {
+		return nil, fmt.Errorf("cannot watch an account store backed by an fs.FS")
+	}
+	files, err := os.ReadDir(s.AccountDir)
+	if err != nil {
+		return nil, err
+	}
+	known := make(map[string]bool)
+	for _, f := range files {
+		if name := s.accountName(f.Name()); name != "" && !f.IsDir() {
+			known[name] = true
+		}
+	}
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	if err := w.Add(s.AccountDir); err != nil {
+		w.Close()
+		return nil, err
+	}
+	events := make(chan AccountEvent)
+	go s.watch(ctx, w, known, events)
+	return events, nil
+}
+
+func (s *FileAccountStore) watch(ctx context.Context, w *fsnotify.Watcher, known map[string]bool, events chan<- AccountEvent) {
+	defer close(events)
+	defer w.Close()
+	pending := make(map[string]bool)
+	settle := time.NewTimer(watchDebounce)
+	settle.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case e, ok := <-w.Events:
+			if !ok {
+				return
+			}
+			if name := s.accountName(filepath.Base(e.Name)); name != "" {
+				pending[name] = true
+				settle.Reset(watchDebounce)
+			}
+		case err, ok := <-w.Errors:
+			if !ok {
+				return
+			}
+			currentLogger().Warnf("watching %v: %v", s.AccountDir, err)
+		case <-settle.C:
+			names := make([]string, 0, len(pending))
+			for name := range pending {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			pending = make(map[string]bool)
+			for _, name := range names {
+				e := AccountEvent{Name: name}
+				_, err := os.Stat(s.AccountDir + "/" + s.keystoreFile(name))
+				switch {
+				case err == nil && known[name]:
+					e.Op = AccountModified
+				case err == nil:
+					e.Op, known[name] = AccountCreated, true
+				case known[name]:
+					e.Op = AccountDeleted
+					delete(known, name)
+				default:
+					// created and removed again before it settled
+					continue
+				}
+				select {
+				case events <- e:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}
+}
+
+// accountName returns the account a file in the account directory holds,
+// or "" for anything that is not a keystore, such as SaveTo's temporary
+// files.
+func (s *FileAccountStore) accountName(file string) string {
+	ext := s.keystoreExt(file)
+	if ext == "" || strings.HasPrefix(file, ".") {
+		return ""
+	}
+	return strings.TrimSuffix(file, ext)
+}
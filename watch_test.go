@@ -0,0 +1,93 @@
+package sdk
+
+import (
+	"context"
+	"os"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestWatch(t *testing.T) {
+	s := NewFileAccountStore(t.TempDir())
+	writeTestAccounts(t, s, 1)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events, err := s.Watch(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	save := func(name string) func(t *testing.T) {
+		return func(t *testing.T) {
+			a := NewAccountInfo()
+			a.Name = name
+			if err := a.AddKeyPair(PermOwner, newTestKeyPair(t, KeyTypeEd25519)); err != nil {
+				t.Fatal(err)
+			}
+			if err := s.SaveAccount(a); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	remove := func(name string) func(t *testing.T) {
+		return func(t *testing.T) {
+			if err := s.DeleteAccount(name); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	tests := []struct {
+		name string
+		run  func(t *testing.T)
+		want AccountEvent
+	}{
+		{"create", save("alice"), AccountEvent{"alice", AccountCreated}},
+		{"modify", save("alice"), AccountEvent{"alice", AccountModified}},
+		{"modify existing", save("acc000"), AccountEvent{"acc000", AccountModified}},
+		{"delete", remove("alice"), AccountEvent{"alice", AccountDeleted}},
+		{"ignore other files", func(t *testing.T) {
+			if err := os.WriteFile(s.AccountDir+"/notes.txt", []byte("hi"), 0600); err != nil {
+				t.Fatal(err)
+			}
+			// created and deleted before it settles, so not reported
+			save("ghost")(t)
+			remove("ghost")(t)
+			save("bob")(t)
+		}, AccountEvent{"bob", AccountCreated}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.run(t)
+			select {
+			case got := <-events:
+				if got != tt.want {
+					t.Fatalf("event = %+v, want %+v", got, tt.want)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatalf("no event, want %+v", tt.want)
+			}
+		})
+	}
+
+	cancel()
+	select {
+	case e, ok := <-events:
+		if ok {
+			t.Fatalf("event %+v after cancel", e)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("channel not closed after cancel")
+	}
+}
+
+func TestWatchRejects(t *testing.T) {
+	s := NewFileAccountStore(t.TempDir() + "/missing")
+	if _, err := s.Watch(context.Background()); !os.IsNotExist(err) {
+		t.Fatalf("missing directory: Watch() = %v", err)
+	}
+	s = NewFileAccountStore(".")
+	s.FS = fstest.MapFS{}
+	if _, err := s.Watch(context.Background()); err == nil {
+		t.Fatal("fs.FS-backed store watched")
+	}
+}
@@ -0,0 +1,30 @@
+package sdk
+
+import (
+	"fmt"
+)
+
+// NewWatchOnlyKeyPair returns a keypair holding just the base58 public key
+// pub, for accounts that are monitored but never sign.
+func NewWatchOnlyKeyPair(pub string, keyType string) (*KeyPairInfo, error) {
+	kt, err := ParseKeyType(keyType)
+	if err != nil {
+		return nil, err
+	}
+	kp := &KeyPairInfo{ID: newKeyPairID(), KeyType: kt, PubKey: pub}
+	if err := kp.Validate(); err != nil {
+		return nil, fmt.Errorf("watch-only keypair: %w", err)
+	}
+	return kp, nil
+}
+
+// IsWatchOnly reports whether the account has keypairs and all of them are
+// watch-only.
+func (a *AccountInfo) IsWatchOnly() bool {
+	for _, kp := range a.Keypairs {
+		if !kp.IsWatchOnly() {
+			return false
+		}
+	}
+	return len(a.Keypairs) > 0
+}
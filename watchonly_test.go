@@ -0,0 +1,132 @@
+package sdk
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestWatchOnlyAccount(t *testing.T) {
+	full := newTestKeyPair(t, KeyTypeP256)
+	kp, err := NewWatchOnlyKeyPair(full.PubKey, "p256")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !kp.IsWatchOnly() || kp.IsEncrypted() || full.IsWatchOnly() {
+		t.Fatalf("watch-only %v, encrypted %v", kp.IsWatchOnly(), kp.IsEncrypted())
+	}
+	s := NewFileAccountStore(t.TempDir())
+	a := NewAccountInfo()
+	a.Name = "watcher"
+	if err := a.AddKeyPair(PermOwner, kp); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SaveAccount(a); err != nil {
+		t.Fatal(err)
+	}
+	accs, err := s.ListAccounts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(accs) != 1 || !accs[0].IsWatchOnly() || accs[0].Keypairs[PermOwner].PubKey != full.PubKey {
+		t.Fatalf("ListAccounts() = %v", accs)
+	}
+	loaded := accs[0]
+	if _, err := loaded.Sign(PermOwner, []byte("msg")); !errors.Is(err, ErrWatchOnly) {
+		t.Fatalf("Sign() = %v, want %v", err, ErrWatchOnly)
+	}
+	if err := loaded.Keypairs[PermOwner].EncryptWithParams([]byte("pw"), fastScrypt); !errors.Is(err, ErrWatchOnly) {
+		t.Fatalf("EncryptWithParams() = %v, want %v", err, ErrWatchOnly)
+	}
+	if _, err := loaded.Keypairs[PermOwner].ToKeyPair(); !errors.Is(err, ErrWatchOnly) {
+		t.Fatalf("ToKeyPair() = %v, want %v", err, ErrWatchOnly)
+	}
+}
+
+func TestAccountIsWatchOnly(t *testing.T) {
+	watchOnly := func(t *testing.T) *KeyPairInfo {
+		kp, err := NewWatchOnlyKeyPair(newTestKeyPair(t, KeyTypeEd25519).PubKey, "ed25519")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return kp
+	}
+	full := func(t *testing.T) *KeyPairInfo { return newTestKeyPair(t, KeyTypeEd25519) }
+	tests := []struct {
+		name     string
+		keypairs []func(*testing.T) *KeyPairInfo
+		want     bool
+	}{
+		{"empty", nil, false},
+		{"watch-only", []func(*testing.T) *KeyPairInfo{watchOnly, watchOnly}, true},
+		{"mixed", []func(*testing.T) *KeyPairInfo{watchOnly, full}, false},
+		{"full", []func(*testing.T) *KeyPairInfo{full}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := NewAccountInfo()
+			for i, kp := range tt.keypairs {
+				if err := a.AddKeyPair([]string{PermOwner, PermActive}[i], kp(t)); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if got := a.IsWatchOnly(); got != tt.want {
+				t.Fatalf("IsWatchOnly() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewWatchOnlyKeyPairRejects(t *testing.T) {
+	pub := newTestKeyPair(t, KeyTypeEd25519).PubKey
+	tests := []struct {
+		name    string
+		pub     string
+		keyType string
+		wantErr error
+	}{
+		{"unknown key type", pub, "rsa", ErrUnsupportedKey},
+		{"not base58", "0OIl", "ed25519", ErrInvalidKeyPair},
+		{"empty", "", "ed25519", ErrInvalidKeyPair},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewWatchOnlyKeyPair(tt.pub, tt.keyType); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("NewWatchOnlyKeyPair() = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestEachSkipsKeylessPermissions(t *testing.T) {
+	a := NewAccountInfo()
+	a.Name = "alice"
+	if err := a.AddKeyPair(PermOwner, newTestKeyPair(t, KeyTypeEd25519)); err != nil {
+		t.Fatal(err)
+	}
+	watchOnly, err := NewWatchOnlyKeyPair(newTestKeyPair(t, KeyTypeEd25519).PubKey, "ed25519")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := a.AddKeyPair("cold", watchOnly); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.RegisterSigner("hw", KeyTypeEd25519, &mockSigner{kp: newTestKeyPair(t, KeyTypeEd25519)}); err != nil {
+		t.Fatal(err)
+	}
+	passwords := map[string][]byte{PermOwner: []byte("pw")}
+	if err := a.EncryptEach(passwords); err != nil {
+		t.Fatal(err)
+	}
+	if !a.Keypairs[PermOwner].IsEncrypted() || a.Keypairs["cold"].IsEncrypted() || a.Keypairs["hw"].IsEncrypted() {
+		t.Fatal("EncryptEach did not encrypt just the owner key")
+	}
+	if err := a.DecryptEach(passwords); err != nil {
+		t.Fatal(err)
+	}
+	if a.Keypairs[PermOwner].IsEncrypted() {
+		t.Fatal("DecryptEach left the owner key encrypted")
+	}
+	if err := a.EncryptEach(map[string][]byte{"cold": []byte("pw")}); err == nil {
+		t.Fatal("EncryptEach succeeded without the owner password")
+	}
+}